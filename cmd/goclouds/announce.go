@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log/slog"
+	"os/exec"
+	"sync/atomic"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	windowTitle      = "Cloud Generation"
+	announceInterval = 2.0 // Seconds between checks for a changed description
+)
+
+// Announcer keeps the window title describing the scene, which screen readers
+// read out when it changes, and the window icon showing the weather, so both
+// can be read at a glance from the taskbar. It optionally speaks each change
+// aloud.
+type Announcer struct {
+	last     string
+	nextPoll float64
+	speaking atomic.Bool // A spoken announcement hasn't finished yet
+	icon     iconKey     // What the window icon shows
+	iconSet  bool
+}
+
+// updateAnnouncer re-describes the scene every few seconds and announces it
+// when the description has changed
+func (g *Game) updateAnnouncer() {
+	a := &g.announcer
+	if g.SimTime < a.nextPoll {
+		return
+	}
+	a.nextPoll = g.SimTime + announceInterval
+	g.updateIcon()
+
+	text := g.Describe()
+	if text == a.last {
+		return
+	}
+	a.last = text
+	ebiten.SetWindowTitle(windowTitle + " - " + text)
+
+	// A voice slower than the changes would otherwise pile up processes,
+	// so changes made while it talks are only shown in the title
+	if speak := g.config.current.Speak; speak != "" && !a.speaking.Load() {
+		// Started rather than waited on so a slow voice never stalls a frame
+		cmd := exec.Command(speak, text)
+		if err := cmd.Start(); err != nil {
+			slog.Warn("speaking description", "program", speak, "err", err)
+			return
+		}
+		a.speaking.Store(true)
+		go func() {
+			cmd.Wait()
+			a.speaking.Store(false)
+		}()
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const autosaveInterval = 30.0 // Seconds between autosaves
+
+// autosavePath is where the scene is periodically snapshotted so it can be
+// recovered after a crash or an accidental ESC
+var autosavePath = filepath.Join(os.TempDir(), "goclouds-autosave.json")
+
+// autosave writes the scene to a temporary file first and renames it into
+// place, so a crash mid-write never leaves a truncated snapshot behind
+func (g *Game) autosave() error {
+	data, err := json.Marshal(g.scene())
+	if err != nil {
+		return err
+	}
+	tmp := autosavePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, autosavePath)
+}
+
+func (g *Game) updateAutosave() {
+	if g.kiosk || g.SimTime < g.nextAutosave {
+		return
+	}
+	g.nextAutosave = g.SimTime + autosaveInterval
+	if err := g.autosave(); err != nil {
+		g.setStatus("Autosave failed: " + err.Error())
+	}
+	g.saveWindow() // So a crash doesn't lose the layout either
+}
+
+// hasAutosave reports whether a previous session left a snapshot behind
+func hasAutosave() bool {
+	_, err := os.Stat(autosavePath)
+	return !errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+)
+
+const (
+	glyphWidth    = 6  // Width of a character of the built-in font
+	glyphHeight   = 16 // Height of a line of the built-in font
+	maxBannerSize = 4
+)
+
+// bannerPositions are where a banner can sit on the screen
+var bannerPositions = []string{"top", "middle", "bottom"}
+
+// Banner is a line of text shown over the scene, such as an announcement
+// on a kiosk or stream, either still or scrolling across as a ticker. It
+// comes from the config file, so saving a change to the file updates it
+// while the app runs.
+type Banner struct {
+	Text       string  `json:"text"`       // Empty shows no banner
+	Color      string  `json:"color"`      // Text color, "#rrggbb" or "#rrggbbaa"
+	Background string  `json:"background"` // Color of the strip behind the text, fully see-through for none
+	Position   string  `json:"position"`   // One of bannerPositions
+	Size       int     `json:"size"`       // Times the size of the built-in font, 1-4
+	Scroll     float64 `json:"scroll"`     // Pixels a second the text scrolls left, 0 holds it still in the middle
+}
+
+var defaultBanner = Banner{Color: "#ffffff", Background: "#00000099", Position: "bottom", Size: 2}
+
+// validate puts back colors, a position or a size that can't be used,
+// reporting what it had to change
+func (b *Banner) validate() []error {
+	var problems []error
+	if _, err := scene.ParseHexColor(b.Color); err != nil {
+		problems = append(problems, fmt.Errorf("banner.color: %w", err))
+		b.Color = defaultBanner.Color
+	}
+	if _, err := scene.ParseHexColor(b.Background); err != nil {
+		problems = append(problems, fmt.Errorf("banner.background: %w", err))
+		b.Background = defaultBanner.Background
+	}
+	if !slices.Contains(bannerPositions, b.Position) {
+		problems = append(problems, fmt.Errorf("banner.position %q is not one of %s", b.Position, strings.Join(bannerPositions, ", ")))
+		b.Position = defaultBanner.Position
+	}
+	if b.Size < 1 || b.Size > maxBannerSize {
+		problems = append(problems, fmt.Errorf("banner.size %d is outside 1-%d", b.Size, maxBannerSize))
+		b.Size = min(maxBannerSize, max(1, b.Size))
+	}
+	if b.Scroll < 0 {
+		problems = append(problems, fmt.Errorf("banner.scroll %.0f is negative", b.Scroll))
+		b.Scroll = 0
+	}
+	return problems
+}
+
+// BannerView is the banner's text drawn once at the font's own size, and
+// how far it has scrolled
+type BannerView struct {
+	text   string
+	image  *ebiten.Image
+	offset float64
+}
+
+// updateBanner scrolls the ticker on, starting it again from the right once
+// it has gone off the left
+func (g *Game) updateBanner(dt float64) {
+	b := g.config.current.Banner
+	v := &g.banner
+	if b.Text == "" || b.Scroll == 0 {
+		v.offset = 0
+		return
+	}
+	width := float64(len([]rune(bannerLine(b.Text))) * glyphWidth * b.Size)
+	v.offset += b.Scroll * dt
+	if v.offset > float64(screenWidth)+width {
+		v.offset = 0
+	}
+}
+
+// bannerLine is the banner's text on one line
+func bannerLine(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "\n", "   ")), " ")
+}
+
+// drawBanner draws the banner over the scene, on a strip across the screen
+func (g *Game) drawBanner(screen *ebiten.Image) {
+	b := g.config.current.Banner
+	v := &g.banner
+	if b.Text == "" {
+		return
+	}
+	line := bannerLine(b.Text)
+	if v.image == nil || v.text != line {
+		if v.image != nil {
+			v.image.Deallocate()
+		}
+		v.text = line
+		v.image = ebiten.NewImage(max(1, len([]rune(line))*glyphWidth), glyphHeight)
+		ebitenutil.DebugPrint(v.image, line)
+	}
+
+	size := float64(b.Size)
+	width, height := float64(v.image.Bounds().Dx())*size, glyphHeight*size
+	pad := 4 * size
+	var y float64
+	switch b.Position {
+	case "top":
+		y = 24
+	case "middle":
+		y = (float64(screenHeight) - height) / 2
+	default:
+		y = float64(screenHeight) - height - 2*pad - 40 // Above the status line and tabs
+	}
+	x := (float64(screenWidth) - width) / 2
+	if b.Scroll > 0 {
+		x = float64(screenWidth) - v.offset
+	}
+
+	bg, _ := scene.ParseHexColor(b.Background)
+	if bg.A > 0 {
+		drawRect(screen, 0, y, float64(screenWidth), height+2*pad, bg)
+	}
+	fg, _ := scene.ParseHexColor(b.Color)
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(size, size)
+	opts.GeoM.Translate(x, y+pad)
+	opts.ColorScale.ScaleWithColor(fg)
+	screen.DrawImage(v.image, opts)
+}
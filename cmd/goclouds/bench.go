@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	benchSeed     = 1 // The same heavy scene every run, so runs compare
+	benchMaxTrees = 20
+	benchWind     = 5 // The strongest wind the config allows, a storm
+)
+
+// Bench times frames of a heavy scene for -bench and reports on them, so
+// runs on different releases can be compared
+type Bench struct {
+	duration time.Duration
+	started  time.Time
+	last     time.Time       // When the last frame started
+	frames   []time.Duration // How long each frame took
+	before   runtime.MemStats
+}
+
+// benchConfig is cfg turned up as far as it goes: every cloud in the sky,
+// the most trees and a storm's wind, always from the same seed
+func benchConfig(cfg Config) Config {
+	cfg.CloudCount = scene.MaxClouds
+	cfg.TreeCount = benchMaxTrees
+	cfg.Density = 1
+	cfg.Wind = benchWind
+	cfg.Seed = benchSeed
+	cfg.ReducedMotion = false
+	cfg.Soak, cfg.LowEnd = false, false
+	cfg.WorldWidth = 1
+	return cfg
+}
+
+func newBench(duration time.Duration) *Bench {
+	b := &Bench{duration: duration, frames: make([]time.Duration, 0, int(duration.Seconds())*240)}
+	runtime.GC()
+	runtime.ReadMemStats(&b.before)
+	b.started = time.Now()
+	b.last = b.started
+	return b
+}
+
+// frame records the time since the last frame started
+func (b *Bench) frame() {
+	now := time.Now()
+	b.frames = append(b.frames, now.Sub(b.last))
+	b.last = now
+}
+
+// done reports whether the run has gone on long enough
+func (b *Bench) done() bool {
+	return time.Since(b.started) >= b.duration
+}
+
+// report prints frame time percentiles and what the run allocated
+func (b *Bench) report(out io.Writer, mode string) {
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	elapsed := time.Since(b.started)
+	frames := slices.Clone(b.frames)
+	slices.Sort(frames)
+	n := max(1, len(frames))
+	percentile := func(p float64) time.Duration {
+		if len(frames) == 0 {
+			return 0
+		}
+		return frames[min(len(frames)-1, int(p*float64(len(frames))))]
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+	fmt.Fprintf(out, "benchmark: %s, %dx%d, %d frames in %.1fs (%.1f FPS)\n",
+		mode, screenWidth, screenHeight, len(frames), elapsed.Seconds(), float64(len(frames))/elapsed.Seconds())
+	fmt.Fprintf(out, "frame time ms: p50 %.2f  p90 %.2f  p99 %.2f  max %.2f\n",
+		ms(percentile(0.5)), ms(percentile(0.9)), ms(percentile(0.99)), ms(percentile(1)))
+	fmt.Fprintf(out, "allocations: %d (%.1f a frame), %.1f MB (%.1f KB a frame)\n",
+		after.Mallocs-b.before.Mallocs, float64(after.Mallocs-b.before.Mallocs)/float64(n),
+		float64(after.TotalAlloc-b.before.TotalAlloc)/(1<<20), float64(after.TotalAlloc-b.before.TotalAlloc)/float64(n)/(1<<10))
+	fmt.Fprintf(out, "garbage collections: %d, %.2f ms paused\n",
+		after.NumGC-b.before.NumGC, float64(after.PauseTotalNs-b.before.PauseTotalNs)/float64(time.Millisecond))
+}
+
+// startBench runs the windowed benchmark: the heavy scene with no controls
+// or overlays, drawn as fast as the GPU allows rather than in step with the
+// display, until the time is up
+func (g *Game) startBench(duration time.Duration) {
+	g.kiosk = true
+	g.lowEnd.probed = lowEndProbe // Judging the frame rate would change what is measured
+	g.World.Wetness = 1
+	ebiten.SetVsyncEnabled(false)
+	g.bench = newBench(duration)
+}
+
+// runHeadlessBench runs the benchmark without a window, stepping the heavy
+// scene once a frame and drawing it with the software renderer, which
+// measures the simulation and painter rather than the GPU
+func runHeadlessBench(cfg Config, duration time.Duration, out io.Writer) {
+	w := sim.New(worldOptions(cfg, rand.New(rand.NewSource(cfg.Seed))))
+	painter := render.New()
+	frame := render.NewRaster(screenWidth, screenHeight, screenWidth, screenHeight)
+	var clock sim.Clock
+
+	b := newBench(duration)
+	for !b.done() {
+		w.Wetness = 1 // The storm keeps the ground wet
+		clock.Advance(w, sim.FixedStep)
+		clock.Blend(w, func() { painter.Draw(frame, w) })
+		b.frame()
+	}
+	b.report(out, "headless")
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// keyAction is something a key does that can be bound to another key
+type keyAction struct {
+	name  string // Key in the config file's keys
+	label string
+	key   ebiten.Key // Default
+}
+
+// bindable lists the actions whose keys can be changed on the Controls page
+// or in the config file. Their Shift variants, such as Shift+L exporting
+// the event log, follow the key.
+var bindable = []keyAction{
+	{"menu", "Toggle Menu", ebiten.KeyM},
+	{"densityUp", "Density Up", ebiten.KeyArrowUp},
+	{"densityDown", "Density Down", ebiten.KeyArrowDown},
+	{"shadowDown", "Shadow Down", ebiten.KeyS},
+	{"shadowUp", "Shadow Up", ebiten.KeyD},
+	{"template", "Next Template", ebiten.KeyG},
+	{"prop", "Place Prop", ebiten.KeyP},
+	{"note", "Sticky Note", ebiten.KeyT},
+	{"ruler", "Ruler", ebiten.KeyR},
+	{"eventLog", "Event Log", ebiten.KeyL},
+	{"rewind", "Rewind", ebiten.KeyB},
+	{"uptime", "Uptime", ebiten.KeyU},
+	{"diagnostics", "Diagnostics", ebiten.KeyF3},
+	{"frameGraph", "Frame Graph", ebiten.KeyF2},
+	{"probes", "Light Probes", ebiten.KeyF4},
+	{"hud", "Hide HUD", ebiten.KeyF1},
+	{"map", "Map View", ebiten.KeyA},
+	{"delete", "Delete", ebiten.KeyDelete},
+	{"screenshot", "Screenshot", ebiten.KeyF12},
+	{"record", "Record GIF", ebiten.KeyF9},
+}
+
+// reservedKeys keep their fixed meanings and can't be bound: menu and text
+// navigation, slots, and the letters that also go with Ctrl
+var reservedKeys = []ebiten.Key{
+	ebiten.KeyEscape, ebiten.KeyTab, ebiten.KeyEnter, ebiten.KeySpace, ebiten.KeyBackspace,
+	ebiten.KeyArrowLeft, ebiten.KeyArrowRight,
+	ebiten.Key1, ebiten.Key2, ebiten.Key3, ebiten.Key4, ebiten.Key5, ebiten.Key6, ebiten.Key7, ebiten.Key8, ebiten.Key9,
+	ebiten.KeyC, ebiten.KeyE, ebiten.KeyF, ebiten.KeyH, ebiten.KeyN,
+}
+
+// modifierKeys are skipped while waiting for a key to bind, as they are
+// held for the Shift and Ctrl variants
+var modifierKeys = []ebiten.Key{
+	ebiten.KeyShiftLeft, ebiten.KeyShiftRight, ebiten.KeyControlLeft, ebiten.KeyControlRight,
+	ebiten.KeyAltLeft, ebiten.KeyAltRight, ebiten.KeyMetaLeft, ebiten.KeyMetaRight,
+}
+
+// Bindings maps each bindable action to the name of its key as Ebiten
+// names them, such as "M", "ArrowUp" or "F3". Actions left out keep their
+// default keys.
+type Bindings map[string]string
+
+func defaultBindings() Bindings {
+	b := make(Bindings, len(bindable))
+	for _, a := range bindable {
+		b[a.name] = a.key.String()
+	}
+	return b
+}
+
+// key returns the key bound to an action
+func (b Bindings) key(name string) ebiten.Key {
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(b[name])); err == nil {
+		return k
+	}
+	if a, ok := findAction(name); ok {
+		return a.key
+	}
+	panic("unknown action " + name)
+}
+
+func findAction(name string) (keyAction, bool) {
+	i := slices.IndexFunc(bindable, func(a keyAction) bool { return a.name == name })
+	if i < 0 {
+		return keyAction{}, false
+	}
+	return bindable[i], true
+}
+
+// boundTo returns the action other than name already bound to key, if any
+func (b Bindings) boundTo(key ebiten.Key, name string) (string, bool) {
+	for _, a := range bindable {
+		if a.name != name && b.key(a.name) == key {
+			return a.label, true
+		}
+	}
+	return "", false
+}
+
+// validate drops unknown actions and puts back the default key for any
+// action bound to an unknown or reserved key, or sharing a key with another
+// action, reporting what it had to change
+func (b Bindings) validate() []error {
+	var problems []error
+	for name, key := range b {
+		if _, ok := findAction(name); !ok {
+			problems = append(problems, fmt.Errorf("keys.%s is not a bindable action", name))
+			delete(b, name)
+			continue
+		}
+		var k ebiten.Key
+		if err := k.UnmarshalText([]byte(key)); err != nil {
+			problems = append(problems, fmt.Errorf("keys.%s: unknown key %q", name, key))
+			delete(b, name)
+		} else if slices.Contains(reservedKeys, k) || slices.Contains(modifierKeys, k) {
+			problems = append(problems, fmt.Errorf("keys.%s: %s is reserved", name, key))
+			delete(b, name)
+		}
+	}
+	// Defaults never clash, so giving back one changed key a round settles it
+	for {
+		clash := false
+		for _, a := range bindable {
+			// Of two actions sharing a key, the one moved off its default
+			// gives it up
+			other, ok := b.boundTo(b.key(a.name), a.name)
+			if !ok || b.key(a.name) == a.key {
+				continue
+			}
+			problems = append(problems, fmt.Errorf("keys.%s: %s is also bound to %s", a.name, b.key(a.name), other))
+			delete(b, a.name)
+			clash = true
+			break
+		}
+		if !clash {
+			return problems
+		}
+	}
+}
+
+// pressed reports whether an action's key went down this frame, without
+// Ctrl so that Ctrl shortcuts sharing a letter don't trigger it
+func (g *Game) pressed(name string) bool {
+	return inpututil.IsKeyJustPressed(g.keys.key(name)) && !ebiten.IsKeyPressed(ebiten.KeyControl)
+}
+
+// keyName is how an action's key is shown in labels
+func (g *Game) keyName(name string) string {
+	return g.keys.key(name).String()
+}
+
+// startRebinding waits for the next key to bind to an action
+func (g *Game) startRebinding(name string) {
+	g.menu.rebinding = name
+}
+
+// updateRebinding binds the first key pressed to the action waiting for
+// one, or gives up on Escape, reporting whether it took the keys this frame
+func (g *Game) updateRebinding() bool {
+	name := g.menu.rebinding
+	if name == "" {
+		return false
+	}
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		if slices.Contains(modifierKeys, k) {
+			continue
+		}
+		g.menu.rebinding = ""
+		if k != ebiten.KeyEscape {
+			g.rebind(name, k)
+		}
+		return true // The key doesn't also do what it is now bound to
+	}
+	return true
+}
+
+// rebind binds an action to a key and saves the bindings to the config file
+func (g *Game) rebind(name string, key ebiten.Key) {
+	if slices.Contains(reservedKeys, key) {
+		g.setStatus(key.String() + " is reserved and can't be bound")
+		return
+	}
+	if other, ok := g.keys.boundTo(key, name); ok {
+		g.setStatus(key.String() + " is already bound to " + other)
+		return
+	}
+	keys := maps.Clone(g.keys)
+	keys[name] = key.String()
+	g.setKeys(keys)
+}
+
+// resetKeys puts back the default key for every action
+func (g *Game) resetKeys() {
+	g.setKeys(defaultBindings())
+}
+
+// setKeys switches to a new set of bindings and writes them to the config
+// file, leaving the rest of it as it is
+func (g *Game) setKeys(keys Bindings) {
+	g.keys = keys
+	if err := g.saveConfig(func(c *Config) { c.Keys = keys }); err != nil {
+		g.setStatus("Keys changed but not saved: " + err.Error())
+		return
+	}
+	g.config.current.Keys = keys
+	g.setStatus("Keys saved to " + g.config.path)
+}
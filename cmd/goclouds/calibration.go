@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+)
+
+const (
+	neutralTemperature = 6500.0 // White point the scene's colors are tuned for, in kelvin
+	calibrationSave    = 1.0    // Seconds after the last change before calibration is written to the config file
+)
+
+// Calibration adjusts the finished scene for the display it is shown on:
+// a white point to make up for a warm or cool screen, and a gain for each
+// channel. It belongs to the display rather than the scene, so it is kept
+// in the config file.
+type Calibration struct {
+	Temperature float64 `json:"temperature"` // White point in kelvin, below 6500 warmer and above cooler
+	Red         float64 `json:"red"`
+	Green       float64 `json:"green"`
+	Blue        float64 `json:"blue"`
+}
+
+// defaultCalibration leaves the colors as they are
+var defaultCalibration = Calibration{Temperature: neutralTemperature, Red: 1, Green: 1, Blue: 1}
+
+// validate clamps the calibration to the slider ranges, reporting what it
+// had to change
+func (c *Calibration) validate() []error {
+	var problems []error
+	if c.Temperature < 3000 || c.Temperature > 10000 {
+		problems = append(problems, fmt.Errorf("calibration temperature %.0f is outside 3000-10000", c.Temperature))
+		c.Temperature = math.Max(3000, math.Min(10000, c.Temperature))
+	}
+	for _, gain := range []struct {
+		name string
+		v    *float64
+	}{{"red", &c.Red}, {"green", &c.Green}, {"blue", &c.Blue}} {
+		if *gain.v < 0.5 || *gain.v > 1.5 {
+			problems = append(problems, fmt.Errorf("calibration %s %.2f is outside 0.5-1.5", gain.name, *gain.v))
+			*gain.v = math.Max(0.5, math.Min(1.5, *gain.v))
+		}
+	}
+	return problems
+}
+
+// scale is what each channel of the scene is multiplied by. The white
+// point is taken relative to 6500K and scaled so its strongest channel
+// stays at full, so shifting it tints the scene without washing it out.
+func (c Calibration) scale() (r, g, b float32) {
+	wr, wg, wb := whitePoint(c.Temperature)
+	nr, ng, nb := whitePoint(neutralTemperature)
+	wr, wg, wb = wr/nr, wg/ng, wb/nb
+	top := math.Max(wr, math.Max(wg, wb))
+	return float32(wr / top * c.Red), float32(wg / top * c.Green), float32(wb / top * c.Blue)
+}
+
+// whitePoint approximates the color of a black body at a temperature in
+// kelvin, each channel 0-1, after Tanner Helland's fit to the blackbody
+// curve
+func whitePoint(kelvin float64) (r, g, b float64) {
+	t := kelvin / 100
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(255, v)) / 255 }
+	if t <= 66 {
+		r = 1
+		g = clamp(99.4708025861*math.Log(t) - 161.1195681661)
+	} else {
+		r = clamp(329.698727446 * math.Pow(t-60, -0.1332047592))
+		g = clamp(288.1221695283 * math.Pow(t-60, -0.0755148492))
+	}
+	switch {
+	case t >= 66:
+		b = 1
+	case t <= 19:
+		b = 0
+	default:
+		b = clamp(138.5177312231*math.Log(t-10) - 305.0447927307)
+	}
+	return r, g, b
+}
+
+// calibrationWidgets set the white point and channel gains, which apply to
+// the scene at once and are saved to the config file shortly after
+func (g *Game) calibrationWidgets() []widget {
+	cal := func() *Calibration { return &g.config.current.Calibration }
+	gain := func(name string, v func() *float64) widget {
+		return &Slider{
+			label:  func() string { return fmt.Sprintf("%s Gain: %.2f", name, *v()) },
+			value:  func() float64 { return *v() },
+			set:    func(x float64) { *v() = x; g.calibrationChanged() },
+			limits: func() (float64, float64) { return 0.5, 1.5 },
+			step:   0.01,
+			help:   "Strength of " + name + " on this display, to balance a screen that leans towards a color",
+		}
+	}
+	return []widget{
+		&Slider{
+			label:  func() string { return fmt.Sprintf("White Point: %.0fK", cal().Temperature) },
+			value:  func() float64 { return cal().Temperature },
+			set:    func(x float64) { cal().Temperature = x; g.calibrationChanged() },
+			limits: func() (float64, float64) { return 3000, 10000 },
+			step:   100,
+			help:   "6500K leaves colors as they are; lower warms them and higher cools them, for a display that leans the other way",
+		},
+		gain("Red", func() *float64 { return &cal().Red }),
+		gain("Green", func() *float64 { return &cal().Green }),
+		gain("Blue", func() *float64 { return &cal().Blue }),
+		&Button{
+			label: func() string { return "Reset Calibration" },
+			click: func() { *cal() = defaultCalibration; g.calibrationChanged() },
+			help:  "Go back to 6500K and full gains",
+		},
+	}
+}
+
+// calibrationChanged puts off saving the calibration until it has been
+// left alone for a moment, so dragging a slider doesn't write the config
+// file every frame
+func (g *Game) calibrationChanged() {
+	g.config.calibrationSave = calibrationSave
+}
+
+// updateCalibration saves the calibration once it has settled
+func (g *Game) updateCalibration(dt float64) {
+	w := &g.config
+	if w.calibrationSave <= 0 {
+		return
+	}
+	if w.calibrationSave -= dt; w.calibrationSave > 0 {
+		return
+	}
+	cal := w.current.Calibration
+	if err := g.saveConfig(func(c *Config) { c.Calibration = cal }); err != nil {
+		slog.Warn("saving calibration", "path", w.path, "err", err)
+		g.setStatus("Calibration changed but not saved: " + err.Error())
+	}
+}
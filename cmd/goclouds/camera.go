@@ -0,0 +1,225 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	focusZoom       = 2.0   // Zoom when focusing on a tree, prop, cloud or the sun
+	cameraEase      = 6.0   // How quickly the camera closes in on its target, per second
+	doubleClickTime = 0.35  // Seconds between clicks that count as a double click
+	maxZoom         = 8.0   // Furthest the camera zooms in; zoom 1 shows the whole scene
+	wheelZoomStep   = 1.15  // Zoom gained or lost for each notch of the mouse wheel
+	keyPanSpeed     = 600.0 // Screen pixels a second the arrow keys pan the camera
+)
+
+// Camera is the view onto the scene. At zoom 1 it shows a window's worth
+// of the scene, the whole of it unless the world is wider than the window;
+// focusing on something glides it over and zooms in.
+type Camera struct {
+	x, y, zoom                   float64 // View center in scene coordinates
+	targetX, targetY, targetZoom float64
+	lastClick                    float64       // SimTime of the last left click
+	view                         *ebiten.Image // The scene is drawn here, then through the camera onto the screen
+	dragging                     bool          // The middle mouse button is panning
+	dragX, dragY                 int           // Where the cursor was last frame while panning
+}
+
+// resetCamera glides back out to zoom 1, showing the whole scene. In a world
+// wider than the window it stays over the same part of the world, starting
+// in the middle.
+func (g *Game) resetCamera() {
+	x := float64(g.Width) / 2
+	if c := &g.camera; c.zoom != 0 && g.Width > screenWidth {
+		x = c.targetX
+	}
+	g.focusOn(point{x, float64(g.Height) / 2}, 1)
+}
+
+// focusOn glides the camera to center p at the given zoom
+func (g *Game) focusOn(p point, zoom float64) {
+	c := &g.camera
+	c.targetX, c.targetY, c.targetZoom = p.x, p.y, zoom
+	if c.zoom == 0 || g.ReducedMotion {
+		c.x, c.y, c.zoom = c.targetX, c.targetY, c.targetZoom
+	}
+}
+
+// focusAt focuses on whatever is at a point in the scene, or plants a tree
+// or spawns a cloud there when there is nothing
+func (g *Game) focusAt(x, y float64) {
+	dx, dy := x-g.SunX, y-g.SunY
+	if dx*dx+dy*dy <= sim.SunRadius*sim.SunRadius {
+		g.focusOn(point{g.SunX, g.SunY}, focusZoom)
+	} else if i := g.treeAt(x, y); i != -1 {
+		t := g.Trees[i]
+		g.focusOn(point{t.X, t.Y - t.Size*0.6}, focusZoom)
+	} else if i := g.propAt(x, y); i != -1 {
+		p := g.Props[i]
+		g.focusOn(point{p.X, p.Y - p.Size*0.6}, focusZoom)
+	} else if c, ok := g.cloudAt(x, y); ok {
+		g.focusOn(point{c.X, c.Y}, focusZoom)
+	} else {
+		g.plantAt(x, y)
+	}
+}
+
+// doubleClick focuses on what was double-clicked, or plants something on an
+// empty spot. It reports whether the
+// click was the second of a double click, so it isn't also taken as a drag.
+func (g *Game) doubleClick(x, y float64) bool {
+	double := g.SimTime-g.camera.lastClick < doubleClickTime
+	g.camera.lastClick = g.SimTime
+	if double {
+		g.camera.lastClick = math.Inf(-1) // A third click starts over
+		g.focusAt(x, y)
+	}
+	return double
+}
+
+// focusSelection focuses on the selected entity, or shows the whole scene
+// with nothing selected
+func (g *Game) focusSelection() {
+	if pos, ok := g.focusPosition(); ok {
+		g.focusOn(pos, focusZoom)
+	} else {
+		g.resetCamera()
+	}
+}
+
+// updateCamera moves the camera towards its target
+func (g *Game) updateCamera(dt float64) {
+	c := &g.camera
+	t := 1 - math.Exp(-cameraEase*dt)
+	c.zoom += (c.targetZoom - c.zoom) * t
+	c.x += (c.targetX - c.x) * t
+	c.y += (c.targetY - c.y) * t
+
+	// Never show past the edges of the scene
+	halfW := float64(screenWidth) / 2 / c.zoom
+	halfH := float64(screenHeight) / 2 / c.zoom
+	c.x = math.Max(halfW, math.Min(float64(g.Width)-halfW, c.x))
+	c.y = math.Max(halfH, math.Min(float64(g.Height)-halfH, c.y))
+}
+
+// updatePan pans the camera by dragging with the middle mouse button, or
+// sideways with the Left and Right arrow keys when keys asks for them
+func (g *Game) updatePan(keys bool, dt float64) {
+	c := &g.camera
+	x, y := ebiten.CursorPosition()
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+		if c.dragging {
+			g.panCamera(float64(x-c.dragX), float64(y-c.dragY))
+		}
+		c.dragging, c.dragX, c.dragY = true, x, y
+	} else {
+		c.dragging = false
+	}
+	if !keys {
+		return
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
+		g.panCamera(keyPanSpeed*dt, 0)
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyRight) {
+		g.panCamera(-keyPanSpeed*dt, 0)
+	}
+}
+
+// panCamera moves the view along with a drag across the screen, staying
+// where it is left
+func (g *Game) panCamera(dx, dy float64) {
+	c := &g.camera
+	_, _, scale := g.viewArea()
+	c.x -= dx / scale / c.zoom
+	c.y -= dy / scale / c.zoom
+	c.targetX, c.targetY = c.x, c.y
+}
+
+// updateWheelZoom zooms in or out with the mouse wheel, keeping the spot
+// under the cursor where it is. Over the menu, context menu or map the
+// wheel is left alone.
+func (g *Game) updateWheelZoom() {
+	_, notches := ebiten.Wheel()
+	if notches == 0 {
+		return
+	}
+	x, y := g.pointerPosition()
+	fx, fy := float64(x), float64(y)
+	if g.menu.visible && g.menuArea().contains(fx, fy) || g.context.panel != nil && g.context.area().contains(fx, fy) {
+		return
+	}
+	if m, ok := g.mapArea(); ok && m.Contains(fx, fy) {
+		return
+	}
+	g.zoomAt(x, y, math.Pow(wheelZoomStep, notches))
+}
+
+// zoomAt scales the zoom by factor, within 1 and maxZoom, around a screen
+// position
+func (g *Game) zoomAt(x, y int, factor float64) {
+	c := &g.camera
+	before := g.toScene(x, y)
+	c.zoom = math.Max(1, math.Min(maxZoom, c.zoom*factor))
+	after := g.toScene(x, y)
+	c.x += before.x - after.x
+	c.y += before.y - after.y
+	c.targetX, c.targetY, c.targetZoom = c.x, c.y, c.zoom
+}
+
+// viewTransform takes the scene image to the screen: through the camera,
+// then into the part of the window the scene has. Drawing and hit-testing
+// both go through it, so what is under the cursor is what was drawn there.
+func (g *Game) viewTransform() ebiten.GeoM {
+	c := &g.camera
+	var m ebiten.GeoM
+	m.Translate(-c.x, -c.y)
+	m.Scale(c.zoom, c.zoom)
+	m.Translate(float64(screenWidth)/2, float64(screenHeight)/2)
+	left, top, scale := g.viewArea()
+	m.Scale(scale, scale)
+	m.Translate(left, top)
+	return m
+}
+
+// toScene converts a screen position to scene coordinates
+func (g *Game) toScene(x, y int) point {
+	m := g.viewTransform()
+	m.Invert()
+	sx, sy := m.Apply(float64(x), float64(y))
+	return point{sx, sy}
+}
+
+// cursor is the mouse or finger position in scene coordinates
+func (g *Game) cursor() point {
+	return g.toScene(g.pointerPosition())
+}
+
+// viewImage returns the image the scene is drawn on before the camera
+func (g *Game) viewImage() *ebiten.Image {
+	if g.camera.view == nil {
+		g.camera.view = ebiten.NewImage(g.Width, g.Height)
+	}
+	return g.camera.view
+}
+
+// drawView draws the scene image through the camera onto the screen,
+// calibrated for the display
+func (g *Game) drawView(screen *ebiten.Image) {
+	c := &g.camera
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM = g.viewTransform()
+	_, _, scale := g.viewArea()
+	if c.zoom != 1 || scale != 1 {
+		opts.Filter = ebiten.FilterLinear
+	}
+	if cal := g.config.current.Calibration; cal != defaultCalibration {
+		r, gr, b := cal.scale()
+		opts.ColorScale.Scale(r, gr, b, 1)
+	}
+	screen.DrawImage(g.camera.view, opts)
+}
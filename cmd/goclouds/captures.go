@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Captures lists the events that save a picture of the scene, for building
+// a gallery of a long unattended run
+type Captures struct {
+	// Events are matched against each event log entry ignoring case, so
+	// "sunset" or "rain began" capture when the sun sets or rain begins
+	Events   []string `json:"events"`
+	Folder   string   `json:"folder"`   // Where the PNGs are saved, made if need be
+	Cooldown float64  `json:"cooldown"` // Fewest seconds between captures, so a burst of events saves one
+}
+
+var defaultCaptures = Captures{Folder: "captures", Cooldown: 30}
+
+// validate puts back a folder and cooldown that can't be used, reporting
+// what it had to change
+func (c *Captures) validate() []error {
+	var problems []error
+	if c.Folder == "" {
+		problems = append(problems, errors.New("captures.folder is empty"))
+		c.Folder = defaultCaptures.Folder
+	}
+	if c.Cooldown < 0 {
+		problems = append(problems, fmt.Errorf("captures.cooldown %.0f is negative", c.Cooldown))
+		c.Cooldown = 0
+	}
+	return problems
+}
+
+// Capture is a picture waiting to be taken and when the last was
+type Capture struct {
+	trigger string // Event the next capture is for, empty for none
+	last    time.Time
+}
+
+// captureOn asks for a capture when an event log entry matches one of the
+// events in the config, unless one was taken too recently
+func (g *Game) captureOn(text string) {
+	cfg := g.config.current.Captures
+	c := &g.capture
+	if time.Since(c.last).Seconds() < cfg.Cooldown {
+		return
+	}
+	text = strings.ToLower(text)
+	for _, event := range cfg.Events {
+		if event != "" && strings.Contains(text, strings.ToLower(event)) {
+			c.trigger = event
+			return
+		}
+	}
+}
+
+// takeCapture saves the scene as drawn this frame, before any overlays, to
+// a timestamped PNG named after the event. The file is written in the
+// background so a slow disk never stalls a frame.
+func (g *Game) takeCapture(view *ebiten.Image) {
+	c := &g.capture
+	if c.trigger == "" {
+		return
+	}
+	img := image.NewRGBA(view.Bounds())
+	view.ReadPixels(img.Pix)
+	folder := g.config.current.Captures.Folder
+	name := fmt.Sprintf("%s-%s.png", time.Now().Format("2006-01-02-150405"), captureSlug(c.trigger))
+	path := filepath.Join(folder, name)
+	go func() {
+		if err := writeCapture(folder, path, img); err != nil {
+			slog.Warn("saving capture", "path", path, "err", err)
+		}
+	}()
+	// Straight into the log, as the entry would otherwise match the event
+	// it names
+	g.events.add("captured " + path)
+	c.trigger, c.last = "", time.Now()
+}
+
+func writeCapture(folder, path string, img image.Image) error {
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// captureSlug turns an event into something safe in a file name, such as
+// "rain-began"
+func captureSlug(event string) string {
+	return strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(event)), "-")
+}
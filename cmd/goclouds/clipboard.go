@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Ebiten has no clipboard access, so the platform's clipboard tools are used
+// instead. Each entry is a command to try, in order of preference.
+type clipboardTool struct {
+	copy, paste []string
+}
+
+func clipboardTools() []clipboardTool {
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardTool{{[]string{"pbcopy"}, []string{"pbpaste"}}}
+	case "windows":
+		return []clipboardTool{{
+			[]string{"clip"},
+			[]string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"},
+		}}
+	}
+	var tools []clipboardTool
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		tools = append(tools, clipboardTool{[]string{"wl-copy"}, []string{"wl-paste", "--no-newline"}})
+	}
+	return append(tools,
+		clipboardTool{[]string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}},
+		clipboardTool{[]string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+	)
+}
+
+var errNoClipboard = errors.New("no clipboard tool found")
+
+func writeClipboard(text string) error {
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool.copy[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		return cmd.Run()
+	}
+	return errNoClipboard
+}
+
+func readClipboard() (string, error) {
+	for _, tool := range clipboardTools() {
+		if _, err := exec.LookPath(tool.paste[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(tool.paste[0], tool.paste[1:]...).Output()
+		return string(out), err
+	}
+	return "", errNoClipboard
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/sim"
+)
+
+// CloudLesson labels clouds with their type. In the quiz the user has to
+// click a cloud of the named type.
+type CloudLesson struct {
+	labels  bool
+	quiz    bool
+	target  sim.CloudType
+	score   int
+	answers int
+}
+
+// visibleCloudTypes lists the types of the active clouds on screen, once each
+func (g *Game) visibleCloudTypes() []sim.CloudType {
+	var seen [len(sim.CloudTypes)]bool
+	var types []sim.CloudType
+	for _, c := range g.ActiveClouds() {
+		if c.X < 0 || c.X > float64(g.Width) || seen[g.CloudKind(c)] {
+			continue
+		}
+		seen[g.CloudKind(c)] = true
+		types = append(types, g.CloudKind(c))
+	}
+	return types
+}
+
+// nextQuizQuestion names a cloud type that is currently on screen
+func (g *Game) nextQuizQuestion() {
+	types := g.visibleCloudTypes()
+	if len(types) == 0 {
+		g.lesson.quiz = false
+		g.setStatus("No clouds to quiz on, add some with the menu")
+		return
+	}
+	g.lesson.target = types[rng.Intn(len(types))]
+}
+
+func (g *Game) updateCloudLesson() {
+	// C toggles the labels, Shift+C starts or stops the quiz
+	if !inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		return
+	}
+	if !ebiten.IsKeyPressed(ebiten.KeyShift) {
+		g.lesson.labels = !g.lesson.labels
+		return
+	}
+	g.lesson.quiz = !g.lesson.quiz
+	if g.lesson.quiz {
+		g.lesson.score, g.lesson.answers = 0, 0
+		g.nextQuizQuestion()
+	} else {
+		g.logEvent("cloud quiz ended with %d of %d correct", g.lesson.score, g.lesson.answers)
+	}
+}
+
+// cloudAt returns the active cloud under the point, topmost first
+func (g *Game) cloudAt(x, y float64) (sim.Cloud, bool) {
+	if i := g.cloudIndexAt(x, y); i != -1 {
+		return g.Clouds[i], true
+	}
+	return sim.Cloud{}, false
+}
+
+// cloudIndexAt returns the index of the active cloud under the point,
+// topmost first, or -1
+func (g *Game) cloudIndexAt(x, y float64) int {
+	clouds := g.ActiveClouds()
+	for i := len(clouds) - 1; i >= 0; i-- {
+		c := clouds[i]
+		r := c.Size * 0.3
+		for _, l := range g.CloudLobes(c) {
+			dx, dy := x-(c.X+l.DX), y-(c.Y+l.DY)
+			if dx*dx+dy*dy <= r*r {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// answerQuiz checks a click during the quiz. It reports whether the click
+// landed on a cloud and so was taken as an answer.
+func (g *Game) answerQuiz(x, y float64) bool {
+	if !g.lesson.quiz {
+		return false
+	}
+	c, ok := g.cloudAt(x, y)
+	if !ok {
+		return false
+	}
+	g.lesson.answers++
+	if g.CloudKind(c) == g.lesson.target {
+		g.lesson.score++
+		g.setStatus(fmt.Sprintf("Correct, that's %s!", g.CloudKind(c)))
+	} else {
+		g.setStatus(fmt.Sprintf("Not quite, that's %s", g.CloudKind(c)))
+	}
+	g.nextQuizQuestion()
+	return true
+}
+
+func (g *Game) drawCloudLesson(screen *ebiten.Image) {
+	if g.lesson.labels {
+		for _, c := range g.ActiveClouds() {
+			if c.X < -50 || c.X > float64(g.Width)+50 {
+				continue
+			}
+			info := sim.CloudTypes[g.CloudKind(c)]
+			ebitenutil.DebugPrintAt(screen, info.Name, int(c.X), int(c.Y+c.Size*0.35))
+			ebitenutil.DebugPrintAt(screen, info.Altitude, int(c.X), int(c.Y+c.Size*0.35)+12)
+		}
+	}
+
+	if g.lesson.quiz {
+		line := fmt.Sprintf("Click a cloud of type %s  (%d/%d, Shift+C to stop)", g.lesson.target, g.lesson.score, g.lesson.answers)
+		width := float64(len(line)*6 + 16)
+		x := (float64(screenWidth) - width) / 2
+		drawRect(screen, x, 60, width, 24, color.RGBA{0, 0, 0, 180})
+		ebitenutil.DebugPrintAt(screen, line, int(x)+8, 65)
+	}
+}
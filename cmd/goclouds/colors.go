@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+)
+
+// pickable are the palette colors the Colors page can change, by their
+// names in the palette file
+var pickable = []struct{ label, field string }{
+	{"Sky", "sky"},
+	{"Ground", "ground"},
+	{"Foliage", "foliage"},
+	{"Trunk", "trunk"},
+}
+
+// ColorPicker is the Colors page's choice of color and its hue, saturation
+// and value. They are kept rather than worked out from the color each
+// frame, as a gray has no hue and a black no saturation to come back to.
+type ColorPicker struct {
+	choice  int // Index into pickable
+	h, s, v float64
+	color   color.RGBA // The color h, s and v describe
+}
+
+// paletteColor is a palette field's current color
+func (g *Game) paletteColor(field string) color.RGBA {
+	for _, f := range g.Palette.Fields() {
+		if f.Name == field {
+			return *f.Color
+		}
+	}
+	return color.RGBA{}
+}
+
+// pickerHSV returns the chosen color's hue, saturation and value, taken
+// afresh from the palette when something else, such as an undo or a new
+// scene, changed it
+func (g *Game) pickerHSV() (h, s, v float64) {
+	p := &g.picker
+	if c := g.paletteColor(pickable[p.choice].field); c != p.color {
+		p.h, p.s, p.v = rgbToHSV(c)
+		p.color = c
+	}
+	return p.h, p.s, p.v
+}
+
+// pickHSV sets the chosen color from a hue, saturation and value
+func (g *Game) pickHSV(h, s, v float64) {
+	p := &g.picker
+	field := pickable[p.choice].field
+	c := hsvToRGB(h, s, v)
+	c.A = g.paletteColor(field).A
+	p.h, p.s, p.v, p.color = h, s, v, c
+	g.pickColor(field, scene.HexColor(c))
+}
+
+// pickColor picks a palette color as an undoable step, "" going back to the
+// palette's own
+func (g *Game) pickColor(field, hex string) {
+	if from := g.PaletteColors[field]; from != hex {
+		g.exec(colorCmd{field, from, hex})
+	}
+}
+
+// setPaletteColor sets one picked color, or drops it with "", and repaints
+// the scene with the palette and the picked colors
+func (g *Game) setPaletteColor(field, hex string) {
+	colors := maps.Clone(g.PaletteColors)
+	if colors == nil {
+		colors = map[string]string{}
+	}
+	if hex == "" {
+		delete(colors, field)
+	} else {
+		colors[field] = hex
+	}
+	if len(colors) == 0 {
+		colors = nil
+	}
+	g.PaletteColors = colors
+	if p, ok := g.PaletteFor(g.PaletteName); ok {
+		g.Palette = p
+	}
+}
+
+// typeColor asks for the chosen color as "#rrggbb"
+func (g *Game) typeColor() {
+	choice := pickable[g.picker.choice]
+	g.startTextEdit(choice.label+" color (#rrggbb)", scene.HexColor(g.paletteColor(choice.field)), 9, func(text string) {
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, "#") {
+			text = "#" + text
+		}
+		if _, err := g.Palette.WithColors(map[string]string{choice.field: text}); err != nil {
+			g.setStatus("Not a color: " + err.Error())
+			return
+		}
+		g.pickColor(choice.field, strings.ToLower(text))
+	})
+}
+
+// colorWidgets pick the sky, ground and tree colors by hue, saturation and
+// value. Picked colors are saved with the scene over its palette.
+func (g *Game) colorWidgets() []widget {
+	labels := make([]string, len(pickable))
+	for i, p := range pickable {
+		labels[i] = p.label
+	}
+	hsv := func(i int) float64 {
+		h, s, v := g.pickerHSV()
+		return [3]float64{h, s, v}[i]
+	}
+	setHSV := func(i int) func(float64) {
+		return func(x float64) {
+			c := [3]float64{}
+			c[0], c[1], c[2] = g.pickerHSV()
+			if c[i] != x {
+				c[i] = x
+				g.pickHSV(c[0], c[1], c[2])
+			}
+		}
+	}
+	return []widget{
+		&ButtonRow{
+			labels:   labels,
+			click:    func(i int) { g.picker.choice = i },
+			selected: func() int { return g.picker.choice },
+			help:     "Choose the color to change",
+		},
+		&Swatch{
+			label: func() string {
+				field := pickable[g.picker.choice].field
+				picked := ""
+				if _, ok := g.PaletteColors[field]; ok {
+					picked = " (picked)"
+				}
+				return scene.HexColor(g.paletteColor(field)) + picked
+			},
+			color: func() color.RGBA { return g.paletteColor(pickable[g.picker.choice].field) },
+			click: g.typeColor,
+			help:  "Click to type the color as #rrggbb",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Hue: %.0f", hsv(0)) },
+			value:  func() float64 { return hsv(0) },
+			set:    setHSV(0),
+			limits: func() (float64, float64) { return 0, 359 },
+			step:   1,
+			help:   "Hue in degrees around the color wheel, 0 red, 120 green, 240 blue",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Saturation: %.2f", hsv(1)) },
+			value:  func() float64 { return hsv(1) },
+			set:    setHSV(1),
+			limits: func() (float64, float64) { return 0, 1 },
+			step:   0.01,
+			help:   "From gray at 0 to the full color at 1",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Value: %.2f", hsv(2)) },
+			value:  func() float64 { return hsv(2) },
+			set:    setHSV(2),
+			limits: func() (float64, float64) { return 0, 1 },
+			step:   0.01,
+			help:   "From black at 0 to the brightest at 1",
+		},
+		&Button{
+			label: func() string { return "Reset " + pickable[g.picker.choice].label + " Color" },
+			click: func() { g.pickColor(pickable[g.picker.choice].field, "") },
+			help:  "Go back to the palette's own color for this one",
+		},
+		&Button{
+			label: func() string { return "Reset All Colors" },
+			click: g.resetColors,
+			help:  "Go back to the palette's own colors for all of them",
+		},
+		&Button{
+			label: func() string { return "Export Lighting Palette" },
+			click: g.exportLighting,
+			help:  "Save the sky, sun, ground, foliage and shadow colors as they are lit now, for other tools",
+		},
+	}
+}
+
+// resetColors drops every picked color as one undoable step
+func (g *Game) resetColors() {
+	from := len(g.history.undo)
+	for _, p := range pickable {
+		g.pickColor(p.field, "")
+	}
+	g.groupHistory(from)
+}
+
+// Swatch shows a color with its name, clicked to change it
+type Swatch struct {
+	label func() string
+	color func() color.RGBA
+	click func()
+	help  string
+}
+
+// swatchSize is the width of a swatch's sample of the color
+const swatchSize = 40
+
+func (s *Swatch) tip() string { return s.help }
+
+func (s *Swatch) press(m mouse, area rect) bool {
+	s.click()
+	return false
+}
+
+func (s *Swatch) drag(m mouse, area rect) {}
+
+func (s *Swatch) adjust(dir int) {}
+
+func (s *Swatch) activate() { s.click() }
+
+func (s *Swatch) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	drawBackdrop(screen, area, area.contains(m.x, m.y), focused)
+	c := s.color()
+	c.A = 255
+	drawRect(screen, area.x+area.w-swatchSize-2, area.y+2, swatchSize, area.h-4, c)
+	ebitenutil.DebugPrintAt(screen, s.label(), int(area.x)+4, int(area.y)+1)
+}
+
+// rgbToHSV gives a color's hue in degrees (0-360) and its saturation and
+// value (0-1)
+func rgbToHSV(c color.RGBA) (h, s, v float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	v = hi
+	if hi == 0 {
+		return 0, 0, 0
+	}
+	s = (hi - lo) / hi
+	if hi == lo {
+		return 0, s, v
+	}
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/(hi-lo), 6)
+	case g:
+		h = (b-r)/(hi-lo) + 2
+	default:
+		h = (r-g)/(hi-lo) + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, v
+}
+
+// hsvToRGB makes an opaque color from a hue in degrees and a saturation and
+// value from 0 to 1
+func hsvToRGB(h, s, v float64) color.RGBA {
+	h = math.Mod(h, 360) / 60
+	chroma := v * s
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+	m := v - chroma
+	channel := func(c float64) uint8 { return uint8(math.Round((c + m) * 255)) }
+	return color.RGBA{channel(r), channel(g), channel(b), 255}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"cloudapp/pkg/sim"
+)
+
+const maxHistory = 200 // Oldest edits are forgotten beyond this
+
+// Command is a reversible edit to the scene. Every user edit goes through a
+// command so it can be undone with Ctrl+Z and redone with Ctrl+Y.
+type Command interface {
+	Do(g *Game)
+	Undo(g *Game)
+	String() string
+}
+
+type History struct {
+	undo []Command
+	redo []Command
+}
+
+// exec applies a command and records it in the history
+func (g *Game) exec(cmd Command) {
+	cmd.Do(g)
+	g.record(cmd)
+}
+
+// record adds an already applied command to the history, used for drags
+// whose effect is applied live while the mouse moves
+func (g *Game) record(cmd Command) {
+	g.history.undo = append(g.history.undo, cmd)
+	if len(g.history.undo) > maxHistory {
+		g.history.undo = g.history.undo[len(g.history.undo)-maxHistory:]
+	}
+	g.history.redo = nil
+}
+
+func (g *Game) undo() {
+	h := &g.history
+	if len(h.undo) == 0 {
+		g.setStatus("Nothing to undo")
+		return
+	}
+	cmd := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	cmd.Undo(g)
+	h.redo = append(h.redo, cmd)
+	g.setStatus("Undo: " + cmd.String())
+}
+
+func (g *Game) redo() {
+	h := &g.history
+	if len(h.redo) == 0 {
+		g.setStatus("Nothing to redo")
+		return
+	}
+	cmd := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	cmd.Do(g)
+	h.undo = append(h.undo, cmd)
+	g.setStatus("Redo: " + cmd.String())
+}
+
+// clearHistory forgets all edits, used when a whole scene is replaced
+func (g *Game) clearHistory() {
+	g.history = History{}
+}
+
+// moveSunCmd moves the sun between two positions
+type moveSunCmd struct {
+	from, to point
+}
+
+func (c moveSunCmd) Do(g *Game)     { c.apply(g, c.to) }
+func (c moveSunCmd) Undo(g *Game)   { c.apply(g, c.from) }
+func (c moveSunCmd) String() string { return "move sun" }
+
+func (c moveSunCmd) apply(g *Game, p point) {
+	g.SunX, g.SunY = p.x, p.y
+}
+
+// moveTreeCmd moves a tree, found by ID, between two positions
+type moveTreeCmd struct {
+	id       int
+	from, to point
+}
+
+func (c moveTreeCmd) Do(g *Game)     { c.apply(g, c.to) }
+func (c moveTreeCmd) Undo(g *Game)   { c.apply(g, c.from) }
+func (c moveTreeCmd) String() string { return fmt.Sprintf("move tree #%d", c.id) }
+
+func (c moveTreeCmd) apply(g *Game, p point) {
+	if t := g.FindTree(c.id); t != nil {
+		t.X, t.Y = p.x, p.y
+	}
+}
+
+// movePropCmd moves a prop, found by ID, between two positions
+type movePropCmd struct {
+	id       int
+	from, to point
+}
+
+func (c movePropCmd) Do(g *Game)     { c.apply(g, c.to) }
+func (c movePropCmd) Undo(g *Game)   { c.apply(g, c.from) }
+func (c movePropCmd) String() string { return fmt.Sprintf("move prop #%d", c.id) }
+
+func (c movePropCmd) apply(g *Game, p point) {
+	if prop := g.FindProp(c.id); prop != nil {
+		prop.X, prop.Y = p.x, p.y
+	}
+}
+
+// moveCloudCmd moves a cloud, by index, a distance. Clouds drift, so it is
+// moved by the distance rather than to a place.
+type moveCloudCmd struct {
+	index  int
+	dx, dy float64
+}
+
+func (c moveCloudCmd) Do(g *Game)     { c.apply(g, 1) }
+func (c moveCloudCmd) Undo(g *Game)   { c.apply(g, -1) }
+func (c moveCloudCmd) String() string { return fmt.Sprintf("move cloud %d", c.index+1) }
+
+func (c moveCloudCmd) apply(g *Game, dir float64) {
+	if c.index < len(g.Clouds) {
+		g.Clouds[c.index].X += c.dx * dir
+		g.Clouds[c.index].Y += c.dy * dir
+	}
+}
+
+// addPropCmd places a prop in the scene
+type addPropCmd struct {
+	prop sim.Prop
+}
+
+func (c addPropCmd) Do(g *Game) {
+	g.Props = append(g.Props, c.prop)
+}
+
+func (c addPropCmd) Undo(g *Game) {
+	g.Props = slices.DeleteFunc(g.Props, func(p sim.Prop) bool { return p.ID == c.prop.ID })
+	g.resetInteraction()
+}
+
+func (c addPropCmd) String() string { return "place " + propLabel(&c.prop) }
+
+// removePropCmd takes a prop out of the scene, putting it back where it was
+// in the list on undo
+type removePropCmd struct {
+	prop  sim.Prop
+	index int
+}
+
+func (c removePropCmd) Do(g *Game) {
+	g.Props = slices.DeleteFunc(g.Props, func(p sim.Prop) bool { return p.ID == c.prop.ID })
+	g.resetInteraction()
+}
+
+func (c removePropCmd) Undo(g *Game) {
+	g.Props = slices.Insert(g.Props, min(c.index, len(g.Props)), c.prop)
+}
+
+func (c removePropCmd) String() string { return "delete " + propLabel(&c.prop) }
+
+// treesCmd swaps the whole tree list, used for tree count changes so the
+// exact trees removed come back on undo rather than new random ones, and
+// for edits to single trees from the context menu
+type treesCmd struct {
+	before, after           []sim.Tree
+	countBefore, countAfter int
+	what                    string // Shown on undo, empty for a count change
+}
+
+func (c treesCmd) Do(g *Game)   { c.apply(g, c.after, c.countAfter) }
+func (c treesCmd) Undo(g *Game) { c.apply(g, c.before, c.countBefore) }
+
+func (c treesCmd) String() string {
+	if c.what != "" {
+		return c.what
+	}
+	return "change tree count"
+}
+
+func (c treesCmd) apply(g *Game, trees []sim.Tree, count int) {
+	g.Trees = slices.Clone(trees)
+	g.TreeCount = count
+	g.resetInteraction()
+}
+
+// cloudsCmd swaps the whole cloud list, used for deleting, duplicating and
+// reshaping single clouds
+type cloudsCmd struct {
+	before, after           []sim.Cloud
+	countBefore, countAfter int // CloudCount
+	what                    string
+}
+
+func (c cloudsCmd) Do(g *Game)     { c.apply(g, c.after, c.countAfter) }
+func (c cloudsCmd) Undo(g *Game)   { c.apply(g, c.before, c.countBefore) }
+func (c cloudsCmd) String() string { return c.what }
+
+func (c cloudsCmd) apply(g *Game, clouds []sim.Cloud, count int) {
+	g.Clouds = slices.Clone(clouds)
+	g.MaxClouds = len(clouds)
+	g.CloudCount = count
+	g.selection.clouds = nil // Their indexes may no longer match
+}
+
+// settingCmd changes a single numeric setting
+type settingCmd struct {
+	name     string
+	from, to float64
+	set      func(g *Game, v float64)
+}
+
+func (c settingCmd) Do(g *Game)     { c.set(g, c.to) }
+func (c settingCmd) Undo(g *Game)   { c.set(g, c.from) }
+func (c settingCmd) String() string { return "change " + c.name }
+
+// colorCmd picks a palette color, or with "" goes back to the palette's own
+type colorCmd struct {
+	field    string // Name in the palette file
+	from, to string // "#rrggbb", or "" for the palette's color
+}
+
+func (c colorCmd) Do(g *Game)     { g.setPaletteColor(c.field, c.to) }
+func (c colorCmd) Undo(g *Game)   { g.setPaletteColor(c.field, c.from) }
+func (c colorCmd) String() string { return "change " + c.field + " color" }
+
+// renameCmd changes the name of a tree or prop
+type renameCmd struct {
+	id       int
+	from, to string
+}
+
+func (c renameCmd) Do(g *Game)     { c.apply(g, c.to) }
+func (c renameCmd) Undo(g *Game)   { c.apply(g, c.from) }
+func (c renameCmd) String() string { return fmt.Sprintf("rename #%d", c.id) }
+
+func (c renameCmd) apply(g *Game, name string) {
+	if n := g.entityName(c.id); n != nil {
+		*n = name
+	}
+}
+
+// notesCmd swaps the list of sticky notes
+type notesCmd struct {
+	before, after []Note
+}
+
+func (c notesCmd) Do(g *Game)     { g.notes = slices.Clone(c.after) }
+func (c notesCmd) Undo(g *Game)   { g.notes = slices.Clone(c.before) }
+func (c notesCmd) String() string { return "edit notes" }
+
+// setTreeCount changes the number of trees, keeping existing ones
+func (g *Game) setTreeCount(n int) {
+	if n == g.TreeCount {
+		return
+	}
+	cmd := treesCmd{before: slices.Clone(g.Trees), countBefore: g.TreeCount, countAfter: n}
+	g.TreeCount = n
+	g.UpdateTreeCount()
+	cmd.after = slices.Clone(g.Trees)
+	g.record(cmd)
+}
+
+func (g *Game) setCloudCount(n int) {
+	if n == g.CloudCount {
+		return
+	}
+	g.exec(settingCmd{"cloud count", float64(g.CloudCount), float64(n), func(g *Game, v float64) {
+		g.CloudCount = int(v)
+	}})
+}
+
+func (g *Game) setTreeShadow(v float64) {
+	if v == g.TreeShadow {
+		return
+	}
+	g.exec(settingCmd{"tree shadow", g.TreeShadow, v, func(g *Game, v float64) {
+		g.TreeShadow = v
+	}})
+}
+
+func (g *Game) setDensity(v float64) {
+	if v == g.Density {
+		return
+	}
+	g.exec(settingCmd{"cloud density", g.Density, v, func(g *Game, v float64) {
+		g.Density = v
+	}})
+}
+
+func (g *Game) setWind(v float64) {
+	if v == g.Wind.Base {
+		return
+	}
+	g.exec(settingCmd{"wind", g.Wind.Base, v, func(g *Game, v float64) {
+		g.Wind.Base = v
+	}})
+}
+
+func (g *Game) setWetness(v float64) {
+	if v == g.Wetness {
+		return
+	}
+	g.exec(settingCmd{"ground wetness", g.Wetness, v, func(g *Game, v float64) {
+		g.Wetness = v
+	}})
+}
+
+// endDrag records whatever the user just finished dragging so the move can
+// be undone
+func (g *Game) endDrag() {
+	switch {
+	case g.isDraggingSun:
+		if to := (point{g.SunX, g.SunY}); to != g.dragFrom {
+			g.record(moveSunCmd{g.dragFrom, to})
+		}
+	case g.draggedTree != -1 && g.duplicating:
+		t := g.Trees[g.draggedTree]
+		before := slices.DeleteFunc(slices.Clone(g.Trees), func(o sim.Tree) bool { return o.ID == t.ID })
+		g.record(treesCmd{before, slices.Clone(g.Trees), g.TreeCount - 1, g.TreeCount, "copy tree to " + treeLabel(&t)})
+		g.logEvent("tree copied to %s at %.0f,%.0f", treeLabel(&t), t.X, t.Y)
+	case g.draggedTree != -1:
+		t := &g.Trees[g.draggedTree]
+		if to := (point{t.X, t.Y}); to != g.dragFrom {
+			g.record(moveTreeCmd{t.ID, g.dragFrom, to})
+		}
+	case g.draggedProp != -1:
+		p := &g.Props[g.draggedProp]
+		if to := (point{p.X, p.Y}); to != g.dragFrom {
+			g.record(movePropCmd{p.ID, g.dragFrom, to})
+		}
+	case g.selection.moving:
+		g.endMove()
+	case g.selection.banding:
+		g.endBand()
+	}
+}
+
+// resetInteraction drops drags and selections that may point at entities
+// that no longer exist
+func (g *Game) resetInteraction() {
+	g.isDraggingSun = false
+	g.draggedTree = -1
+	g.draggedProp = -1
+	g.duplicating = false
+	g.forgetSelection()
+	if g.selectedID != sunID && g.entityName(g.selectedID) == nil {
+		g.selectedID = 0
+	}
+}
+
+// batchCmd is several commands undone and redone as one, such as the steps
+// a slider passed through while it was dragged
+type batchCmd []Command
+
+func (c batchCmd) Do(g *Game) {
+	for _, cmd := range c {
+		cmd.Do(g)
+	}
+}
+
+func (c batchCmd) Undo(g *Game) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].Undo(g)
+	}
+}
+
+func (c batchCmd) String() string { return c[0].String() }
+
+// groupHistory folds everything recorded since the history held from
+// commands into a single batchCmd
+func (g *Game) groupHistory(from int) {
+	h := &g.history
+	if from < 0 || len(h.undo)-from < 2 {
+		return
+	}
+	batch := batchCmd(slices.Clone(h.undo[from:]))
+	h.undo = append(h.undo[:from], batch)
+}
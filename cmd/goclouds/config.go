@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	configFile       = "goclouds.json"
+	maxBackgroundFPS = 60 // The full update rate
+	maxWorldWidth    = 4  // Most windows wide the world can be
+)
+
+// Config holds the startup options read from goclouds.json
+type Config struct {
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	CloudCount int     `json:"cloudCount"`
+	TreeCount  int     `json:"treeCount"`
+	Density    float64 `json:"density"`
+	Seed       int64   `json:"seed"` // 0 picks a new random seed on every run
+	Palette    string  `json:"palette"`
+	Wind       float64 `json:"wind"` // Prevailing wind strength, 1 is a gentle breeze
+
+	// How many windows wide the world is, panned across with the camera
+	WorldWidth float64 `json:"worldWidth"`
+
+	// Slow ambient motion and drop streaks and glides, for users with
+	// vestibular or photosensitivity issues
+	ReducedMotion bool `json:"reducedMotion"`
+
+	// Distance birds keep from scarecrows until they get used to them
+	ScarecrowRadius float64 `json:"scarecrowRadius"`
+
+	// Where clouds and trees spawn, for scenes much larger or differently
+	// shaped than 800x600
+	Margins scene.Margins `json:"margins"`
+
+	// Text-to-speech program, such as espeak or say, run with each change
+	// to the scene description as its argument. Empty only updates the
+	// window title.
+	Speak string `json:"speak"`
+
+	// Run unattended around the clock, checking the scene now and then and
+	// recovering from errors instead of exiting
+	Soak bool `json:"soak"`
+
+	// Updates a second while the window is unfocused or minimized, 0 keeps
+	// full speed
+	BackgroundFPS int `json:"backgroundFPS"`
+
+	// Draw a minimal scene at 30 FPS for Raspberry Pi and other weak GPUs.
+	// It also turns on by itself on such hardware.
+	LowEnd bool `json:"lowEnd"`
+
+	// Megabytes of cached tree, shadow and background images to keep on
+	// the GPU before the least recently drawn are dropped, 0 for no limit
+	ImageBudgetMB int `json:"imageBudgetMB"`
+
+	// Mood the weather, sun and wind are kept to, such as "serene" or
+	// "dramatic". Empty leaves them to be set by hand.
+	Mood string `json:"mood"`
+
+	// Name of a group of displays on the network to keep in lockstep with,
+	// such as a video wall. Empty runs alone.
+	Sync     string `json:"sync"`
+	SyncPort int    `json:"syncPort"` // UDP port the group's beacons are broadcast on
+
+	// Text shown over the scene, still or as a ticker
+	Banner Banner `json:"banner"`
+
+	// Events that save a picture of the scene, such as "sunset"
+	Captures Captures `json:"captures"`
+
+	// Where F12 saves screenshots and how large scene-only ones are
+	Screenshots Screenshots `json:"screenshots"`
+
+	// How the record key makes an animated GIF of the scene
+	Recording Recording `json:"recording"`
+
+	// Shake connected gamepads for storms and strong gusts
+	Rumble bool `json:"rumble"`
+
+	// White point and channel gains for the display the app is shown on
+	Calibration Calibration `json:"calibration"`
+
+	// Keys for the bindable actions, such as "menu": "M"
+	Keys Bindings `json:"keys"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Width:      800,
+		Height:     600,
+		CloudCount: scene.MaxClouds,
+		TreeCount:  numTrees,
+		Density:    0.2,
+		Palette:    "default",
+		Wind:       1.0,
+		WorldWidth: 1,
+
+		ScarecrowRadius: 120,
+		Margins:         scene.DefaultMargins,
+		BackgroundFPS:   5,
+		ImageBudgetMB:   64,
+		SyncPort:        defaultSyncPort,
+		Banner:          defaultBanner,
+		Captures:        defaultCaptures,
+		Screenshots:     defaultScreenshots,
+		Recording:       defaultRecording,
+		Rumble:          true,
+		Calibration:     defaultCalibration,
+		Keys:            defaultBindings(),
+	}
+}
+
+// loadConfig reads the config file, writing the defaults to it on first run.
+// Missing fields keep their default values.
+func loadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		data, err = json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return cfg, err
+		}
+		return cfg, os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, cfg.validate()
+}
+
+// validate clamps out-of-range values back to something usable, reporting
+// what it had to change
+func (c *Config) validate() error {
+	var problems []error
+	if c.Width < 320 || c.Height < 240 {
+		problems = append(problems, fmt.Errorf("window size %dx%d is below 320x240", c.Width, c.Height))
+		c.Width = max(320, c.Width)
+		c.Height = max(240, c.Height)
+	}
+	if c.WorldWidth < 1 || c.WorldWidth > maxWorldWidth {
+		problems = append(problems, fmt.Errorf("worldWidth %.1f is outside 1-%d", c.WorldWidth, maxWorldWidth))
+		c.WorldWidth = math.Max(1, math.Min(maxWorldWidth, c.WorldWidth))
+	}
+	if c.CloudCount < 0 {
+		problems = append(problems, fmt.Errorf("cloudCount %d is negative", c.CloudCount))
+		c.CloudCount = 0
+	}
+	if c.TreeCount < 1 || c.TreeCount > 20 {
+		problems = append(problems, fmt.Errorf("treeCount %d is outside 1-20", c.TreeCount))
+		c.TreeCount = min(20, max(1, c.TreeCount))
+	}
+	if c.Density < 0 || c.Density > 1 {
+		problems = append(problems, fmt.Errorf("density %.2f is outside 0-1", c.Density))
+		c.Density = math.Max(0, math.Min(1, c.Density))
+	}
+	if c.Wind < 0 || c.Wind > 5 {
+		problems = append(problems, fmt.Errorf("wind %.2f is outside 0-5", c.Wind))
+		c.Wind = math.Max(0, math.Min(5, c.Wind))
+	}
+	if c.ScarecrowRadius < 0 {
+		problems = append(problems, fmt.Errorf("scarecrowRadius %.0f is negative", c.ScarecrowRadius))
+		c.ScarecrowRadius = 0
+	}
+	if m := &c.Margins; m.CloudWrap < 0 {
+		problems = append(problems, fmt.Errorf("margins.cloudWrap %.0f is negative", m.CloudWrap))
+		m.CloudWrap = 0
+	}
+	if m := &c.Margins; m.TreeEdge < 0 || m.TreeEdge > float64(c.Width)/4 {
+		problems = append(problems, fmt.Errorf("margins.treeEdge %.0f is outside 0-%d", m.TreeEdge, c.Width/4))
+		m.TreeEdge = math.Max(0, math.Min(float64(c.Width)/4, m.TreeEdge))
+	}
+	if m := &c.Margins; m.SkyBand < 0.1 || m.SkyBand > 1 {
+		problems = append(problems, fmt.Errorf("margins.skyBand %.2f is outside 0.1-1", m.SkyBand))
+		m.SkyBand = math.Max(0.1, math.Min(1, m.SkyBand))
+	}
+	if c.BackgroundFPS < 0 || c.BackgroundFPS > maxBackgroundFPS {
+		problems = append(problems, fmt.Errorf("backgroundFPS %d is outside 0-%d", c.BackgroundFPS, maxBackgroundFPS))
+		c.BackgroundFPS = min(maxBackgroundFPS, max(0, c.BackgroundFPS))
+	}
+	if c.ImageBudgetMB < 0 {
+		problems = append(problems, fmt.Errorf("imageBudgetMB %d is negative", c.ImageBudgetMB))
+		c.ImageBudgetMB = 0
+	}
+	if _, ok := sim.FindMood(c.Mood); c.Mood != "" && !ok {
+		problems = append(problems, fmt.Errorf("unknown mood %q", c.Mood))
+		c.Mood = ""
+	}
+	if c.SyncPort < 1024 || c.SyncPort > 65535 {
+		problems = append(problems, fmt.Errorf("syncPort %d is outside 1024-65535", c.SyncPort))
+		c.SyncPort = defaultSyncPort
+	}
+	problems = append(problems, c.Banner.validate()...)
+	problems = append(problems, c.Captures.validate()...)
+	problems = append(problems, c.Screenshots.validate()...)
+	problems = append(problems, c.Recording.validate()...)
+	problems = append(problems, c.Calibration.validate()...)
+	if c.Keys == nil {
+		c.Keys = defaultBindings()
+	}
+	problems = append(problems, c.Keys.validate()...)
+	if _, ok := scene.Palettes[c.Palette]; !ok {
+		problems = append(problems, fmt.Errorf("unknown palette %q", c.Palette))
+		c.Palette = "default"
+	}
+	return errors.Join(problems...)
+}
+
+// saveConfig rewrites one part of the config file, leaving the rest of it
+// as it is. The file is read afresh so command-line flags given for this
+// run aren't written into it, and the write isn't taken for an edit to
+// reload.
+func (g *Game) saveConfig(change func(c *Config)) error {
+	path := g.config.path
+	if path == "" {
+		return errors.New("no config file")
+	}
+	cfg := DefaultConfig()
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			// Don't overwrite a file that needs fixing by hand
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	change(&cfg)
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	if info, err := os.Stat(path); err == nil {
+		g.config.modTime = info.ModTime()
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	contextWidth = 170
+	maxTrees     = scene.MaxTrees // As many trees as the tree count allows
+)
+
+// ContextMenu is the small menu right-clicking the sun, a tree or a cloud
+// opens at the cursor, with actions for just that one
+type ContextMenu struct {
+	title string
+	panel *Panel // Nil while closed
+}
+
+// updateContextMenu opens the context menu on a right-click or long press and handles
+// clicks on it, reporting whether it took the mouse. Any click away from the
+// menu closes it.
+func (g *Game) updateContextMenu() bool {
+	c := &g.context
+	m := g.readMouse()
+	if g.secondaryPressed() {
+		if !g.menu.visible || !g.menuArea().contains(m.x, m.y) {
+			g.openContextMenu(m)
+		}
+		return true
+	}
+	if c.panel == nil {
+		return false
+	}
+	if m.pressed && !c.area().contains(m.x, m.y) {
+		c.panel = nil
+		return true
+	}
+	return c.panel.update(m)
+}
+
+// area is the menu's backdrop, with its title above the buttons
+func (c *ContextMenu) area() rect {
+	a := c.panel.area
+	return rect{a.x - 4, a.y - 20, a.w + 8, c.panel.bottom() - a.y + 24}
+}
+
+// openContextMenu offers the actions for whatever is under the cursor:
+// the sun, then a tree, then a cloud behind them. Anywhere else it opens
+// or closes the menu, which touchscreens have no key for.
+func (g *Game) openContextMenu(m mouse) {
+	c := &g.context
+	c.panel = nil
+	pos := g.cursor()
+	var widgets []widget
+	if dx, dy := pos.x-g.SunX, pos.y-g.SunY; dx*dx+dy*dy <= sim.SunRadius*sim.SunRadius {
+		c.title = "Sun"
+		widgets = []widget{
+			g.contextButton(func() string { return lockLabel(g.SunLocked) }, g.toggleSunLock),
+		}
+	} else if i := g.treeAt(pos.x, pos.y); i != -1 {
+		t := &g.Trees[i]
+		id := t.ID
+		c.title = treeLabel(t)
+		widgets = []widget{
+			g.contextButton(func() string { return "Delete" }, func() { g.deleteTree(id) }),
+			g.contextButton(func() string { return "Duplicate" }, func() { g.duplicateTree(id) }),
+			g.contextButton(func() string { return "Randomize Shape" }, func() { g.reshapeTree(id) }),
+			g.contextButton(func() string {
+				t := g.FindTree(id)
+				return lockLabel(t != nil && t.Locked)
+			}, func() { g.toggleTreeLock(id) }),
+		}
+	} else if i := g.cloudIndexAt(pos.x, pos.y); i != -1 {
+		c.title = fmt.Sprintf("%s cloud", g.CloudKind(g.Clouds[i]))
+		widgets = []widget{
+			g.contextButton(func() string { return "Delete" }, func() { g.deleteCloud(i) }),
+			g.contextButton(func() string { return "Duplicate" }, func() { g.duplicateCloud(i) }),
+			g.contextButton(func() string { return "Randomize Shape" }, func() { g.reshapeCloud(i) }),
+			g.contextButton(func() string { return lockLabel(i < len(g.Clouds) && g.Clouds[i].Locked) }, func() { g.toggleCloudLock(i) }),
+		}
+	} else {
+		c.title = "Scene"
+		widgets = []widget{
+			g.contextButton(func() string {
+				if g.menu.visible {
+					return "Close Menu"
+				}
+				return "Open Menu"
+			}, func() { g.menu.visible = !g.menu.visible }),
+		}
+	}
+
+	// Kept on screen when opened near the right or bottom edge
+	height := float64(len(widgets)*(widgetHeight+widgetGap)) + 24
+	x := math.Min(m.x+4, float64(screenWidth)-contextWidth-4)
+	y := math.Min(m.y+20, float64(screenHeight)-height)
+	c.panel = &Panel{area: rect{x, y, contextWidth - 8, 0}, widgets: widgets}
+}
+
+// contextButton is a context menu button that closes the menu once used
+func (g *Game) contextButton(label func() string, action func()) widget {
+	do := func() {
+		g.context.panel = nil
+		action()
+	}
+	return &Button{label: label, click: do}
+}
+
+func lockLabel(locked bool) string {
+	if locked {
+		return "Unlock Position"
+	}
+	return "Lock Position"
+}
+
+func (g *Game) drawContextMenu(screen *ebiten.Image) {
+	c := &g.context
+	if c.panel == nil {
+		return
+	}
+	a := c.area()
+	drawRect(screen, a.x, a.y, a.w, a.h, color.RGBA{0, 0, 0, 200})
+	ebitenutil.DebugPrintAt(screen, c.title, int(a.x)+6, int(a.y)+3)
+	c.panel.draw(screen, g.readMouse())
+}
+
+// editTrees applies change to a copy of the trees as one undoable step
+func (g *Game) editTrees(what string, count int, change func(trees []sim.Tree) []sim.Tree) {
+	cmd := treesCmd{before: slices.Clone(g.Trees), countBefore: g.TreeCount, countAfter: count, what: what}
+	cmd.after = change(slices.Clone(g.Trees))
+	g.exec(cmd)
+	g.logEvent("%s", what)
+}
+
+func (g *Game) deleteTree(id int) {
+	if len(g.Trees) <= 1 {
+		g.setStatus("A scene keeps at least one tree")
+		return
+	}
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	g.editTrees("delete "+treeLabel(t), g.TreeCount-1, func(trees []sim.Tree) []sim.Tree {
+		return slices.DeleteFunc(trees, func(t sim.Tree) bool { return t.ID == id })
+	})
+}
+
+// duplicateTree plants a copy of a tree beside it, unnamed and unlocked
+func (g *Game) duplicateTree(id int) {
+	if len(g.Trees) >= maxTrees {
+		g.setStatus(fmt.Sprintf("A scene holds at most %d trees", maxTrees))
+		return
+	}
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	twin := *t
+	twin.ID = g.NewID()
+	twin.Name = ""
+	twin.Locked = false
+	twin.X = math.Min(float64(g.Width), t.X+t.Size*0.8)
+	g.editTrees("duplicate "+treeLabel(t), g.TreeCount+1, func(trees []sim.Tree) []sim.Tree {
+		return append(trees, twin)
+	})
+}
+
+// dragDuplicate leaves a tree where it stands and takes hold of a copy of
+// it, with the same size, shape and shade, for Alt-dragging. The copy is
+// added to the scene as one undoable edit when it is let go.
+func (g *Game) dragDuplicate(i int, cursorX float64) {
+	if len(g.Trees) >= maxTrees {
+		g.setStatus(fmt.Sprintf("A scene holds at most %d trees", maxTrees))
+		return
+	}
+	twin := g.Trees[i]
+	twin.ID = g.NewID()
+	twin.Name = ""
+	twin.Locked = false
+	g.Trees = append(g.Trees, twin)
+	g.TreeCount++
+	g.draggedTree = len(g.Trees) - 1
+	g.dragTreeStartX = cursorX - twin.X
+	g.dragFrom = point{twin.X, twin.Y}
+	g.selectedID = twin.ID
+	g.duplicating = true
+}
+
+// reshapeTree gives a tree a random shape, growing it afresh if it branches
+func (g *Game) reshapeTree(id int) {
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	shape := g.Rand.Intn(scene.NumShapes)
+	seed := int64(g.Rand.Int31())
+	g.editTrees("reshape "+treeLabel(t), g.TreeCount, func(trees []sim.Tree) []sim.Tree {
+		for i := range trees {
+			if trees[i].ID == id {
+				trees[i].Shape, trees[i].Seed, trees[i].Structure = shape, seed, nil
+				if shape == scene.ShapeBranching {
+					trees[i].Structure = scene.GrowTree(seed)
+				}
+			}
+		}
+		return trees
+	})
+}
+
+func (g *Game) toggleTreeLock(id int) {
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	g.exec(lockCmd("tree", t.Locked, func(g *Game, on bool) {
+		if t := g.FindTree(id); t != nil {
+			t.Locked = on
+		}
+	}))
+}
+
+func (g *Game) toggleSunLock() {
+	g.exec(lockCmd("sun", g.SunLocked, func(g *Game, on bool) { g.SunLocked = on }))
+}
+
+// lockCmd flips a lock as a setting, so it can be undone like any other
+func lockCmd(what string, locked bool, set func(g *Game, on bool)) settingCmd {
+	from, to := 0.0, 1.0
+	if locked {
+		from, to = 1, 0
+	}
+	return settingCmd{what + " lock", from, to, func(g *Game, v float64) { set(g, v == 1) }}
+}
+
+// editClouds applies change to a copy of the clouds as one undoable step
+func (g *Game) editClouds(what string, count int, change func(clouds []sim.Cloud) []sim.Cloud) {
+	cmd := cloudsCmd{before: slices.Clone(g.Clouds), countBefore: g.CloudCount, countAfter: count, what: what}
+	cmd.after = change(slices.Clone(g.Clouds))
+	g.exec(cmd)
+	g.logEvent("%s", what)
+}
+
+func (g *Game) deleteCloud(i int) {
+	if i >= len(g.Clouds) {
+		return
+	}
+	g.editClouds("delete cloud", min(g.CloudCount, len(g.Clouds)-1), func(clouds []sim.Cloud) []sim.Cloud {
+		return slices.Delete(clouds, i, i+1)
+	})
+}
+
+// duplicateCloud adds a copy of a cloud just downwind of it, drawn right
+// after it so it shows whenever the original does
+func (g *Game) duplicateCloud(i int) {
+	if i >= len(g.Clouds) {
+		return
+	}
+	twin := g.Clouds[i]
+	twin.X += twin.Size * 0.8
+	twin.Locked = false
+	g.editClouds("duplicate cloud", g.CloudCount+1, func(clouds []sim.Cloud) []sim.Cloud {
+		return slices.Insert(clouds, i+1, twin)
+	})
+}
+
+func (g *Game) reshapeCloud(i int) {
+	if i >= len(g.Clouds) {
+		return
+	}
+	seed := g.Rand.Float64() * 2 * math.Pi
+	g.editClouds("reshape cloud", g.CloudCount, func(clouds []sim.Cloud) []sim.Cloud {
+		clouds[i].ShapeSeed = seed
+		return clouds
+	})
+}
+
+func (g *Game) toggleCloudLock(i int) {
+	if i >= len(g.Clouds) {
+		return
+	}
+	g.exec(lockCmd("cloud", g.Clouds[i].Locked, func(g *Game, on bool) {
+		if i < len(g.Clouds) {
+			g.Clouds[i].Locked = on
+		}
+	}))
+}
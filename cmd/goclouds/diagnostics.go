@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/render/ebitenrender"
+	"cloudapp/pkg/sim"
+)
+
+// diagnosticsLogInterval is how often frame figures are logged at debug
+// level
+const diagnosticsLogInterval = 10 * time.Second
+
+// Diagnostics is the F3 overlay of frame rates, counts and cache use, the
+// periodic debug log of the same figures, the F2 frame time graph and the
+// F4 light probe view
+type Diagnostics struct {
+	visible bool
+	graph   bool // Rolling graph of the recent frame times shown
+	probes  bool // Light factor heatmap and tree gauges shown
+	nextLog time.Time
+}
+
+// setupLogging sends diagnostics to stderr as key=value lines, including
+// debug ones when debug is set
+func setupLogging(debug bool) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+}
+
+// updateDiagnostics logs the frame figures every so often when debug
+// logging is on
+func (g *Game) updateDiagnostics() {
+	now := time.Now()
+	if now.Before(g.diagnostics.nextLog) {
+		return
+	}
+	g.diagnostics.nextLog = now.Add(diagnosticsLogInterval)
+	stats := g.renderer.Stats
+	slog.Debug("frame",
+		"fps", ebiten.ActualFPS(),
+		"tps", ebiten.ActualTPS(),
+		"calls", stats.Calls,
+		"simplified", stats.Simplified,
+		"clouds", g.ActiveCloudCount(),
+		"trees", len(g.Trees),
+		"props", len(g.Props),
+		"treeHitRate", stats.Trees.HitRate(),
+		"cloudShadowHitRate", stats.CloudShadows.HitRate(),
+		"images", stats.Images,
+		"imageBytes", stats.ImageBytes,
+		"evicted", stats.Evicted,
+	)
+}
+
+// diagnosticsLines are the overlay's figures, one per line
+func (g *Game) diagnosticsLines() []string {
+	birds := 0
+	for _, e := range g.Entities(sim.LayerAir) {
+		if f, ok := e.(*sim.Flock); ok {
+			birds += len(f.Birds)
+		}
+	}
+	stats := g.renderer.Stats
+	rate := func(name string, c render.CacheStats) string {
+		return fmt.Sprintf("  %-15s%3.0f%% of %d", name, c.HitRate()*100, c.Hits+c.Misses)
+	}
+	return []string{
+		fmt.Sprintf("FPS %.1f  TPS %.1f / %d", ebiten.ActualFPS(), ebiten.ActualTPS(), ebiten.TPS()),
+		fmt.Sprintf("Clouds %d/%d  Trees %d  Props %d", g.ActiveCloudCount(), len(g.Clouds), len(g.Trees), len(g.Props)),
+		fmt.Sprintf("Birds %d  Contrail %d  Scenes %d", birds, len(g.Contrail), len(g.tabs.open)),
+		fmt.Sprintf("Draw calls ~%d  Low detail %d", stats.Calls, stats.Simplified),
+		"Cache hits:",
+		rate("Ground and sun", stats.Background),
+		rate("Tree shadows", stats.TreeShadows),
+		rate("Trees", stats.Trees),
+		rate("Object shadows", stats.ObjectShadows),
+		rate("Cloud shadows", stats.CloudShadows),
+		fmt.Sprintf("Images %d  %s", stats.Images, g.imageMemory(stats.ImageBytes)),
+		fmt.Sprintf("  Evicted %d", stats.Evicted),
+	}
+}
+
+// imageMemory shows how much the cached images take, against the budget
+// when there is one
+func (g *Game) imageMemory(bytes int64) string {
+	used := float64(bytes) / (1 << 20)
+	if budget := g.config.current.ImageBudgetMB; budget > 0 {
+		return fmt.Sprintf("%.1f/%d MB", used, budget)
+	}
+	return fmt.Sprintf("%.1f MB", used)
+}
+
+// drawDiagnostics shows the overlay in the bottom left, above the status
+// line
+func (g *Game) drawDiagnostics(screen *ebiten.Image) {
+	if !g.diagnostics.visible {
+		return
+	}
+	lines := g.diagnosticsLines()
+	height := float64(len(lines)*16 + 8)
+	y := float64(screenHeight) - 30 - height
+	drawRect(screen, 10, y, 230, height, color.RGBA{0, 0, 0, 160})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, 14, int(y)+4+i*16)
+	}
+}
+
+// drawLightProbes draws the light factor the trees are lit by over the
+// scene, with each tree's own value printed by its gauge
+func (g *Game) drawLightProbes(view *ebiten.Image) {
+	if !g.diagnostics.probes {
+		return
+	}
+	render.DrawLightProbes(ebitenrender.Screen{Image: view}, g.World)
+	for i := range g.Trees {
+		tree := &g.Trees[i]
+		label := fmt.Sprintf("%.2f", render.LightFactor(g.World, tree.X, tree.Y))
+		ebitenutil.DebugPrintAt(view, label, int(tree.X)+14, int(tree.Y-tree.Height())-18)
+	}
+}
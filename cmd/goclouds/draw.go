@@ -0,0 +1,31 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/render/ebitenrender"
+)
+
+// drawLine and the other overlay helpers go through the same Ebiten backend
+// as the scene, so overlays share its batched vector drawing
+func drawLine(dst *ebiten.Image, x1, y1, x2, y2 float64, c color.Color) {
+	ebitenrender.Screen{Image: dst}.DrawLine(x1, y1, x2, y2, c)
+}
+
+func drawRect(dst *ebiten.Image, x, y, width, height float64, c color.Color) {
+	ebitenrender.Screen{Image: dst}.DrawRect(x, y, width, height, c)
+}
+
+// drawFrame outlines a rectangle
+func drawFrame(dst *ebiten.Image, r rect, c color.Color) {
+	drawLine(dst, r.x, r.y, r.x+r.w, r.y, c)
+	drawLine(dst, r.x+r.w, r.y, r.x+r.w, r.y+r.h, c)
+	drawLine(dst, r.x+r.w, r.y+r.h, r.x, r.y+r.h, c)
+	drawLine(dst, r.x, r.y+r.h, r.x, r.y, c)
+}
+
+func drawCircle(dst *ebiten.Image, x, y, r float64, c color.Color) {
+	ebitenrender.Screen{Image: dst}.DrawCircle(x, y, r, c)
+}
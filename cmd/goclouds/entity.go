@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const maxNameLength = 24
+
+// Prop kinds the user can place with the P key, cycled with Shift+P
+var placeableProps = []scene.PropKind{scene.PropWindTurbine, scene.PropBirdhouse, scene.PropFeeder, scene.PropScarecrow}
+
+// entityLabel is how an entity is referred to in the UI and event log
+func entityLabel(kind string, id int, name string) string {
+	if name != "" {
+		return fmt.Sprintf("%s #%d '%s'", kind, id, name)
+	}
+	return fmt.Sprintf("%s #%d", kind, id)
+}
+
+func treeLabel(t *sim.Tree) string {
+	return entityLabel("Tree", t.ID, t.Name)
+}
+
+func propLabel(p *sim.Prop) string {
+	return entityLabel(p.Kind.String(), p.ID, p.Name)
+}
+
+// entityName returns a pointer to the name of the entity with the given ID,
+// or nil when no tree or prop has that ID
+func (g *Game) entityName(id int) *string {
+	if t := g.FindTree(id); t != nil {
+		return &t.Name
+	}
+	if p := g.FindProp(id); p != nil {
+		return &p.Name
+	}
+	return nil
+}
+
+// treeAt returns the index of the tree whose trunk or crown is at the point,
+// or -1
+func (g *Game) treeAt(x, y float64) int {
+	for i, tree := range g.Trees {
+		// Expand hitbox to include both trunk and tree crown
+		dx := x - tree.X
+		crownTop := tree.Y - tree.Size*1.2 // Account for full tree height
+		if math.Abs(dx) < tree.Size*0.4 && y >= crownTop && y <= tree.Y {
+			return i
+		}
+	}
+	return -1
+}
+
+// propAt returns the index of the prop at the point, or -1
+func (g *Game) propAt(x, y float64) int {
+	for i := range g.Props {
+		if g.Props[i].Hit(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// renameSelected prompts for a new name for the selected tree or prop
+func (g *Game) renameSelected() {
+	name := g.entityName(g.selectedID)
+	if name == nil {
+		return
+	}
+	id := g.selectedID
+	g.startTextEdit("Name", *name, maxNameLength, func(text string) {
+		// Look the entity up again in case the slices changed while typing
+		if name := g.entityName(id); name != nil && *name != text {
+			g.exec(renameCmd{id, *name, text})
+			g.logEvent("entity #%d renamed to '%s'", id, text)
+		}
+	})
+}
+
+// deleteSelected removes the group picked with a band, or else the selected
+// tree or prop or the cloud in the inspector
+func (g *Game) deleteSelected() {
+	switch {
+	case !g.selection.empty():
+		g.deleteSelection()
+	case g.FindTree(g.selectedID) != nil:
+		g.deleteTree(g.selectedID)
+	case g.FindProp(g.selectedID) != nil:
+		g.deleteProp(g.selectedID)
+	case g.menu.selectedCloud != -1:
+		g.deleteCloud(g.menu.selectedCloud)
+		g.menu.selectedCloud = -1
+	}
+}
+
+func (g *Game) deleteProp(id int) {
+	i := slices.IndexFunc(g.Props, func(p sim.Prop) bool { return p.ID == id })
+	if i == -1 {
+		return
+	}
+	cmd := removePropCmd{g.Props[i], i}
+	g.exec(cmd)
+	g.logEvent("%s", cmd.String())
+}
+
+// drawSelection outlines the selected entity and labels it with its ID and
+// name
+func (g *Game) drawSelection(screen *ebiten.Image) {
+	var label string
+	var x, y, top float64
+	if t := g.FindTree(g.selectedID); t != nil {
+		label, x, y, top = treeLabel(t), t.X, t.Y, t.Y-t.Size*1.6
+	} else if p := g.FindProp(g.selectedID); p != nil {
+		label, x, y, top = propLabel(p), p.X, p.Y, p.Y-p.Size*1.2
+	} else {
+		return
+	}
+
+	// Bracket the entity's base and mark its top
+	highlight := color.RGBA{255, 255, 255, 200}
+	drawLine(screen, x-12, y+2, x+12, y+2, highlight)
+	drawLine(screen, x, top-4, x, top-10, highlight)
+
+	if !g.textEdit.active {
+		label += " (N: Rename)"
+	}
+	width := float64(len(label)*6 + 8)
+	drawRect(screen, x-width/2, top-30, width, 18, color.RGBA{0, 0, 0, 160})
+	ebitenutil.DebugPrintAt(screen, label, int(x-width/2)+4, int(top)-28)
+}
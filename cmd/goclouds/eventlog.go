@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	eventLogFile    = "events.txt"
+	maxEvents       = 500 // Oldest entries are dropped beyond this
+	eventLogVisible = 12  // Entries shown on screen
+)
+
+type Event struct {
+	at   time.Time
+	text string
+}
+
+// EventLog records notable happenings so long-running instances have a
+// reviewable history
+type EventLog struct {
+	entries []Event
+	visible bool
+}
+
+func (l *EventLog) add(text string) {
+	l.entries = append(l.entries, Event{at: time.Now(), text: text})
+	if len(l.entries) > maxEvents {
+		l.entries = l.entries[len(l.entries)-maxEvents:]
+	}
+}
+
+func (l *EventLog) String() string {
+	var b strings.Builder
+	for _, e := range l.entries {
+		fmt.Fprintf(&b, "%s %s\n", e.at.Format("2006-01-02 15:04:05"), e.text)
+	}
+	return b.String()
+}
+
+func (l *EventLog) export(path string) error {
+	return os.WriteFile(path, []byte(l.String()), 0o644)
+}
+
+// logEvent adds a formatted entry to the event log, capturing the scene if
+// it is an event the config asks for
+func (g *Game) logEvent(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	g.events.add(text)
+	g.captureOn(text)
+}
+
+func (g *Game) drawEventLog(screen *ebiten.Image) {
+	if !g.events.visible {
+		return
+	}
+
+	width := 300.0
+	height := float64(eventLogVisible*16 + 30)
+	left := float64(screenWidth) - width - 10
+
+	drawRect(screen, left, 10, width, height, color.RGBA{0, 0, 0, 180})
+
+	x := int(left) + 5
+	y := 15
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("=== Event Log === Wishes: %d", g.wishes), x, y)
+	y += 20
+
+	start := max(0, len(g.events.entries)-eventLogVisible)
+	for _, e := range g.events.entries[start:] {
+		ebitenutil.DebugPrintAt(screen, e.at.Format("15:04")+" "+e.text, x, y)
+		y += 16
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	frameWindow   = 300  // Recent frames the median is taken over, 5 seconds at 60 FPS
+	stutterFactor = 2    // A frame this many times longer than the median is a stutter
+	maxStutters   = 50   // Stutters kept for the overlay and report, oldest dropped first
+	typicalWeight = 0.05 // How fast a subsystem's typical time follows its recent ones
+	framesFile    = "frames.txt"
+)
+
+// frameBuckets are the upper edges, in milliseconds, of the frame time
+// histogram's buckets. Anything longer goes in one last bucket.
+var frameBuckets = [...]float64{4, 8, 12, 17, 20, 25, 33, 50, 100}
+
+// subsystem is a part of the frame that is timed separately, so a stutter
+// can be blamed on the one that ran long
+type subsystem int
+
+const (
+	subSimulation subsystem = iota
+	subUpdate               // Input, editing and everything else in Update
+	subScene                // Drawing the scene through the renderer
+	subOverlays             // Menus, labels and other overlays
+	subOther                // Time between frames, spent presenting, waiting for vsync or collecting garbage
+	numSubsystems
+)
+
+var subsystemNames = [numSubsystems]string{
+	subSimulation: "simulation",
+	subUpdate:     "input and editing",
+	subScene:      "scene drawing",
+	subOverlays:   "overlays",
+	subOther:      "outside update and draw (GPU, vsync or GC)",
+}
+
+// FrameTimes records how long each frame takes in a histogram and notes
+// stutters, frames much longer than the median, along with the subsystem
+// that was slow
+type FrameTimes struct {
+	last      time.Time // When the previous frame began drawing, zero to start over
+	recent    []float64 // Last frameWindow frame times in ms, a ring
+	rebuilds  []int     // Shadow images redrawn in each of the recent frames
+	next      int
+	sorted    []float64 // Scratch for the median
+	histogram [len(frameBuckets) + 1]int
+	frames    int
+	spent     [numSubsystems]time.Duration // This frame so far
+	typical   [numSubsystems]float64       // Moving average in ms
+	stutters  []Stutter
+}
+
+// Stutter is one frame that took much longer than the ones around it
+type Stutter struct {
+	at     time.Time
+	ms     float64
+	median float64
+	parts  [numSubsystems]float64 // Time each subsystem took, ms
+	cause  subsystem
+}
+
+// add adds the time since start to a subsystem's share of the frame, as a
+// deferred call
+func (f *FrameTimes) add(s subsystem, start time.Time) {
+	f.spent[s] += time.Since(start)
+}
+
+// frame closes the previous frame as the next starts drawing, along with
+// how many shadow images it redrew. Update and Draw time their whole run as
+// subUpdate and subOverlays, so the simulation and scene drawing timed
+// within them are taken back out. Throttled frames are left out as they are
+// slow on purpose.
+func (f *FrameTimes) frame(throttled bool, rebuilds int) {
+	now := time.Now()
+	spent := f.spent
+	f.spent = [numSubsystems]time.Duration{}
+	if throttled {
+		f.last = time.Time{}
+		return
+	}
+	if f.last.IsZero() {
+		f.last = now
+		return
+	}
+	total := now.Sub(f.last)
+	f.last = now
+
+	var parts [numSubsystems]float64
+	for s, d := range spent {
+		parts[s] = ms(d)
+	}
+	parts[subUpdate] -= parts[subSimulation]
+	parts[subOverlays] -= parts[subScene]
+	parts[subOther] = ms(total - spent[subUpdate] - spent[subOverlays])
+
+	frame := ms(total)
+	median := f.median()
+	f.record(frame, rebuilds)
+	if median > 0 && frame > median*stutterFactor {
+		f.stutter(Stutter{at: now, ms: frame, median: median, parts: parts})
+	}
+	for s, p := range parts {
+		if f.frames == 1 {
+			f.typical[s] = p
+		} else {
+			f.typical[s] += (p - f.typical[s]) * typicalWeight
+		}
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// record adds a frame time to the histogram and the recent frames
+func (f *FrameTimes) record(frame float64, rebuilds int) {
+	f.frames++
+	f.histogram[bucketOf(frame)]++
+	if len(f.recent) < frameWindow {
+		f.recent = append(f.recent, frame)
+		f.rebuilds = append(f.rebuilds, rebuilds)
+		return
+	}
+	f.recent[f.next] = frame
+	f.rebuilds[f.next] = rebuilds
+	f.next = (f.next + 1) % frameWindow
+}
+
+// eachRecent calls fn with the recent frames from oldest to newest
+func (f *FrameTimes) eachRecent(fn func(i int, frame float64, rebuilds int)) {
+	for i := range f.recent {
+		j := (f.next + i) % len(f.recent)
+		fn(i, f.recent[j], f.rebuilds[j])
+	}
+}
+
+func bucketOf(frame float64) int {
+	i, _ := slices.BinarySearch(frameBuckets[:], frame)
+	return i
+}
+
+// median is the median of the recent frames, 0 before there are any
+func (f *FrameTimes) median() float64 {
+	if len(f.recent) == 0 {
+		return 0
+	}
+	f.sorted = append(f.sorted[:0], f.recent...)
+	slices.Sort(f.sorted)
+	return f.sorted[len(f.sorted)/2]
+}
+
+// stutter blames a long frame on the subsystem that ran furthest over its
+// typical time, and keeps it
+func (f *FrameTimes) stutter(s Stutter) {
+	worst := -1.0
+	for sub, p := range s.parts {
+		if over := p - f.typical[sub]; over > worst {
+			s.cause, worst = subsystem(sub), over
+		}
+	}
+	if len(f.stutters) == maxStutters {
+		f.stutters = slices.Delete(f.stutters, 0, 1)
+	}
+	f.stutters = append(f.stutters, s)
+	slog.Debug("stutter", "ms", s.ms, "median", s.median, "cause", subsystemNames[s.cause])
+}
+
+// bucketLabel names a histogram bucket by its range
+func bucketLabel(i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("<%g", frameBuckets[0])
+	case i == len(frameBuckets):
+		return fmt.Sprintf(">%g", frameBuckets[i-1])
+	}
+	return fmt.Sprintf("%g-%g", frameBuckets[i-1], frameBuckets[i])
+}
+
+// lines are the histogram and the latest stutters, for the overlay. Bars
+// are scaled to the fullest bucket.
+func (f *FrameTimes) lines(stutters int) []string {
+	lines := []string{fmt.Sprintf("Frame ms, median %.1f of %d", f.median(), f.frames)}
+	fullest := slices.Max(f.histogram[:])
+	for i, n := range f.histogram {
+		bar := 0
+		if fullest > 0 {
+			bar = (n*20 + fullest - 1) / fullest
+		}
+		lines = append(lines, fmt.Sprintf("%7s %-20s %3.0f%%", bucketLabel(i), strings.Repeat("#", bar), percent(n, f.frames)))
+	}
+	lines = append(lines, fmt.Sprintf("Stutters (>%dx median): %d", stutterFactor, len(f.stutters)))
+	for _, s := range f.stutters[max(0, len(f.stutters)-stutters):] {
+		lines = append(lines, fmt.Sprintf(" %s %3.0fms %s", s.at.Format("15:04:05"), s.ms, shortName(s.cause)))
+	}
+	return lines
+}
+
+func percent(n, of int) float64 {
+	if of == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(of)
+}
+
+// shortName fits a subsystem's name on an overlay line
+func shortName(s subsystem) string {
+	if s == subOther {
+		return "outside the frame"
+	}
+	return subsystemNames[s]
+}
+
+// export writes the histogram and every kept stutter with its breakdown,
+// for attaching to a performance report
+func (f *FrameTimes) export(path string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GoClouds frame times, %s\n", time.Now().Format(time.DateTime))
+	fmt.Fprintf(&b, "%d frames, median of the last %d: %.2f ms\n\n", f.frames, len(f.recent), f.median())
+	b.WriteString("Histogram (ms):\n")
+	for i, n := range f.histogram {
+		fmt.Fprintf(&b, "  %7s %8d  %5.1f%%\n", bucketLabel(i), n, percent(n, f.frames))
+	}
+	fmt.Fprintf(&b, "\nStutters, frames over %dx the median (last %d):\n", stutterFactor, maxStutters)
+	for _, s := range f.stutters {
+		fmt.Fprintf(&b, "  %s  %.1f ms (median %.1f), slowest: %s\n", s.at.Format("15:04:05.000"), s.ms, s.median, subsystemNames[s.cause])
+		for sub, p := range s.parts {
+			fmt.Fprintf(&b, "      %-44s %7.2f ms (typically %.2f)\n", subsystemNames[sub], p, f.typical[sub])
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
+
+// exportFrames writes the frame report and says where it went
+func (g *Game) exportFrames() {
+	if err := g.frames.export(framesFile); err != nil {
+		g.setStatus("Export failed: " + err.Error())
+		return
+	}
+	g.setStatus("Frame times exported to " + framesFile)
+}
+
+// drawFrames shows the histogram beside the diagnostics overlay
+func (g *Game) drawFrames(screen *ebiten.Image) {
+	if !g.diagnostics.visible {
+		return
+	}
+	lines := g.frames.lines(5)
+	height := float64(len(lines)*16 + 8)
+	y := float64(screenHeight) - 30 - height
+	drawRect(screen, 250, y, 230, height, color.RGBA{0, 0, 0, 160})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, 254, int(y)+4+i*16)
+	}
+}
+
+const (
+	graphHeight = 80
+	graphMaxMS  = 50.0 // Frame time at the top of the graph; longer frames are cut off
+)
+
+// drawFrameGraph shows the recent frame times as a rolling graph in the
+// bottom right, a bar a frame, with lines at 60 and 30 FPS. A tick above a
+// bar marks a frame that redrew shadow images, a common cause of stutter.
+func (g *Game) drawFrameGraph(screen *ebiten.Image) {
+	if !g.diagnostics.graph {
+		return
+	}
+	f := &g.frames
+	x := float64(screenWidth - frameWindow - 14)
+	y := float64(screenHeight - 60 - graphHeight)
+	drawRect(screen, x-4, y-22, frameWindow+8, graphHeight+26, color.RGBA{0, 0, 0, 160})
+	latest, rebuilt := 0.0, 0
+	f.eachRecent(func(i int, frame float64, rebuilds int) {
+		latest = frame
+		if rebuilds > 0 {
+			rebuilt++
+		}
+	})
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Frame %.1fms  median %.1f  redraws %d", latest, f.median(), rebuilt), int(x), int(y)-20)
+	for _, target := range []float64{1000.0 / 60, 1000.0 / 30} {
+		drawRect(screen, x, y+graphHeight*(1-target/graphMaxMS), frameWindow, 1, color.RGBA{255, 255, 255, 60})
+	}
+	f.eachRecent(func(i int, frame float64, rebuilds int) {
+		h := graphHeight * min(frame, graphMaxMS) / graphMaxMS
+		c := color.RGBA{80, 200, 80, 255}
+		switch {
+		case frame > 1000.0/30:
+			c = color.RGBA{230, 60, 60, 255}
+		case frame > 1000.0/60+1:
+			c = color.RGBA{230, 200, 60, 255}
+		}
+		drawRect(screen, x+float64(i), y+graphHeight-h, 1, h, c)
+		if rebuilds > 0 {
+			drawRect(screen, x+float64(i), y, 1, 4, color.RGBA{80, 160, 255, 255})
+		}
+	})
+}
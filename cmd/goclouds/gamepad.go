@@ -0,0 +1,248 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	stickDeadZone = 0.2   // Stick travel ignored as drift
+	stickPush     = 0.6   // Right stick travel that counts as a d-pad press
+	padSunSpeed   = 300.0 // Pixels a second the left stick moves the sun at full tilt
+)
+
+// padButton is a button by its place on the standard layout, along with
+// the raw button it usually is on pads Ebiten has no mapping for, in the
+// order XInput and most Xbox-style pads report them
+type padButton struct {
+	standard ebiten.StandardGamepadButton
+	raw      ebiten.GamepadButton
+}
+
+var (
+	padA     = padButton{ebiten.StandardGamepadButtonRightBottom, ebiten.GamepadButton0}
+	padB     = padButton{ebiten.StandardGamepadButtonRightRight, ebiten.GamepadButton1}
+	padX     = padButton{ebiten.StandardGamepadButtonRightLeft, ebiten.GamepadButton2}
+	padLB    = padButton{ebiten.StandardGamepadButtonFrontTopLeft, ebiten.GamepadButton4}
+	padRB    = padButton{ebiten.StandardGamepadButtonFrontTopRight, ebiten.GamepadButton5}
+	padBack  = padButton{ebiten.StandardGamepadButtonCenterLeft, ebiten.GamepadButton6}
+	padStart = padButton{ebiten.StandardGamepadButtonCenterRight, ebiten.GamepadButton7}
+
+	// The d-pad, indexed by padDir
+	padDPad = [4]padButton{
+		{ebiten.StandardGamepadButtonLeftTop, ebiten.GamepadButton11},
+		{ebiten.StandardGamepadButtonLeftBottom, ebiten.GamepadButton13},
+		{ebiten.StandardGamepadButtonLeftLeft, ebiten.GamepadButton14},
+		{ebiten.StandardGamepadButtonLeftRight, ebiten.GamepadButton12},
+	}
+)
+
+// padDir is a direction on the d-pad or right stick
+type padDir int
+
+const (
+	padUp padDir = iota
+	padDown
+	padLeft
+	padRight
+)
+
+// padAxis is a stick axis by its place on the standard layout and its raw
+// index on unmapped pads
+type padAxis struct {
+	standard ebiten.StandardGamepadAxis
+	raw      int
+}
+
+var (
+	padLeftX  = padAxis{ebiten.StandardGamepadAxisLeftStickHorizontal, 0}
+	padLeftY  = padAxis{ebiten.StandardGamepadAxisLeftStickVertical, 1}
+	padRightX = padAxis{ebiten.StandardGamepadAxisRightStickHorizontal, 2}
+	padRightY = padAxis{ebiten.StandardGamepadAxisRightStickVertical, 3}
+)
+
+// Gamepad is the state kept between frames for the connected gamepads
+type Gamepad struct {
+	ids      []ebiten.GamepadID
+	stick    [4]int // Frames the right stick has been held in each direction
+	sunFrom  point  // Where the sun was when the left stick started moving it
+	sunMoved bool
+}
+
+// held is how many frames a button has been held, 0 when it is up
+func (b padButton) held(id ebiten.GamepadID) int {
+	if ebiten.IsStandardGamepadLayoutAvailable(id) {
+		return inpututil.StandardGamepadButtonPressDuration(id, b.standard)
+	}
+	return inpututil.GamepadButtonPressDuration(id, b.raw)
+}
+
+func (b padButton) pressed(id ebiten.GamepadID) bool {
+	return b.held(id) == 1
+}
+
+// value is an axis's position from -1 to 1, 0 inside the dead zone
+func (a padAxis) value(id ebiten.GamepadID) float64 {
+	var v float64
+	if ebiten.IsStandardGamepadLayoutAvailable(id) {
+		v = ebiten.StandardGamepadAxisValue(id, a.standard)
+	} else {
+		v = ebiten.GamepadAxisValue(id, a.raw)
+	}
+	if math.Abs(v) < stickDeadZone {
+		return 0
+	}
+	return v
+}
+
+// repeats reports a press, then repeats it while held, as keyRepeat does
+// for keys
+func repeats(held int) bool {
+	return held == 1 || (held > 20 && held%3 == 0)
+}
+
+// updateGamepad handles every connected gamepad. Start toggles the menu;
+// with it open the d-pad moves through it, A activates, B closes it and
+// the bumpers turn its pages. Otherwise the left stick moves the sun, the
+// d-pad or right stick sets the cloud (up/down) and tree (left/right)
+// counts, A generates the next template, X picks the next mood, the
+// bumpers set the shadow and Back hides the HUD.
+func (g *Game) updateGamepad(dt float64) {
+	p := &g.gamepad
+	for _, id := range inpututil.AppendJustConnectedGamepadIDs(nil) {
+		layout := "standard layout"
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			layout = "assuming an Xbox layout"
+		}
+		g.setStatus("Gamepad connected: " + ebiten.GamepadName(id) + " (" + layout + ")")
+		g.logEvent("gamepad %s connected", ebiten.GamepadName(id))
+	}
+	p.ids = ebiten.AppendGamepadIDs(p.ids[:0])
+	var sunX, sunY float64
+	for _, id := range p.ids {
+		if padStart.pressed(id) {
+			g.menu.visible = !g.menu.visible
+		}
+		var dirs [4]bool
+		for d, b := range padDPad {
+			dirs[d] = repeats(b.held(id))
+		}
+		for d, v := range [4]float64{-padRightY.value(id), padRightY.value(id), -padRightX.value(id), padRightX.value(id)} {
+			if v > stickPush {
+				p.stick[d]++
+				dirs[d] = dirs[d] || repeats(p.stick[d])
+			} else {
+				p.stick[d] = 0
+			}
+		}
+		if g.menu.visible {
+			g.padMenu(id, dirs)
+			continue
+		}
+		g.padScene(id, dirs)
+		sunX += padLeftX.value(id)
+		sunY += padLeftY.value(id)
+	}
+	g.padSun(sunX, sunY, dt)
+}
+
+// padMenu moves through the menu like the arrow keys, Enter and Tab
+func (g *Game) padMenu(id ebiten.GamepadID, dirs [4]bool) {
+	pages := g.menuPages()
+	if padLB.pressed(id) {
+		pages.page = cycle(pages.page, -1, len(pages.panels))
+	}
+	if padRB.pressed(id) {
+		pages.page = cycle(pages.page, 1, len(pages.panels))
+	}
+	panel := pages.current()
+	if len(panel.widgets) == 0 {
+		return
+	}
+	if dirs[padUp] {
+		panel.focus = cycle(panel.focus, -1, len(panel.widgets))
+	}
+	if dirs[padDown] {
+		panel.focus = cycle(panel.focus, 1, len(panel.widgets))
+	}
+	w := panel.widgets[panel.focus]
+	if dirs[padLeft] {
+		w.adjust(-1)
+	}
+	if dirs[padRight] {
+		w.adjust(1)
+	}
+	if padA.pressed(id) {
+		w.activate()
+	}
+	if padB.pressed(id) {
+		g.menu.visible = false
+	}
+}
+
+// padScene changes the counts, shadow, template and mood
+func (g *Game) padScene(id ebiten.GamepadID, dirs [4]bool) {
+	if dirs[padUp] {
+		g.setCloudCount(min(g.MaxClouds, g.CloudCount+10))
+	}
+	if dirs[padDown] {
+		g.setCloudCount(max(0, g.CloudCount-10))
+	}
+	if dirs[padLeft] {
+		g.setTreeCount(max(1, g.TreeCount-1))
+	}
+	if dirs[padRight] {
+		g.setTreeCount(min(20, g.TreeCount+1))
+	}
+	if padLB.pressed(id) {
+		g.setTreeShadow(math.Max(0.2, g.TreeShadow-0.1))
+	}
+	if padRB.pressed(id) {
+		g.setTreeShadow(math.Min(2.0, g.TreeShadow+0.1))
+	}
+	if padA.pressed(id) {
+		g.menu.template = (g.menu.template + 1) % len(scene.Templates)
+		g.applyTemplate()
+	}
+	if padX.pressed(id) {
+		// Off, then each mood in turn
+		if i := g.moodIndex() + 1; i < len(sim.Moods) {
+			g.setMood(&sim.Moods[i])
+		} else {
+			g.setMood(nil)
+		}
+	}
+	if padBack.pressed(id) {
+		g.setHUDHidden(!g.hud.hidden, false)
+	}
+}
+
+// padSun moves the sun with the left stick as one undoable move from when
+// the stick is pushed until it is let go
+func (g *Game) padSun(x, y, dt float64) {
+	p := &g.gamepad
+	if x == 0 && y == 0 {
+		if to := (point{g.SunX, g.SunY}); p.sunMoved && to != p.sunFrom {
+			g.record(moveSunCmd{p.sunFrom, to})
+		}
+		p.sunMoved = false
+		return
+	}
+	if !p.sunMoved && g.SunLocked {
+		g.setStatus("The sun is locked (right-click to unlock)")
+	}
+	if !p.sunMoved {
+		p.sunFrom, p.sunMoved = point{g.SunX, g.SunY}, true
+		g.FinishTransition()
+	}
+	if g.SunLocked {
+		return
+	}
+	g.SunX = math.Max(sim.SunRadius, math.Min(float64(g.Width)-sim.SunRadius, g.SunX+x*padSunSpeed*dt))
+	g.SunY = math.Max(sim.SunRadius, math.Min(float64(screenHeight)-scene.GroundHeight-10, g.SunY+y*padSunSpeed*dt))
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// A small widget toolkit for the overlay panels: sliders, buttons, rows of
+// buttons and labels stacked in a Panel, and Pages of panels behind tabs.
+// Widgets work with the mouse and, through the panel's focus, with Up/Down,
+// Left/Right and Enter.
+
+const (
+	widgetHeight = 18
+	widgetGap    = 4
+	charWidth    = 6 // Width of a character in Ebiten's debug font
+)
+
+var (
+	widgetHover = color.RGBA{255, 255, 255, 30}
+	widgetFocus = color.RGBA{255, 255, 255, 60}
+	widgetEdge  = color.RGBA{255, 255, 255, 120}
+	widgetTrack = color.RGBA{255, 255, 255, 70}
+	widgetFill  = color.RGBA{120, 180, 255, 220}
+)
+
+// rect is an area of the screen, in screen pixels
+type rect struct {
+	x, y, w, h float64
+}
+
+func (r rect) contains(x, y float64) bool {
+	return x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h
+}
+
+// mouse is the left button and cursor as widgets see them this frame, a
+// finger standing in for them on a touchscreen
+type mouse struct {
+	x, y    float64
+	down    bool // Held
+	pressed bool // Went down this frame
+}
+
+func (g *Game) readMouse() mouse {
+	x, y := g.pointerPosition()
+	return mouse{
+		x:       float64(x),
+		y:       float64(y),
+		down:    g.pointerDown(),
+		pressed: g.pointerPressed(),
+	}
+}
+
+// widget is one row of a Panel, laid out in the area it is given
+type widget interface {
+	// press handles a click inside the widget, reporting whether it wants
+	// to follow the mouse until the button is released
+	press(m mouse, area rect) bool
+	// drag follows the mouse after press asked to
+	drag(m mouse, area rect)
+	// adjust and activate are the keyboard's Left/Right and Enter
+	adjust(dir int)
+	activate()
+	draw(screen *ebiten.Image, area rect, m mouse, focused bool)
+}
+
+// tipper is a widget with a line of help shown when the pointer rests on it
+type tipper interface {
+	tip() string
+}
+
+// Panel stacks widgets in rows from the top of its area
+type Panel struct {
+	area    rect
+	widgets []widget
+	focus   int
+	held    widget // Widget following the mouse, nil when none
+	heldRow int
+}
+
+// row is the area of widget i
+func (p *Panel) row(i int) rect {
+	return rect{p.area.x, p.area.y + float64(i*(widgetHeight+widgetGap)), p.area.w, widgetHeight}
+}
+
+// bottom is the screen y just below the last row
+func (p *Panel) bottom() float64 {
+	return p.row(len(p.widgets)).y
+}
+
+// dragging reports whether a widget is following the mouse
+func (p *Panel) dragging() bool {
+	return p.held != nil
+}
+
+// update handles the mouse, reporting whether the panel took it so clicks
+// on the panel don't also reach the scene underneath
+func (p *Panel) update(m mouse) bool {
+	if p.held != nil {
+		if !m.down {
+			p.held = nil
+			return true
+		}
+		p.held.drag(m, p.row(p.heldRow))
+		return true
+	}
+	if !m.pressed {
+		return false
+	}
+	for i, w := range p.widgets {
+		if area := p.row(i); area.contains(m.x, m.y) {
+			p.focus = i
+			if w.press(m, area) {
+				p.held, p.heldRow = w, i
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// hovered returns the help for the widget under the pointer, if it has any
+func (p *Panel) hovered(m mouse) string {
+	for i, w := range p.widgets {
+		if t, ok := w.(tipper); ok && p.row(i).contains(m.x, m.y) {
+			return t.tip()
+		}
+	}
+	return ""
+}
+
+// updateKeys moves focus with Up/Down, adjusts the focused widget with
+// Left/Right and activates it with Enter
+func (p *Panel) updateKeys() {
+	if len(p.widgets) == 0 {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		p.focus = cycle(p.focus, -1, len(p.widgets))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		p.focus = cycle(p.focus, 1, len(p.widgets))
+	}
+	w := p.widgets[p.focus]
+	if keyRepeat(ebiten.KeyLeft) {
+		w.adjust(-1)
+	}
+	if keyRepeat(ebiten.KeyRight) {
+		w.adjust(1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		w.activate()
+	}
+}
+
+func (p *Panel) draw(screen *ebiten.Image, m mouse) {
+	for i, w := range p.widgets {
+		w.draw(screen, p.row(i), m, i == p.focus)
+	}
+}
+
+// drawBackdrop shades a widget's area when it has focus or the mouse is over it
+func drawBackdrop(screen *ebiten.Image, area rect, hover, focused bool) {
+	switch {
+	case focused:
+		drawRect(screen, area.x, area.y, area.w, area.h, widgetFocus)
+	case hover:
+		drawRect(screen, area.x, area.y, area.w, area.h, widgetHover)
+	}
+}
+
+// Slider picks a number between two limits in fixed steps by dragging
+type Slider struct {
+	label  func() string // Shown left of the track, usually with the value
+	value  func() float64
+	set    func(v float64)
+	limits func() (lo, hi float64)
+	step   float64
+	help   string
+}
+
+func (s *Slider) tip() string { return s.help }
+
+// sliderLabel is how much of a slider's row its label takes
+const sliderLabel = 112
+
+func (s *Slider) track(area rect) rect {
+	return rect{area.x + sliderLabel, area.y, area.w - sliderLabel - 6, area.h}
+}
+
+func (s *Slider) press(m mouse, area rect) bool {
+	s.drag(m, area)
+	return true
+}
+
+// drag sets the value under the mouse, snapped to a step
+func (s *Slider) drag(m mouse, area rect) {
+	t := s.track(area)
+	lo, hi := s.limits()
+	frac := math.Max(0, math.Min(1, (m.x-t.x)/t.w))
+	s.setClamped(lo + math.Round(frac*(hi-lo)/s.step)*s.step)
+}
+
+func (s *Slider) adjust(dir int) {
+	s.setClamped(s.value() + float64(dir)*s.step)
+}
+
+func (s *Slider) activate() {}
+
+func (s *Slider) setClamped(v float64) {
+	lo, hi := s.limits()
+	// Round away float drift from adding steps so values compare equal
+	v = math.Round(math.Max(lo, math.Min(hi, v))/s.step) * s.step
+	if v != s.value() {
+		s.set(v)
+	}
+}
+
+func (s *Slider) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	drawBackdrop(screen, area, area.contains(m.x, m.y), focused)
+	ebitenutil.DebugPrintAt(screen, s.label(), int(area.x)+4, int(area.y)+1)
+
+	t := s.track(area)
+	lo, hi := s.limits()
+	frac := 0.0
+	if hi > lo {
+		frac = (s.value() - lo) / (hi - lo)
+	}
+	mid := t.y + t.h/2
+	drawRect(screen, t.x, mid-1, t.w, 2, widgetTrack)
+	drawRect(screen, t.x, mid-1, t.w*frac, 2, widgetFill)
+	knob := color.Color(widgetEdge)
+	if focused || t.contains(m.x, m.y) {
+		knob = color.White
+	}
+	drawRect(screen, t.x+t.w*frac-3, t.y+2, 6, t.h-4, knob)
+}
+
+// Button runs click when pressed. Left/Right call step when it is set, for
+// buttons that cycle through choices.
+type Button struct {
+	label func() string
+	click func()
+	step  func(dir int)
+	help  string
+}
+
+func (b *Button) tip() string { return b.help }
+
+func (b *Button) press(m mouse, area rect) bool {
+	b.click()
+	return false
+}
+
+func (b *Button) drag(m mouse, area rect) {}
+
+func (b *Button) adjust(dir int) {
+	if b.step != nil {
+		b.step(dir)
+	}
+}
+
+func (b *Button) activate() { b.click() }
+
+func (b *Button) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	drawButton(screen, area, b.label(), area.contains(m.x, m.y), focused)
+}
+
+// drawButton draws an outlined button with its label centered
+func drawButton(screen *ebiten.Image, area rect, label string, hover, focused bool) {
+	drawBackdrop(screen, area, hover, focused)
+	x0, y0, x1, y1 := area.x, area.y, area.x+area.w-1, area.y+area.h-1
+	drawLine(screen, x0, y0, x1, y0, widgetEdge)
+	drawLine(screen, x0, y1, x1, y1, widgetEdge)
+	drawLine(screen, x0, y0, x0, y1, widgetEdge)
+	drawLine(screen, x1, y0, x1, y1, widgetEdge)
+	x := area.x + area.w/2 - float64(len(label)*charWidth)/2
+	ebitenutil.DebugPrintAt(screen, label, int(x), int(area.y)+1)
+}
+
+// ButtonRow shares one row between several buttons, such as a set of
+// presets. Left/Right move between them and Enter presses the chosen one.
+type ButtonRow struct {
+	labels   []string
+	click    func(i int)
+	selected func() int // Button drawn as chosen, -1 for none
+	cursor   int
+	help     string
+}
+
+func (b *ButtonRow) tip() string { return b.help }
+
+func (b *ButtonRow) button(area rect, i int) rect {
+	r := shareRow(area, b.labels, i)
+	return rect{r.x + 1, r.y, r.w - 2, r.h}
+}
+
+// shareRow is where the ith of several labelled parts of a row sits. Each
+// is as wide as its label, with the room left over shared evenly, so long
+// labels don't run into their neighbors.
+func shareRow(area rect, labels []string, i int) rect {
+	chars := 0
+	for _, l := range labels {
+		chars += len(l)
+	}
+	spare := (area.w - float64(chars*charWidth)) / float64(len(labels))
+	x := area.x
+	for _, l := range labels[:i] {
+		x += float64(len(l)*charWidth) + spare
+	}
+	return rect{x, area.y, float64(len(labels[i])*charWidth) + spare, area.h}
+}
+
+func (b *ButtonRow) press(m mouse, area rect) bool {
+	for i := range b.labels {
+		if b.button(area, i).contains(m.x, m.y) {
+			b.cursor = i
+			b.click(i)
+		}
+	}
+	return false
+}
+
+func (b *ButtonRow) drag(m mouse, area rect) {}
+
+func (b *ButtonRow) adjust(dir int) {
+	b.cursor = cycle(b.cursor, dir, len(b.labels))
+}
+
+func (b *ButtonRow) activate() { b.click(b.cursor) }
+
+func (b *ButtonRow) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	for i, label := range b.labels {
+		r := b.button(area, i)
+		if b.selected != nil && b.selected() == i {
+			drawRect(screen, r.x, r.y, r.w, r.h, color.RGBA{120, 180, 255, 70})
+		}
+		drawButton(screen, r, label, r.contains(m.x, m.y), focused && i == b.cursor)
+	}
+}
+
+// Stepper is a value with - and + buttons at the right end of its row.
+// Clicking the value itself, or Enter, asks for a new one to be typed.
+type Stepper struct {
+	label func() string // Name and value
+	step  func(dir int)
+	enter func()
+	help  string
+}
+
+// stepperButton is the width of each of a stepper's buttons
+const stepperButton = 20
+
+// buttons returns the areas of the - and + buttons
+func (s *Stepper) buttons(area rect) (minus, plus rect) {
+	plus = rect{area.x + area.w - stepperButton, area.y, stepperButton, area.h}
+	minus = rect{plus.x - stepperButton - 2, area.y, stepperButton, area.h}
+	return minus, plus
+}
+
+func (s *Stepper) press(m mouse, area rect) bool {
+	switch minus, plus := s.buttons(area); {
+	case minus.contains(m.x, m.y):
+		s.step(-1)
+	case plus.contains(m.x, m.y):
+		s.step(1)
+	default:
+		s.enter()
+	}
+	return false
+}
+
+func (s *Stepper) drag(m mouse, area rect) {}
+func (s *Stepper) adjust(dir int)          { s.step(dir) }
+func (s *Stepper) activate()               { s.enter() }
+func (s *Stepper) tip() string             { return s.help }
+
+func (s *Stepper) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	minus, plus := s.buttons(area)
+	value := rect{area.x, area.y, minus.x - area.x - 2, area.h}
+	drawBackdrop(screen, value, value.contains(m.x, m.y), focused)
+	ebitenutil.DebugPrintAt(screen, s.label(), int(area.x)+4, int(area.y)+1)
+	drawButton(screen, minus, "-", minus.contains(m.x, m.y), false)
+	drawButton(screen, plus, "+", plus.contains(m.x, m.y), false)
+}
+
+// Label is a row of text that does nothing when clicked
+type Label struct {
+	text func() string
+}
+
+// labels makes a Label for each line of fixed text
+func labels(lines ...string) []widget {
+	ws := make([]widget, len(lines))
+	for i, line := range lines {
+		ws[i] = &Label{text: func() string { return line }}
+	}
+	return ws
+}
+
+func (l *Label) press(m mouse, area rect) bool { return false }
+func (l *Label) drag(m mouse, area rect)       {}
+func (l *Label) adjust(dir int)                {}
+func (l *Label) activate()                     {}
+
+func (l *Label) draw(screen *ebiten.Image, area rect, m mouse, focused bool) {
+	ebitenutil.DebugPrintAt(screen, l.text(), int(area.x)+4, int(area.y)+1)
+}
+
+// Pages shows one of several panels at a time under a row of tabs, turned
+// by clicking a tab or with Tab and Shift+Tab
+type Pages struct {
+	area   rect // Where the tabs sit, the panels go below them
+	titles []string
+	panels []*Panel
+	page   int
+}
+
+func (p *Pages) tab(i int) rect {
+	return shareRow(rect{p.area.x, p.area.y, p.area.w, widgetHeight}, p.titles, i)
+}
+
+func (p *Pages) current() *Panel {
+	return p.panels[p.page]
+}
+
+// update turns the page on a tab click or Tab, then passes the mouse to the
+// panel showing, reporting whether either took it
+func (p *Pages) update(m mouse) bool {
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) && !ebiten.IsKeyPressed(ebiten.KeyControl) {
+		dir := 1
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			dir = -1
+		}
+		p.page = cycle(p.page, dir, len(p.panels))
+	}
+	if m.pressed && !p.current().dragging() {
+		for i := range p.titles {
+			if p.tab(i).contains(m.x, m.y) {
+				p.page = i
+				return true
+			}
+		}
+	}
+	return p.current().update(m)
+}
+
+// hovered returns the help for the tab or widget under the pointer
+func (p *Pages) hovered(m mouse) string {
+	for i, title := range p.titles {
+		if p.tab(i).contains(m.x, m.y) {
+			return "Show the " + title + " page (Tab/Shift+Tab turn pages)"
+		}
+	}
+	return p.current().hovered(m)
+}
+
+func (p *Pages) draw(screen *ebiten.Image, m mouse) {
+	for i, title := range p.titles {
+		r := p.tab(i)
+		if i == p.page {
+			drawRect(screen, r.x, r.y, r.w, r.h, widgetFocus)
+		} else if r.contains(m.x, m.y) {
+			drawRect(screen, r.x, r.y, r.w, r.h, widgetHover)
+		}
+		x := r.x + r.w/2 - float64(len(title)*charWidth)/2
+		ebitenutil.DebugPrintAt(screen, title, int(x), int(r.y)+1)
+	}
+	r := p.tab(p.page)
+	drawLine(screen, p.area.x, r.y+r.h, p.area.x+p.area.w, r.y+r.h, widgetEdge)
+	drawLine(screen, r.x, r.y+r.h, r.x+r.w, r.y+r.h, widgetFill)
+	p.current().draw(screen, m)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"time"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	configPollInterval = 1.0 // Seconds between checks of the config file
+	configTweenTime    = 1.5 // Seconds changed values take to reach their new setting
+)
+
+// ConfigWatch notices edits to the config file while the app runs and eases
+// the changed values over to their new settings
+type ConfigWatch struct {
+	path     string
+	modTime  time.Time
+	nextPoll float64
+	current  Config // Last applied config
+
+	tweens   []tween
+	progress float64 // 0-1 through the tweens
+
+	calibrationSave float64 // Seconds until the calibration is written to the file, 0 when it has been
+}
+
+// tween eases one value from one setting to another
+type tween struct {
+	from, to float64
+	set      func(g *Game, v float64)
+}
+
+// watchConfig starts watching the config file the game was started with
+func (g *Game) watchConfig(path string, cfg Config) {
+	g.config = ConfigWatch{path: path, current: cfg}
+	if info, err := os.Stat(path); err == nil {
+		g.config.modTime = info.ModTime()
+	}
+}
+
+// updateConfigWatch polls the config file and steps any running tweens
+func (g *Game) updateConfigWatch(dt float64) {
+	w := &g.config
+	if len(w.tweens) > 0 {
+		w.progress = math.Min(1, w.progress+dt/configTweenTime)
+		ease := w.progress * w.progress * (3 - 2*w.progress)
+		for _, t := range w.tweens {
+			t.set(g, t.from+(t.to-t.from)*ease)
+		}
+		if w.progress >= 1 {
+			w.tweens = nil
+			g.trimClouds()
+		}
+	}
+
+	if w.path == "" || g.SimTime < w.nextPoll {
+		return
+	}
+	w.nextPoll = g.SimTime + configPollInterval
+	info, err := os.Stat(w.path)
+	if err != nil || !info.ModTime().After(w.modTime) {
+		return
+	}
+	w.modTime = info.ModTime()
+
+	cfg, err := loadConfig(w.path)
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		// Most likely saved halfway through an edit, keep what is running
+		g.setStatus("Config not reloaded: " + err.Error())
+		return
+	}
+	g.applyConfig(cfg)
+	if err != nil {
+		slog.Warn("reloading config", "path", w.path, "err", err)
+		g.setStatus("Config reloaded with fixes: " + err.Error())
+	}
+}
+
+// applyConfig eases the scene over to a reloaded config. Window and world
+// size, seed and the sync group only matter at startup, so they keep the
+// values the app started with.
+func (g *Game) applyConfig(cfg Config) {
+	w := &g.config
+	old := w.current
+	cfg.Width, cfg.Height, cfg.Seed, cfg.WorldWidth = old.Width, old.Height, old.Seed, old.WorldWidth
+	cfg.Sync, cfg.SyncPort = old.Sync, old.SyncPort
+	w.current = cfg
+	w.tweens = nil
+	w.progress = 0
+
+	g.setStatus("Config reloaded")
+	g.logEvent("config reloaded from %s", w.path)
+
+	g.keys = cfg.Keys
+	if cfg.ReducedMotion != old.ReducedMotion {
+		g.setReducedMotion(cfg.ReducedMotion)
+	}
+	if cfg.Density != old.Density {
+		w.tweens = append(w.tweens, tween{g.Density, cfg.Density, func(g *Game, v float64) {
+			g.Density = v
+		}})
+	}
+	if cfg.Wind != old.Wind {
+		w.tweens = append(w.tweens, tween{g.Wind.Base, cfg.Wind, func(g *Game, v float64) {
+			g.Wind.Base = v
+		}})
+	}
+	if cfg.ScarecrowRadius != old.ScarecrowRadius {
+		w.tweens = append(w.tweens, tween{g.ScarecrowRadius, cfg.ScarecrowRadius, func(g *Game, v float64) {
+			g.ScarecrowRadius = v
+		}})
+	}
+	if cfg.TreeCount != old.TreeCount {
+		// Trees come and go one at a time
+		w.tweens = append(w.tweens, tween{float64(g.TreeCount), float64(cfg.TreeCount), func(g *Game, v float64) {
+			if n := int(math.Round(v)); n != g.TreeCount {
+				g.TreeCount = n
+				g.UpdateTreeCount()
+			}
+		}})
+	}
+	if cfg.CloudCount != old.CloudCount {
+		// New clouds drift in from the left, removed ones are dropped once
+		// the count has eased down
+		for len(g.Clouds) < cfg.CloudCount {
+			g.Clouds = append(g.Clouds, sim.Cloud{
+				X:         -g.Margins.CloudWrap - rng.Float64()*float64(g.Width),
+				Y:         g.CloudY(rng.Float64()),
+				Speed:     1 + rng.Float64()*2,
+				Size:      30 + rng.Float64()*50,
+				Opacity:   0.3 + rng.Float64()*0.5,
+				ShapeSeed: rng.Float64() * 2 * math.Pi,
+			})
+		}
+		g.MaxClouds = max(g.MaxClouds, cfg.CloudCount)
+		w.tweens = append(w.tweens, tween{float64(g.CloudCount), float64(cfg.CloudCount), func(g *Game, v float64) {
+			g.CloudCount = int(math.Round(v))
+		}})
+	}
+	if cfg.Margins != old.Margins {
+		// Only new clouds and trees spawn within them, so nothing moves
+		g.Margins = cfg.Margins
+	}
+	if cfg.Mood != old.Mood {
+		m, _ := sim.FindMood(cfg.Mood)
+		g.setMood(m)
+	}
+	if cfg.Palette != old.Palette {
+		from := g.Palette
+		to, _ := g.PaletteFor(cfg.Palette)
+		g.PaletteName = cfg.Palette
+		w.tweens = append(w.tweens, tween{0, 1, func(g *Game, v float64) {
+			g.Palette = lerpPalette(from, to, v)
+		}})
+	}
+}
+
+// trimClouds drops clouds beyond the configured count once it has eased down
+func (g *Game) trimClouds() {
+	n := g.config.current.CloudCount
+	if n < len(g.Clouds) {
+		g.Clouds = g.Clouds[:n]
+		g.MaxClouds = n
+		g.CloudCount = min(g.CloudCount, n)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const cursorIdle = 3.0 // Seconds the pointer rests before presentation mode hides it
+
+// HUD is whether everything drawn over the scene is hidden, for clean
+// viewing and screenshots. Presentation mode hides the pointer too once it
+// has been left alone.
+type HUD struct {
+	hidden       bool
+	presentation bool
+	idle         float64 // Seconds since the pointer moved or a button or key was pressed
+	lastX, lastY int
+	cursorHidden bool
+}
+
+// setHUDHidden hides or shows the overlays. The menu and context menu close
+// and the selection is dropped as they would take clicks unseen; the event
+// log, diagnostics and other overlays come back as they were.
+func (g *Game) setHUDHidden(hidden, presentation bool) {
+	h := &g.hud
+	h.hidden, h.presentation = hidden, hidden && presentation
+	h.idle = 0
+	if hidden {
+		g.menu.visible = false
+		g.context.panel = nil
+		g.selectedID = 0
+		g.menu.selectedCloud = -1
+	}
+	g.showCursor(true)
+}
+
+// updateHUD toggles the HUD with its key, Shift for presentation mode, and
+// in presentation mode hides the pointer after it rests. Opening the menu
+// shows the HUD again.
+func (g *Game) updateHUD(dt float64) {
+	if g.pressed("hud") {
+		g.setHUDHidden(!g.hud.hidden, ebiten.IsKeyPressed(ebiten.KeyShift))
+	}
+	h := &g.hud
+	if h.hidden && g.menu.visible {
+		g.setHUDHidden(false, false)
+	}
+	if !h.presentation {
+		return
+	}
+	x, y := g.pointerPosition()
+	if x != h.lastX || y != h.lastY || len(inpututil.AppendJustPressedKeys(nil)) > 0 ||
+		g.pointerPressed() || g.secondaryPressed() {
+		h.lastX, h.lastY, h.idle = x, y, 0
+		g.showCursor(true)
+		return
+	}
+	h.idle += dt
+	if h.idle >= cursorIdle {
+		g.showCursor(false)
+	}
+}
+
+func (g *Game) showCursor(show bool) {
+	if g.hud.cursorHidden != show {
+		return
+	}
+	g.hud.cursorHidden = !show
+	if show {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	}
+}
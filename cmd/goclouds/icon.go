@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/sim"
+)
+
+// iconSizes are the sizes the window icon is drawn at, so the desktop can
+// pick whichever suits its taskbar or dock
+var iconSizes = []int{16, 32, 48}
+
+// weatherGlyph is the picture the window icon shows
+type weatherGlyph int
+
+const (
+	glyphSun weatherGlyph = iota
+	glyphSunCloud
+	glyphCloud
+	glyphRain
+)
+
+// iconKey is what the window icon was last drawn from
+type iconKey struct {
+	glyph      weatherGlyph
+	sun, cloud color.RGBA
+}
+
+// glyphFor picks the glyph for the scene's conditions. Wet ground keeps the
+// rain glyph up for a while after the rain stops.
+func glyphFor(w *sim.World) weatherGlyph {
+	switch {
+	case w.Raining() || w.Wet():
+		return glyphRain
+	case w.Sky() <= sim.SkyFewClouds:
+		return glyphSun
+	case w.Sky() == sim.SkyPartlyCloudy:
+		return glyphSunCloud
+	}
+	return glyphCloud
+}
+
+// weatherIcon draws a glyph at each of iconSizes, on a 32x32 canvas scaled
+// to fit
+func weatherIcon(key iconKey) []image.Image {
+	var icons []image.Image
+	for _, size := range iconSizes {
+		r := render.NewRaster(size, size, 32, 32)
+		switch key.glyph {
+		case glyphSun:
+			drawIconSun(r, 16, 16, 9, key.sun)
+		case glyphSunCloud:
+			drawIconSun(r, 20, 11, 7, key.sun)
+			drawIconCloud(r, 14, 22, key.cloud)
+		case glyphCloud:
+			drawIconCloud(r, 16, 18, key.cloud)
+		case glyphRain:
+			drawIconCloud(r, 16, 13, key.cloud)
+			drop := color.RGBA{90, 150, 255, 255}
+			for _, x := range []float64{9, 16, 23} {
+				r.DrawLine(x, 21, x-3, 29, drop)
+			}
+		}
+		icons = append(icons, r.Image)
+	}
+	return icons
+}
+
+// drawIconSun draws a disc with short rays around it
+func drawIconSun(r render.Renderer, x, y, radius float64, c color.Color) {
+	r.DrawCircle(x, y, radius, c)
+	for i := range 8 {
+		dx, dy := math.Cos(float64(i)*math.Pi/4), math.Sin(float64(i)*math.Pi/4)
+		r.DrawLine(x+dx*(radius+1.5), y+dy*(radius+1.5), x+dx*(radius+4), y+dy*(radius+4), c)
+	}
+}
+
+// drawIconCloud draws a cloud of three puffs on a flat base, centered on x
+// with its base at y+4
+func drawIconCloud(r render.Renderer, x, y float64, c color.Color) {
+	r.DrawCircle(x-7, y, 5, c)
+	r.DrawCircle(x+1, y-3, 7, c)
+	r.DrawCircle(x+8, y+1, 4, c)
+	r.DrawRect(x-7, y, 15, 5, c)
+}
+
+// updateIcon redraws the window icon when the glyph or the palette's sun
+// and cloud colors change
+func (g *Game) updateIcon() {
+	key := iconKey{glyphFor(g.World), g.Palette.Sun, g.Palette.Cloud}
+	if a := &g.announcer; !a.iconSet || key != a.icon {
+		ebiten.SetWindowIcon(weatherIcon(key))
+		a.icon, a.iconSet = key, true
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const inspectorWidth = 200
+
+// shapeNames name the tree shapes, indexed by the scene.Shape constants
+var shapeNames = [scene.NumShapes]string{
+	scene.ShapeTriangle:  "Triangle",
+	scene.ShapeOval:      "Oval",
+	scene.ShapeCircle:    "Circle",
+	scene.ShapeBranching: "Branching",
+}
+
+// Inspector lists the selected tree's or cloud's properties so they can be
+// fine-tuned beyond dragging
+type Inspector struct {
+	panel *Panel
+	tree  int // ID of the tree the panel was built for, 0 for none
+	cloud int // Index of the cloud it was built for, -1 for none
+}
+
+// inspectorField is one number the inspector edits. set is only called
+// with a value inside lo-hi that differs from the current one.
+type inspectorField struct {
+	name   string
+	format string // How the value is shown and offered for typing
+	step   float64
+	lo, hi float64
+	get    func() float64
+	set    func(v float64)
+}
+
+// stepper shows a field with - and + buttons that move it a step, and asks
+// for a typed value when the value is clicked
+func (g *Game) stepper(f inspectorField) widget {
+	set := func(v float64) {
+		if v = math.Max(f.lo, math.Min(f.hi, v)); v != f.get() {
+			f.set(v)
+		}
+	}
+	return &Stepper{
+		label: func() string { return f.name + ": " + fmt.Sprintf(f.format, f.get()) },
+		step:  func(dir int) { set(f.get() + float64(dir)*f.step) },
+		enter: func() {
+			g.startTextEdit(f.name, fmt.Sprintf(f.format, f.get()), 12, func(text string) {
+				v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+				if err != nil || math.IsNaN(v) {
+					g.setStatus(f.name + " must be a number")
+					return
+				}
+				set(v)
+			})
+		},
+		help: fmt.Sprintf("-/+ step %s by %g, click the value to type one from %g to %g", strings.ToLower(f.name), f.step, f.lo, f.hi),
+	}
+}
+
+// selectedCloud is the index of the cloud picked for the inspector, or -1
+// when none is or it is no longer shown
+func (g *Game) selectedCloud() int {
+	if i := g.menu.selectedCloud; i >= 0 && i < len(g.ActiveClouds()) {
+		return i
+	}
+	return -1
+}
+
+func (g *Game) cloudLabel(i int) string {
+	return fmt.Sprintf("%s cloud %d", g.CloudKind(g.Clouds[i]), i+1)
+}
+
+// inspectorPanel returns the panel for the selection, built afresh when it
+// changes, or nil when no tree or cloud is selected
+func (g *Game) inspectorPanel() *Panel {
+	in := &g.inspector
+	tree, cloud := 0, g.selectedCloud()
+	if g.FindTree(g.selectedID) != nil {
+		tree, cloud = g.selectedID, -1
+	}
+	if tree == 0 && cloud == -1 {
+		in.panel = nil
+		return nil
+	}
+	if in.panel == nil || tree != in.tree || cloud != in.cloud {
+		var widgets []widget
+		if tree != 0 {
+			widgets = g.treeFields(tree)
+		} else {
+			widgets = g.cloudFields(cloud)
+		}
+		in.panel = &Panel{widgets: widgets}
+		in.tree, in.cloud = tree, cloud
+	}
+	// Sits below the event log when that is showing
+	top := 30.0
+	if g.events.visible {
+		top += float64(eventLogVisible*16+30) + 10
+	}
+	in.panel.area = rect{float64(screenWidth) - inspectorWidth - 10, top, inspectorWidth - 10, 0}
+	return in.panel
+}
+
+// treeFields edit a tree's position, size, shade and shape. A locked tree
+// keeps its place, but can still be resized and recolored.
+func (g *Game) treeFields(id int) []widget {
+	get := func(field func(t *sim.Tree) float64) func() float64 {
+		return func() float64 {
+			if t := g.FindTree(id); t != nil {
+				return field(t)
+			}
+			return 0
+		}
+	}
+	set := func(name, format string, change func(t *sim.Tree, v float64)) func(float64) {
+		return func(v float64) {
+			g.editTree(id, fmt.Sprintf("%s set to "+format, strings.ToLower(name), v), func(t *sim.Tree) { change(t, v) })
+		}
+	}
+	move := func(name string, change func(t *sim.Tree, v float64)) func(float64) {
+		return func(v float64) {
+			if t := g.FindTree(id); t != nil && t.Locked {
+				g.setStatus(treeLabel(t) + " is locked (right-click to unlock)")
+				return
+			}
+			set(name, "%.0f", change)(v)
+		}
+	}
+	return []widget{
+		g.stepper(inspectorField{
+			name: "X", format: "%.0f", step: 5, lo: 0, hi: float64(g.Width),
+			get: get(func(t *sim.Tree) float64 { return t.X }),
+			set: move("X", func(t *sim.Tree, v float64) { t.X = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Y", format: "%.0f", step: 5, lo: g.GroundTop(), hi: float64(screenHeight),
+			get: get(func(t *sim.Tree) float64 { return t.Y }),
+			set: move("Y", func(t *sim.Tree, v float64) { t.Y = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Size", format: "%.0f", step: 2, lo: 10, hi: 150,
+			get: get(func(t *sim.Tree) float64 { return t.Size }),
+			set: set("Size", "%.0f", func(t *sim.Tree, v float64) { t.Size = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Shade", format: "%.2f", step: 0.05, lo: 0.3, hi: 1,
+			get: get(func(t *sim.Tree) float64 { return t.Shade }),
+			set: set("Shade", "%.2f", func(t *sim.Tree, v float64) { t.Shade = v }),
+		}),
+		&Stepper{
+			label: func() string {
+				return "Shape: " + shapeNames[int(get(func(t *sim.Tree) float64 { return float64(t.Shape) })())]
+			},
+			step:  func(dir int) { g.stepTreeShape(id, dir) },
+			enter: func() { g.stepTreeShape(id, 1) },
+			help:  "-/+ or click to change the shape, branching trees grow from their seed",
+		},
+	}
+}
+
+// editTree changes one tree as an undoable step
+func (g *Game) editTree(id int, what string, change func(t *sim.Tree)) {
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	g.editTrees(treeLabel(t)+" "+what, g.TreeCount, func(trees []sim.Tree) []sim.Tree {
+		for i := range trees {
+			if trees[i].ID == id {
+				change(&trees[i])
+			}
+		}
+		return trees
+	})
+}
+
+// stepTreeShape moves a tree on to the next or previous shape, growing its
+// branches when it becomes a branching tree
+func (g *Game) stepTreeShape(id int, dir int) {
+	t := g.FindTree(id)
+	if t == nil {
+		return
+	}
+	shape := cycle(t.Shape, dir, scene.NumShapes)
+	seed := t.Seed
+	if seed == 0 {
+		seed = int64(g.Rand.Int31())
+	}
+	g.editTree(id, "shape set to "+strings.ToLower(shapeNames[shape]), func(t *sim.Tree) {
+		t.Shape, t.Structure = shape, nil
+		if shape == scene.ShapeBranching {
+			t.Seed, t.Structure = seed, scene.GrowTree(seed)
+		}
+	})
+}
+
+// cloudFields edit a cloud's position, size, opacity and speed
+func (g *Game) cloudFields(i int) []widget {
+	get := func(field func(c *sim.Cloud) float64) func() float64 {
+		return func() float64 {
+			if i < len(g.Clouds) {
+				return field(&g.Clouds[i])
+			}
+			return 0
+		}
+	}
+	set := func(name, format string, change func(c *sim.Cloud, v float64)) func(float64) {
+		return func(v float64) {
+			if i >= len(g.Clouds) {
+				return
+			}
+			g.editClouds(fmt.Sprintf("%s %s set to "+format, g.cloudLabel(i), strings.ToLower(name), v), g.CloudCount, func(clouds []sim.Cloud) []sim.Cloud {
+				change(&clouds[i], v)
+				return clouds
+			})
+		}
+	}
+	wrap := g.Margins.CloudWrap
+	return []widget{
+		g.stepper(inspectorField{
+			name: "X", format: "%.0f", step: 5, lo: -wrap, hi: float64(g.Width) + wrap,
+			get: get(func(c *sim.Cloud) float64 { return c.X }),
+			set: set("X", "%.0f", func(c *sim.Cloud, v float64) { c.X = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Y", format: "%.0f", step: 5, lo: 0, hi: g.GroundTop(),
+			get: get(func(c *sim.Cloud) float64 { return c.Y }),
+			set: set("Y", "%.0f", func(c *sim.Cloud, v float64) { c.Y = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Size", format: "%.0f", step: 2, lo: 10, hi: 150,
+			get: get(func(c *sim.Cloud) float64 { return c.Size }),
+			set: set("Size", "%.0f", func(c *sim.Cloud, v float64) { c.Size = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Opacity", format: "%.2f", step: 0.05, lo: 0.05, hi: 1,
+			get: get(func(c *sim.Cloud) float64 { return c.Opacity }),
+			set: set("Opacity", "%.2f", func(c *sim.Cloud, v float64) { c.Opacity = v }),
+		}),
+		g.stepper(inspectorField{
+			name: "Speed", format: "%.1f", step: 0.1, lo: 0, hi: 5,
+			get: get(func(c *sim.Cloud) float64 { return c.Speed }),
+			set: set("Speed", "%.1f", func(c *sim.Cloud, v float64) { c.Speed = v }),
+		}),
+	}
+}
+
+// updateInspector passes the mouse to the inspector, reporting whether it
+// took it
+func (g *Game) updateInspector() bool {
+	p := g.inspectorPanel()
+	if p == nil {
+		return false
+	}
+	m := g.readMouse()
+	return p.update(m) || (m.pressed && g.inspectorArea(p).contains(m.x, m.y))
+}
+
+// inspectorArea is the inspector's backdrop, with its title above the rows
+func (g *Game) inspectorArea(p *Panel) rect {
+	a := p.area
+	return rect{a.x - 5, a.y - 20, a.w + 10, p.bottom() - a.y + 24}
+}
+
+func (g *Game) drawInspector(screen *ebiten.Image) {
+	p := g.inspectorPanel()
+	if p == nil {
+		return
+	}
+	title := "Cloud"
+	if t := g.FindTree(g.inspector.tree); t != nil {
+		title = treeLabel(t)
+	} else if i := g.inspector.cloud; i >= 0 && i < len(g.Clouds) {
+		title = g.cloudLabel(i)
+	}
+	a := g.inspectorArea(p)
+	drawRect(screen, a.x, a.y, a.w, a.h, color.RGBA{0, 0, 0, 180})
+	ebitenutil.DebugPrintAt(screen, title, int(a.x)+5, int(a.y)+3)
+	p.draw(screen, g.readMouse())
+}
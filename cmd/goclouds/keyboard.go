@@ -0,0 +1,280 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// sunID is the selectedID of the sun when it has keyboard focus. Entity IDs
+// start at 1, so it can't clash with a tree or prop.
+const sunID = -1
+
+const (
+	nudgeStep     = 1.0  // A pixel, for placing things more exactly than a drag can
+	nudgeStepFast = 10.0 // With Shift held
+)
+
+// keyRepeat reports a key press, repeating while the key is held
+func keyRepeat(key ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d > 20 && d%3 == 0)
+}
+
+// focusOrder lists what Tab moves through: the sun, then trees and props from
+// left to right
+func (g *Game) focusOrder() []int {
+	type stop struct {
+		id int
+		x  float64
+	}
+	var stops []stop
+	for _, t := range g.Trees {
+		stops = append(stops, stop{t.ID, t.X})
+	}
+	for _, p := range g.Props {
+		stops = append(stops, stop{p.ID, p.X})
+	}
+	slices.SortStableFunc(stops, func(a, b stop) int {
+		switch {
+		case a.x < b.x:
+			return -1
+		case a.x > b.x:
+			return 1
+		}
+		return 0
+	})
+
+	ids := []int{sunID}
+	for _, s := range stops {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+// cycleFocus moves keyboard focus to the next or previous entity
+func (g *Game) cycleFocus(dir int) {
+	ids := g.focusOrder()
+	i := slices.Index(ids, g.selectedID)
+	if i < 0 {
+		// Start from the first stop going forwards, the last going back
+		i = -1
+		if dir < 0 {
+			i = 0
+		}
+	}
+	g.selectedID = ids[(i+dir+len(ids))%len(ids)]
+	g.usingKeyboard = true
+	g.FinishTransition()
+}
+
+// focusPosition returns where the focused entity stands
+func (g *Game) focusPosition() (point, bool) {
+	if g.selectedID == sunID {
+		return point{g.SunX, g.SunY}, true
+	}
+	if t := g.FindTree(g.selectedID); t != nil {
+		return point{t.X, t.Y}, true
+	}
+	if p := g.FindProp(g.selectedID); p != nil {
+		return point{p.X, p.Y}, true
+	}
+	return point{}, false
+}
+
+// pointer is where keyboard and mouse actions such as placing a prop or a
+// note happen: the mouse cursor, or the focused entity while the keyboard is
+// being used to move around
+func (g *Game) pointer() point {
+	if g.usingKeyboard {
+		if pos, ok := g.focusPosition(); ok {
+			return pos
+		}
+	}
+	return g.cursor()
+}
+
+// trackPointer notices when the mouse moves, handing control back to it
+func (g *Game) trackPointer() {
+	x, y := g.pointerPosition()
+	cursor := point{float64(x), float64(y)}
+	if cursor != g.lastCursor {
+		g.usingKeyboard = false
+	}
+	g.lastCursor = cursor
+}
+
+// nudgeSelected moves the focused entity, or the cloud in the inspector,
+// with the arrow keys. Holding an arrow key keeps adding to the same
+// undoable move.
+func (g *Game) nudgeSelected() bool {
+	from, ok := g.focusPosition()
+	cloud := -1
+	if !ok {
+		if cloud = g.selectedCloud(); cloud == -1 {
+			return false
+		}
+	}
+	step := nudgeStep
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		step = nudgeStepFast
+	}
+	var dx, dy float64
+	if keyRepeat(ebiten.KeyLeft) {
+		dx -= step
+	}
+	if keyRepeat(ebiten.KeyRight) {
+		dx += step
+	}
+	if keyRepeat(ebiten.KeyUp) {
+		dy -= step
+	}
+	if keyRepeat(ebiten.KeyDown) {
+		dy += step
+	}
+	if dx == 0 && dy == 0 {
+		return true
+	}
+	if t := g.FindTree(g.selectedID); (g.selectedID == sunID && g.SunLocked) || (t != nil && t.Locked) || (cloud != -1 && g.Clouds[cloud].Locked) {
+		g.setStatus("Locked in place (right-click to unlock)")
+		return true
+	}
+	g.usingKeyboard = true
+	g.FinishTransition()
+	if cloud != -1 {
+		g.nudgeCloud(cloud, dx, dy)
+		return true
+	}
+
+	to := point{from.x + dx, from.y + dy}
+	groundY := float64(screenHeight - scene.GroundHeight + scene.GroundOffset)
+	var cmd Command
+	switch g.selectedID {
+	case sunID:
+		to.x = math.Max(sim.SunRadius, math.Min(float64(g.Width)-sim.SunRadius, to.x))
+		to.y = math.Max(sim.SunRadius, math.Min(float64(screenHeight)-scene.GroundHeight-10, to.y))
+		cmd = moveSunCmd{from, to}
+	default:
+		to.x = math.Max(0, math.Min(float64(g.Width), to.x))
+		to.y = math.Max(groundY, math.Min(float64(screenHeight), to.y))
+		if g.FindTree(g.selectedID) != nil {
+			cmd = moveTreeCmd{g.selectedID, from, to}
+		} else {
+			cmd = movePropCmd{g.selectedID, from, to}
+		}
+	}
+	if to == from {
+		return true
+	}
+	cmd.Do(g)
+
+	// Fold into the previous nudge of the same entity while keys are held
+	if h := &g.history; g.nudging && len(h.undo) > 0 {
+		switch last := h.undo[len(h.undo)-1].(type) {
+		case moveSunCmd:
+			if g.selectedID == sunID {
+				h.undo[len(h.undo)-1] = moveSunCmd{last.from, to}
+				return true
+			}
+		case moveTreeCmd:
+			if last.id == g.selectedID {
+				h.undo[len(h.undo)-1] = moveTreeCmd{last.id, last.from, to}
+				return true
+			}
+		case movePropCmd:
+			if last.id == g.selectedID {
+				h.undo[len(h.undo)-1] = movePropCmd{last.id, last.from, to}
+				return true
+			}
+		}
+	}
+	g.record(cmd)
+	g.nudging = true
+	return true
+}
+
+// nudgeCloud moves a cloud by the arrow keys, keeping it in the sky. Clouds
+// drift, so the move is recorded as how far it went rather than where to.
+func (g *Game) nudgeCloud(i int, dx, dy float64) {
+	c := &g.Clouds[i]
+	dy = math.Max(0, math.Min(g.GroundTop(), c.Y+dy)) - c.Y
+	if dx == 0 && dy == 0 {
+		return
+	}
+	cmd := moveCloudCmd{i, dx, dy}
+	cmd.Do(g)
+	if h := &g.history; g.nudging && len(h.undo) > 0 {
+		if last, ok := h.undo[len(h.undo)-1].(moveCloudCmd); ok && last.index == i {
+			h.undo[len(h.undo)-1] = moveCloudCmd{i, last.dx + dx, last.dy + dy}
+			return
+		}
+	}
+	g.record(cmd)
+	g.nudging = true
+}
+
+// updateKeyboardFocus handles Tab focus cycling and arrow-key nudging. It
+// reports whether the arrow keys were taken for nudging.
+func (g *Game) updateKeyboardFocus() bool {
+	g.trackPointer()
+	// While the menu is open Tab turns its pages instead
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) && !ebiten.IsKeyPressed(ebiten.KeyControl) && !g.menu.visible {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.cycleFocus(-1)
+		} else {
+			g.cycleFocus(1)
+		}
+	}
+	arrows := []ebiten.Key{ebiten.KeyLeft, ebiten.KeyRight, ebiten.KeyUp, ebiten.KeyDown}
+	if !slices.ContainsFunc(arrows, ebiten.IsKeyPressed) {
+		g.nudging = false
+	}
+	if g.menu.visible {
+		return false
+	}
+	return g.nudgeSelected()
+}
+
+// drawSunDrag highlights the sun while it is being dragged, tracing its arc
+// and showing the time of day while it keeps to it
+func (g *Game) drawSunDrag(screen *ebiten.Image) {
+	if !g.isDraggingSun {
+		return
+	}
+	drawCircle(
+		screen,
+		g.SunX,
+		g.SunY,
+		sim.SunRadius+2,
+		color.RGBA{255, 255, 255, 100},
+	)
+	if g.snappingSun() {
+		g.drawSunArc(screen)
+	}
+}
+
+// drawSunFocus rings the sun while it has keyboard focus
+func (g *Game) drawSunFocus(screen *ebiten.Image) {
+	if g.selectedID != sunID {
+		return
+	}
+	ring := color.RGBA{255, 255, 255, 220}
+	steps := 32
+	r := float64(sim.SunRadius + 6)
+	for i := 0; i < steps; i += 2 {
+		a0 := float64(i) / float64(steps) * 2 * math.Pi
+		a1 := float64(i+1) / float64(steps) * 2 * math.Pi
+		drawLine(screen,
+			g.SunX+math.Cos(a0)*r, g.SunY+math.Sin(a0)*r,
+			g.SunX+math.Cos(a1)*r, g.SunY+math.Sin(a1)*r,
+			ring)
+	}
+	ebitenutil.DebugPrintAt(screen, "Sun (arrows: move)", int(g.SunX)-54, int(g.SunY-r)-18)
+}
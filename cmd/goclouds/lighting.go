@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cloudapp/pkg/render"
+)
+
+// lightingFile is where the lighting palette is exported, as a GIMP
+// palette, an Adobe Swatch Exchange file and JSON
+const lightingFile = "lighting"
+
+// exportLighting writes the scene's colors as they are lit right now in
+// each palette format, so a golden hour or a stormy dusk can be reused in
+// other tools
+func (g *Game) exportLighting() {
+	colors := render.LightingColors(g.World)
+	name := fmt.Sprintf("GoClouds %s %s", g.PaletteName, time.Now().Format("2006-01-02 15:04"))
+	var gpl, ase, js bytes.Buffer
+	err := errors.Join(
+		render.WriteGPL(&gpl, name, colors),
+		render.WriteASE(&ase, colors),
+		render.WriteColorsJSON(&js, name, colors),
+	)
+	for ext, data := range map[string][]byte{".gpl": gpl.Bytes(), ".ase": ase.Bytes(), ".json": js.Bytes()} {
+		if err == nil {
+			err = os.WriteFile(lightingFile+ext, data, 0o644)
+		}
+	}
+	if err != nil {
+		g.setStatus("Export failed: " + err.Error())
+		return
+	}
+	g.setStatus(fmt.Sprintf("%d lighting colors exported to %s.gpl, .ase and .json", len(colors), lightingFile))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	lowEndTPS    = 30   // Update and frame rate on low-end hardware
+	lowEndProbe  = 10.0 // Seconds of full-speed running before the frame rate is judged
+	lowEndMinFPS = 40   // Below this the GPU is taken to be too slow for the full scene
+)
+
+// LowEnd is the minimal mode for Pi-class kiosk hardware: half the frame
+// rate and the painter's low-end path. It is turned on by -lowend or the
+// config, on hardware that looks like a single board computer, or when the
+// first seconds of running can't keep up.
+type LowEnd struct {
+	active bool
+	probed float64 // Seconds of foreground running measured so far
+}
+
+// lowEndHardware reports whether the machine looks like a Raspberry Pi or a
+// similar board, which has too little GPU for the full scene
+func lowEndHardware() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if runtime.GOARCH == "arm" {
+		return true
+	}
+	model, err := os.ReadFile("/proc/device-tree/model")
+	return err == nil && strings.Contains(string(model), "Raspberry Pi")
+}
+
+// startLowEnd switches to the low-end mode for the rest of the run
+func (g *Game) startLowEnd(reason string) {
+	g.lowEnd.active = true
+	g.renderer.LowEnd = true
+	if !g.throttle.active {
+		ebiten.SetTPS(lowEndTPS)
+	}
+	g.logEvent("low-end mode: %s", reason)
+	slog.Info("low-end mode", "reason", reason)
+}
+
+// foregroundTPS is the update rate while the window is in front
+func (g *Game) foregroundTPS() int {
+	if g.lowEnd.active {
+		return lowEndTPS
+	}
+	return ebiten.DefaultTPS
+}
+
+// updateLowEnd watches the frame rate over the first seconds in front and
+// drops to the low-end mode if the GPU can't keep up
+func (g *Game) updateLowEnd(dt float64) {
+	if g.lowEnd.active || g.throttle.active || g.lowEnd.probed >= lowEndProbe {
+		return
+	}
+	g.lowEnd.probed += dt
+	if fps := ebiten.ActualFPS(); g.lowEnd.probed >= lowEndProbe && fps < lowEndMinFPS {
+		g.startLowEnd(fmt.Sprintf("only %.0f FPS", fps))
+	}
+}
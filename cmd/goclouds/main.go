@@ -0,0 +1,811 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/render/ebitenrender"
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	numTrees = 5
+)
+
+// Window size, set from the config file at startup
+var (
+	screenWidth  = 800
+	screenHeight = 600
+)
+
+// rng drives all scene randomness so a fixed seed reproduces a scene
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+type Menu struct {
+	visible       bool
+	selectedCloud int    // Index of the cloud in the inspector, -1 for none
+	placeKind     int    // Index into placeableProps for the P key
+	template      int    // Index into templates
+	seed          int64  // Seed for the next generated template
+	pages         *Pages // Widgets, built by menuPages
+	dragFrom      int    // Length of the history when a slider drag began
+	rebinding     string // Action waiting for a key on the Controls page
+}
+
+type Game struct {
+	*sim.World
+
+	isDraggingSun          bool
+	sunSnap                bool // Dragging the sun keeps it on its arc, setting the time of day
+	dragStartX, dragStartY float64
+	menu                   Menu
+	context                ContextMenu
+	tooltip                Tooltip
+	inspector              Inspector
+	hud                    HUD
+	mapMode                MapMode
+	gamepad                Gamepad
+	banner                 BannerView
+	capture                Capture
+	shot                   Screenshot
+	recorder               Recorder
+	rumble                 Rumble
+	touch                  Touch
+	sync                   Sync
+	picker                 ColorPicker
+	draggedTree            int // -1 when no tree is being dragged
+	dragTreeStartX         float64
+	duplicating            bool // The dragged tree is a copy made by Alt-dragging, added to the scene when let go
+	draggedProp            int  // -1 when no prop is being dragged
+	dragPropStartX         float64
+	status                 string  // Short message shown at the bottom of the screen
+	statusUntil            float64 // simTime at which the status message disappears
+	scenePath              string  // File used by Ctrl+S / Ctrl+O
+	events                 EventLog
+	wishes                 int // Shooting stars clicked this session
+	nextAutosave           float64
+	start                  StartScreen // Offering to resume a recent scene or the autosave
+	selectedID             int         // Selected tree or prop, 0 when nothing is selected
+	selection              Selection   // Trees and clouds picked with a band, moved and deleted together
+	textEdit               TextEdit
+	notes                  []Note
+	notesVisible           bool
+	ruler                  Ruler
+	history                History
+	dragFrom               point // Where the dragged sun, tree or prop started
+	teaching               bool  // Shadow lesson overlay is shown
+	lesson                 CloudLesson
+	tour                   Tour
+	usingKeyboard          bool  // Focus was last moved with the keyboard rather than the mouse
+	lastCursor             point // Mouse position last frame
+	nudging                bool  // Arrow keys are held moving the focused entity
+	config                 ConfigWatch
+	palettes               PaletteWatch
+	announcer              Announcer
+	camera                 Camera
+	lastSearch             string // Offered again by Ctrl+F so Enter finds the next match
+	rewind                 sim.Rewind
+	soak                   Soak
+	throttle               Throttle
+	lowEnd                 LowEnd
+	tabs                   Tabs
+	wizard                 Wizard
+	diagnostics            Diagnostics
+	clock                  sim.Clock
+	advanced               time.Time // When the clock was last advanced, which frames drawn since blend on from
+	kiosk                  bool      // No controls, overlays or autosave, for exported players and benchmarks
+	bench                  *Bench    // Timing frames for -bench, nil otherwise
+	keys                   Bindings
+	renderer               *render.Painter
+	frames                 FrameTimes
+}
+
+func NewGame(cfg Config) *Game {
+	g := &Game{
+		scenePath:   sceneFile,
+		draggedTree: -1,
+		draggedProp: -1,
+		menu: Menu{
+			visible:       false,
+			selectedCloud: -1,
+			seed:          1,
+		},
+		nextAutosave: autosaveInterval,
+		notesVisible: true,
+		soak:         Soak{started: time.Now()},
+		renderer:     render.New(),
+		camera:       Camera{lastClick: math.Inf(-1)},
+		keys:         cfg.Keys,
+	}
+	g.World = g.newWorld(cfg, rng)
+	g.tabs.open = []Tab{{}}
+	g.resetCamera()
+
+	g.logEvent("session started")
+	if cfg.Soak {
+		g.startSoak()
+	}
+	if cfg.LowEnd {
+		g.startLowEnd("requested")
+	} else if lowEndHardware() {
+		g.startLowEnd("single board computer")
+	}
+	return g
+}
+
+func (g *Game) Update() error {
+	defer g.frames.add(subUpdate, time.Now())
+
+	// Closing the window saves on the way out like ESC does
+	if ebiten.IsWindowBeingClosed() {
+		g.saveWindow()
+		if !g.kiosk && !g.start.visible {
+			g.autosave()
+		}
+		return ebiten.Termination
+	}
+	g.updateTouch()
+	if g.updateRebinding() {
+		return nil
+	}
+
+	// Check for escape key to close window, snapshotting the scene first so
+	// an accidental ESC can be undone on the next launch
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		if g.kiosk {
+			return ebiten.Termination
+		} else if g.textEdit.active {
+			g.textEdit.active = false // Escape cancels typing instead of quitting
+		} else if g.wizard.visible {
+			g.wizard.visible = false
+		} else if g.tour.active {
+			g.stopTour()
+		} else if g.context.panel != nil {
+			g.context.panel = nil
+		} else if g.hud.hidden {
+			g.setHUDHidden(false, false)
+		} else if g.menu.visible {
+			g.menu.visible = false // Then the menu closes, then focus is dropped
+		} else if g.selectedID != 0 || g.selectedCloud() != -1 {
+			g.selectedID = 0
+			g.menu.selectedCloud = -1
+		} else {
+			if !g.start.visible {
+				g.autosave()
+			}
+			g.saveWindow()
+			return ebiten.Termination
+		}
+	}
+
+	g.updateThrottle()
+	if g.bench != nil {
+		g.World.Wetness = 1 // The storm keeps the ground wet
+		if g.bench.done() {
+			g.bench.report(os.Stdout, "windowed")
+			return ebiten.Termination
+		}
+	}
+
+	// Wait for an answer before anything can overwrite the autosave
+	if g.updateStartScreen() {
+		return nil
+	}
+	g.updateAutosave()
+
+	dt := 1.0 / float64(ebiten.TPS())
+	g.ExactClouds = g.menu.visible
+	g.safely("simulation", func() {
+		defer g.frames.add(subSimulation, time.Now())
+		g.clock.Advance(g.World, g.syncElapsed(dt))
+	}, g.recoverSimulation)
+	g.advanced = time.Now()
+	g.rewind.Record(g.World)
+	g.updateSync()
+	g.updateSoak()
+	g.updateLowEnd(dt)
+	g.updateDiagnostics()
+	g.updateConfigWatch(dt)
+	g.updateCalibration(dt)
+	g.updateBanner(dt)
+	g.updateRecording()
+	g.updatePaletteWatch(dt)
+	g.updateAnnouncer()
+	g.updateCamera(dt)
+	if g.kiosk {
+		return nil
+	}
+
+	// While typing, keys go to the text instead of the shortcuts, and the
+	// new scene wizard takes them while it is open
+	if g.textEdit.active {
+		g.updateTextEdit()
+		return nil
+	}
+	if g.updateWizard() {
+		return nil
+	}
+
+	// Rename the selected tree or prop with N, Ctrl+N starts a new scene
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl)
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		if ctrl {
+			g.openWizard()
+		} else {
+			g.renameSelected()
+		}
+	}
+
+	// Toggle the menu, with M unless rebound
+	if g.pressed("menu") {
+		g.menu.visible = !g.menu.visible
+	}
+	// Hide everything over the scene with F1, Shift+F1 hides the pointer too
+	g.updateHUD(dt)
+	g.updateGamepad(dt)
+	g.updateRumble()
+
+	// Save and load the scene with Ctrl+S / Ctrl+O
+	tabClicked := g.updateTabs(ctrl)
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		if err := g.saveScene(g.scenePath); err != nil {
+			g.setStatus("Save failed: " + err.Error())
+		} else {
+			rememberScene(g.scenePath)
+			g.setStatus("Scene saved to " + g.scenePath)
+			g.logEvent("scene saved to %s", g.scenePath)
+		}
+	}
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		if err := g.openScene(g.scenePath); err != nil {
+			g.setStatus("Load failed: " + err.Error())
+		} else {
+			g.setStatus("Scene loaded from " + g.scenePath)
+			g.logEvent("scene loaded from %s", g.scenePath)
+		}
+	}
+
+	// Focus the camera on the selection with F, Ctrl+F finds an entity
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		if ctrl {
+			g.startSearch()
+		} else {
+			g.focusSelection()
+		}
+	}
+
+	// Share the scene as a compact string with Ctrl+C / Ctrl+V
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		g.copyShare()
+	}
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyV) {
+		g.pasteShare()
+	}
+
+	g.updateSlots()
+
+	// Show uptime, frame rate and memory use with U, frame figures, cache
+	// use and the frame time histogram with F3 (Shift+F3 exports the frame
+	// times), the recent frame times as a graph with F2, and the lighting
+	// model with F4
+	if g.pressed("uptime") {
+		g.soak.visible = !g.soak.visible
+	}
+	if g.pressed("diagnostics") {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.exportFrames()
+		} else {
+			g.diagnostics.visible = !g.diagnostics.visible
+		}
+	}
+	// Show the ground from above in a corner or beside the scene with A
+	if g.pressed("map") {
+		g.stepMapMode(1)
+	}
+	if g.pressed("frameGraph") {
+		g.diagnostics.graph = !g.diagnostics.graph
+	}
+	if g.pressed("probes") {
+		g.diagnostics.probes = !g.diagnostics.probes
+	}
+
+	// Remove the selection with Delete
+	if g.pressed("delete") {
+		g.deleteSelected()
+	}
+
+	// Save a screenshot with F12, Shift+F12 saves the scene without the HUD
+	if g.pressed("screenshot") {
+		g.screenshot(ebiten.IsKeyPressed(ebiten.KeyShift))
+	}
+
+	// Start or stop recording a GIF with F9
+	if g.pressed("record") {
+		g.toggleRecording()
+	}
+
+	// Take the simulation itself back a few seconds with B
+	if g.pressed("rewind") {
+		g.rewindSimulation()
+	}
+
+	// Undo with Ctrl+Z, redo with Ctrl+Y or Ctrl+Shift+Z
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.redo()
+		} else {
+			g.undo()
+		}
+	}
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyY) {
+		g.redo()
+	}
+
+	// Toggle the event log with L, Shift+L exports it as text
+	if g.pressed("eventLog") {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if err := g.events.export(eventLogFile); err != nil {
+				g.setStatus("Export failed: " + err.Error())
+			} else {
+				g.setStatus("Event log exported to " + eventLogFile)
+			}
+		} else {
+			g.events.visible = !g.events.visible
+		}
+	}
+
+	// Tab moves keyboard focus between the sun, trees and props, and the
+	// arrow keys nudge whatever has focus
+	nudged := g.updateKeyboardFocus()
+
+	// Handle menu controls when visible
+	menuClicked := false
+	if g.menu.visible {
+		// Widgets take clicks and drags, Up/Down pick a row, Left/Right
+		// change it and Enter activates it
+		menuClicked = g.updateMenu()
+
+		// New: Adjust tree shadow value with S (decrease) and D (increase)
+		if g.pressed("shadowDown") {
+			g.setTreeShadow(math.Max(0.2, g.TreeShadow-0.1))
+		}
+		if g.pressed("shadowUp") {
+			g.setTreeShadow(math.Min(2.0, g.TreeShadow+0.1))
+		}
+
+		// Pick a scene template with G, Shift+G edits its seed
+		if g.pressed("template") {
+			if ebiten.IsKeyPressed(ebiten.KeyShift) {
+				g.editSeed()
+			} else {
+				g.menu.template = (g.menu.template + 1) % len(scene.Templates)
+			}
+		}
+	} else if !nudged {
+		// Original density controls when menu is hidden
+		if g.pressed("densityUp") {
+			g.setDensity(math.Min(1.0, g.Density+0.1))
+		}
+		if g.pressed("densityDown") {
+			g.setDensity(math.Max(0.0, g.Density-0.1))
+		}
+	}
+
+	cursor := g.cursor()
+	cursorX, cursorY := cursor.x, cursor.y
+
+	// Pin or edit a sticky note at the pointer with T, Shift+T hides them all
+	if g.pressed("note") {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.notesVisible = !g.notesVisible
+		} else {
+			pos := g.pointer()
+			g.editNoteAt(pos.x, pos.y)
+		}
+	}
+
+	g.updateTour(dt)
+	g.updateTooltip(dt)
+
+	// Toggle the shadow lesson overlay with E, Ctrl+E exports the scene as
+	// a player that opens straight into it
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		if ctrl {
+			g.exportScenePlayer()
+		} else {
+			g.teaching = !g.teaching
+		}
+	}
+
+	if !ctrl {
+		g.updateCloudLesson()
+	}
+
+	// Toggle the ruler with R, which takes over LMB while it is active
+	if g.pressed("ruler") {
+		g.ruler.active = !g.ruler.active
+		g.ruler.measured = false
+	}
+	if g.ruler.active {
+		g.updateRuler(cursorX, cursorY)
+	}
+
+	// Place a prop at the pointer with P, Shift+P cycles which prop is placed.
+	// From the keyboard it goes beside the focused entity, or mid-ground, and
+	// takes focus so it can be nudged into place.
+	if g.pressed("prop") {
+		groundY := float64(screenHeight - scene.GroundHeight + scene.GroundOffset)
+		pos := g.pointer()
+		if g.usingKeyboard {
+			pos.x += 40
+			if pos.y < groundY {
+				pos = point{g.camera.x, g.GroundRow(0.5)}
+			}
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.menu.placeKind = (g.menu.placeKind + 1) % len(placeableProps)
+		} else if pos.y >= groundY {
+			prop := g.NewProp(placeableProps[g.menu.placeKind], pos.x, pos.y)
+			g.exec(addPropCmd{prop})
+			g.logEvent("%s placed at %.0f,%.0f", propLabel(&prop), pos.x, pos.y)
+			if g.usingKeyboard {
+				g.selectedID = prop.ID
+			}
+		}
+	}
+
+	g.updateWheelZoom()
+	g.updatePan(!g.menu.visible && !nudged, dt)
+
+	// Handle mouse input
+	// Double-clicking focuses the camera or plants a tree or cloud on an
+	// empty spot, clicking a shooting star makes a wish instead of starting a
+	// drag and during the cloud quiz clicking a cloud answers the question
+	contextClicked := !g.ruler.active && !g.hud.hidden && g.updateContextMenu()
+	inspectorClicked := !g.ruler.active && !g.hud.hidden && !contextClicked && g.updateInspector()
+	mapClicked := !contextClicked && !inspectorClicked && g.updateMap()
+	if !g.ruler.active && !tabClicked && !menuClicked && !contextClicked && !inspectorClicked && !mapClicked && g.pointerPressed() &&
+		!g.doubleClick(cursorX, cursorY) && !g.wishOn(cursorX, cursorY) && !g.answerQuiz(cursorX, cursorY) &&
+		!g.grabSelection(cursorX, cursorY) {
+		g.FinishTransition()
+
+		// Check for sun dragging first
+		dx := cursorX - g.SunX
+		dy := cursorY - g.SunY
+		g.selectedID = 0
+		g.menu.selectedCloud = -1
+		if !ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.selection.clear()
+		}
+		if dx*dx+dy*dy <= sim.SunRadius*sim.SunRadius && g.SunLocked {
+			g.setStatus("The sun is locked (right-click to unlock)")
+		} else if dx*dx+dy*dy <= sim.SunRadius*sim.SunRadius {
+			g.Stop() // Taking hold of the sun ends any animation
+			g.isDraggingSun = true
+			g.dragStartX = cursorX - g.SunX
+			g.dragStartY = cursorY - g.SunY
+			g.dragFrom = point{g.SunX, g.SunY}
+		} else {
+			// Check for tree dragging, then prop dragging if no tree was grabbed.
+			// With Alt held the tree stays and a copy of it is dragged instead.
+			if i := g.treeAt(cursorX, cursorY); i != -1 && ebiten.IsKeyPressed(ebiten.KeyAlt) {
+				g.dragDuplicate(i, cursorX)
+			} else if i != -1 && g.Trees[i].Locked {
+				g.selectedID = g.Trees[i].ID
+				g.setStatus(treeLabel(&g.Trees[i]) + " is locked (right-click to unlock)")
+			} else if i != -1 {
+				tree := g.Trees[i]
+				g.draggedTree = i
+				g.dragTreeStartX = cursorX - tree.X
+				g.dragFrom = point{tree.X, tree.Y}
+				g.selectedID = tree.ID
+			} else if i := g.propAt(cursorX, cursorY); i != -1 {
+				g.draggedProp = i
+				g.dragPropStartX = cursorX - g.Props[i].X
+				g.dragFrom = point{g.Props[i].X, g.Props[i].Y}
+				g.selectedID = g.Props[i].ID
+			} else if i := g.cloudIndexAt(cursorX, cursorY); i != -1 {
+				g.menu.selectedCloud = i // Opens the inspector
+			} else {
+				// Dragging across an empty spot selects everything in the band
+				g.startBand(cursorX, cursorY)
+			}
+		}
+	}
+
+	if g.pointerDown() {
+		if g.isDraggingSun && g.snappingSun() {
+			g.snapSun(cursorX - g.dragStartX)
+		} else if g.isDraggingSun {
+			// Update sun position while dragging
+			g.SunX = cursorX - g.dragStartX
+			g.SunY = cursorY - g.dragStartY
+
+			// Keep sun within screen bounds
+			g.SunX = math.Max(sim.SunRadius, math.Min(float64(g.Width)-sim.SunRadius, g.SunX))
+			g.SunY = math.Max(sim.SunRadius, math.Min(float64(screenHeight)-scene.GroundHeight-10, g.SunY))
+		} else if g.draggedTree != -1 {
+			// Update tree position while dragging
+			newX := cursorX - g.dragTreeStartX
+			newY := cursorY
+			groundY := float64(screenHeight - scene.GroundHeight + scene.GroundOffset)
+
+			// Allow free movement but keep tree below ground line
+			if newY >= groundY {
+				g.Trees[g.draggedTree].X = newX
+				g.Trees[g.draggedTree].Y = newY
+			}
+		} else if g.draggedProp != -1 {
+			// Props follow the same ground constraint as trees
+			newY := cursorY
+			if newY >= float64(screenHeight-scene.GroundHeight+scene.GroundOffset) {
+				g.Props[g.draggedProp].X = cursorX - g.dragPropStartX
+				g.Props[g.draggedProp].Y = newY
+			}
+		} else if g.selection.moving {
+			g.moveSelection(cursorX, cursorY)
+		} else if g.selection.banding {
+			g.selection.to = point{cursorX, cursorY}
+		}
+	} else {
+		g.endDrag()
+		g.isDraggingSun = false
+		g.draggedTree = -1
+		g.draggedProp = -1
+		g.duplicating = false
+		g.selection.banding, g.selection.moving = false, false
+	}
+
+	return nil
+}
+
+// setStatus shows a message at the bottom of the screen for a few seconds
+func (g *Game) setStatus(msg string) {
+	g.status = msg
+	g.statusUntil = g.SimTime + 4
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	if g.bench != nil {
+		g.bench.frame()
+	}
+	// The screen isn't cleared between frames, so in the background frames
+	// without an update can be skipped
+	if g.skipDraw() {
+		return
+	}
+	g.frames.frame(g.throttle.active, g.renderer.Stats.ShadowRebuilds())
+	defer g.frames.add(subOverlays, time.Now())
+	defer g.takeScreenshot(screen, &g.shot.screen) // Once everything is drawn
+
+	// The scene and everything pinned to it is drawn through the camera
+	view := g.viewImage()
+	g.safely("drawing", func() {
+		defer g.frames.add(subScene, time.Now())
+		g.renderer.ImageBudget = int64(g.config.current.ImageBudgetMB) << 20
+		g.clock.Since(time.Since(g.advanced).Seconds())
+		g.clock.Blend(g.World, func() {
+			g.renderer.Draw(ebitenrender.Screen{Image: view}, g.World)
+		})
+	}, func() {
+		g.renderer = render.New()
+		g.renderer.Economy = g.throttle.active
+		g.renderer.LowEnd = g.lowEnd.active
+	})
+	g.takeCapture(view)
+	g.takeScreenshot(view, &g.shot.scene)
+	g.recordFrame(view)
+	if !g.hud.hidden {
+		g.drawLightProbes(view)
+		g.drawSelection(view)
+		g.drawGroupSelection(view)
+		g.drawSunFocus(view)
+		g.drawSunDrag(view)
+		g.drawCloudLesson(view)
+		g.drawNotes(view)
+		g.drawRuler(view)
+		g.drawShadowLesson(view)
+	}
+	g.clearBeside(screen)
+	g.drawView(screen)
+	g.drawMap(screen)
+	g.drawBanner(screen)
+	if g.kiosk {
+		return
+	}
+	if g.hud.hidden {
+		// Only what is waiting for an answer shows over the clean scene
+		g.drawStartScreen(screen)
+		g.drawWizard(screen)
+		g.drawTextEdit(screen)
+		return
+	}
+
+	if g.menu.visible {
+		g.drawMenu(screen)
+	} else {
+		// Hovering explains the rest
+		ebitenutil.DebugPrint(screen, "Press "+g.keyName("menu")+" for the menu, H for a guided tour")
+	}
+
+	g.drawInspector(screen)
+	g.drawContextMenu(screen)
+	g.drawTabs(screen)
+	g.drawTooltip(screen)
+	g.drawEventLog(screen)
+	g.drawHealth(screen)
+	g.drawDiagnostics(screen)
+	g.drawFrames(screen)
+	g.drawFrameGraph(screen)
+	g.drawRecording(screen)
+	g.drawStartScreen(screen)
+	g.drawWizard(screen)
+	g.drawTour(screen)
+	g.drawTextEdit(screen)
+
+	if g.status != "" && g.SimTime < g.statusUntil {
+		ebitenutil.DebugPrintAt(screen, g.status, 10, screenHeight-20)
+	}
+
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return screenWidth, screenHeight
+}
+
+func main() {
+	// goclouds render and goclouds timelapse draw a scene without opening a
+	// window
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "render":
+			os.Exit(renderCommand(os.Args[2:]))
+		case "timelapse":
+			os.Exit(timelapseCommand(os.Args[2:]))
+		}
+	}
+
+	// Command-line flags override the config file so runs can be scripted
+	width := flag.Int("width", 0, "window width in pixels")
+	height := flag.Int("height", 0, "window height in pixels")
+	seed := flag.Int64("seed", 0, "random seed for a reproducible scene")
+	scenePath := flag.String("scene", "", "scene file to load at startup and save to")
+	fullscreen := flag.Bool("fullscreen", false, "start in fullscreen mode")
+	animationPath := flag.String("animation", "", "animation file to play, such as a looping demo")
+	soak := flag.Bool("soak", false, "run unattended around the clock, recovering from errors")
+	lowEnd := flag.Bool("lowend", false, "draw a minimal scene at 30 FPS, for Raspberry Pi and other weak GPUs")
+	debug := flag.Bool("debug", false, "log frame rates, draw calls and cache use every 10 seconds")
+	last := flag.Bool("last", false, "resume the most recently opened scene, or the last session")
+	bench := flag.Int("bench", 0, "run a heavy scene for this many seconds, then print frame times and allocations")
+	headless := flag.Bool("headless", false, "with -bench, draw in software without opening a window")
+	syncGroup := flag.String("sync", "", "keep in lockstep with other displays on the network in this sync group")
+	flag.Parse()
+	setupLogging(*debug)
+
+	// A copy made with Ctrl+E carries its own scene and plays it full screen
+	player, isPlayer, err := loadPlayer()
+	if err != nil {
+		slog.Warn("loading exported scene", "err", err)
+		isPlayer = false
+	}
+
+	// Palettes first, as the config picks one of them by name
+	if err := loadPalettes(paletteFile); err != nil {
+		slog.Warn("loading palettes", "path", paletteFile, "err", err)
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		slog.Warn("loading config", "path", configFile, "err", err)
+	}
+	if *width > 0 {
+		cfg.Width = *width
+	}
+	if *height > 0 {
+		cfg.Height = *height
+	}
+	if *seed != 0 {
+		cfg.Seed = *seed
+	}
+	if *soak {
+		cfg.Soak = true
+	}
+	if *lowEnd {
+		cfg.LowEnd = true
+	}
+	if *syncGroup != "" {
+		cfg.Sync = *syncGroup
+	}
+	if *bench > 0 {
+		cfg = benchConfig(cfg)
+	} else if *headless {
+		slog.Warn("-headless only applies to -bench")
+	}
+	if err := cfg.validate(); err != nil {
+		slog.Warn("invalid options", "err", err)
+	}
+
+	screenWidth, screenHeight = cfg.Width, cfg.Height
+	if isPlayer {
+		screenWidth, screenHeight = player.Width, player.Height
+		if player.Margins != (scene.Margins{}) {
+			cfg.Margins = player.Margins
+		}
+		*fullscreen = true
+	}
+	if cfg.Seed != 0 {
+		rng.Seed(cfg.Seed)
+	}
+	if *bench > 0 && *headless {
+		runHeadlessBench(cfg, time.Duration(*bench)*time.Second, os.Stdout)
+		return
+	}
+
+	ebiten.SetWindowSize(screenWidth, screenHeight)
+	ebiten.SetWindowTitle(windowTitle)
+	ebiten.SetFullscreen(*fullscreen)
+	ebiten.SetScreenClearedEveryFrame(false)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowClosingHandled(true)
+
+	// Open the window as the last session left it, unless playing or
+	// benchmarking, with a size asked for on the command line winning
+	window, restore := loadWindowState()
+	restore = restore && !isPlayer && *bench == 0
+	if restore {
+		restoreWindow(window, *width > 0 || *height > 0)
+	}
+
+	game := NewGame(cfg)
+	if restore && window.Settings != nil {
+		game.restoreSettings(*window.Settings)
+	}
+	game.watchConfig(configFile, cfg)
+	game.watchPalettes(paletteFile)
+	switch {
+	case *bench > 0:
+		game.startBench(time.Duration(*bench) * time.Second)
+	case isPlayer:
+		game.startPlayer(player)
+	case cfg.Sync != "":
+		game.startSync(cfg)
+	case *scenePath != "":
+		game.scenePath = *scenePath
+		// Start with a fresh scene rather than refusing to run, so a file
+		// from a newer version doesn't lock the user out
+		if err := game.loadScene(*scenePath); err != nil {
+			slog.Warn("loading scene", "path", *scenePath, "err", err)
+			game.setStatus("Load failed: " + err.Error())
+		} else {
+			rememberScene(*scenePath)
+		}
+	case *last:
+		game.resumeLast()
+	case *animationPath == "" && !game.openStartScreen() && !cfg.Soak:
+		// With nothing to resume, ask what to start with rather than
+		// dropping the user into a random scene, unless running unattended
+		game.openWizard()
+	}
+	if restore {
+		game.restorePanels(window.Panels)
+	}
+	if *animationPath != "" {
+		if err := game.loadAnimation(*animationPath); err != nil {
+			slog.Warn("loading animation", "path", *animationPath, "err", err)
+		}
+	}
+	if err := ebiten.RunGame(game); err != nil {
+		if err != ebiten.Termination {
+			panic(err)
+		}
+	}
+}
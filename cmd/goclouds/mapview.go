@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/render/ebitenrender"
+)
+
+// MapMode is how the top-down map is shown
+type MapMode int
+
+const (
+	mapOff   MapMode = iota
+	mapInset         // In a corner over the scene
+	mapSplit         // Beside the scene, which shrinks to make room
+	numMapModes
+)
+
+var mapModeNames = [numMapModes]string{"Off", "Corner", "Side by Side"}
+
+const (
+	splitScale = 0.6 // Size of the scene beside the map
+	insetWidth = 240
+	insetDepth = 120
+)
+
+var splitBackground = color.RGBA{20, 24, 30, 255}
+
+// viewArea is where the scene is drawn on the screen: its top left and
+// scale, shrunk to the left when the map is beside it
+func (g *Game) viewArea() (left, top, scale float64) {
+	if g.mapMode == mapSplit {
+		return 0, float64(screenHeight) * (1 - splitScale) / 2, splitScale
+	}
+	return 0, 0, 1
+}
+
+// mapArea is where the map is drawn, reporting false when it is off or
+// hidden with the HUD. Beside the scene it stays up with the HUD hidden,
+// as it is part of the view.
+func (g *Game) mapArea() (render.Map, bool) {
+	switch {
+	case g.mapMode == mapSplit:
+		left := float64(screenWidth)*splitScale + 10
+		return render.Map{X: left, Y: 30, Width: float64(screenWidth) - left - 10, Height: float64(screenHeight) - 60}, true
+	case g.mapMode == mapInset && !g.hud.hidden:
+		return render.Map{X: float64(screenWidth-insetWidth) / 2, Y: 30, Width: insetWidth, Height: insetDepth}, true
+	}
+	return render.Map{}, false
+}
+
+// setMapMode shows the map in a corner or beside the scene, or hides it
+func (g *Game) setMapMode(mode MapMode) {
+	g.mapMode = mode
+	g.setStatus("Map: " + mapModeNames[mode])
+}
+
+// stepMapMode moves on to the next or previous way of showing the map
+func (g *Game) stepMapMode(dir int) {
+	g.setMapMode(MapMode(cycle(int(g.mapMode), dir, int(numMapModes))))
+}
+
+// updateMap focuses the camera on the spot on the ground a click on the
+// map shows, reporting whether it took the click
+func (g *Game) updateMap() bool {
+	m, ok := g.mapArea()
+	if !ok {
+		return false
+	}
+	x, y := g.pointerPosition()
+	if !m.Contains(float64(x), float64(y)) {
+		return false
+	}
+	if g.pointerPressed() {
+		gx, gy := m.Ground(g.World, float64(x), float64(y))
+		g.focusOn(point{gx, gy}, focusZoom)
+	}
+	return g.pointerDown()
+}
+
+// clearBeside fills the screen around the shrunken scene, as the screen
+// isn't cleared between frames
+func (g *Game) clearBeside(screen *ebiten.Image) {
+	if g.mapMode == mapSplit {
+		screen.Fill(splitBackground)
+	}
+}
+
+func (g *Game) drawMap(screen *ebiten.Image) {
+	m, ok := g.mapArea()
+	if !ok {
+		return
+	}
+	m.Draw(ebitenrender.Screen{Image: screen}, g.World)
+	ebitenutil.DebugPrintAt(screen, "Map (click to focus)", int(m.X), int(m.Y)-18)
+}
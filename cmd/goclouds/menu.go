@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// menuLeft, menuTop and menuWidth place the environment menu's backdrop,
+// which grows down to fit the page showing
+const (
+	menuLeft  = 10
+	menuTop   = 10
+	menuWidth = 300
+)
+
+// menuArea is the menu's backdrop. Clicks anywhere on it stay with the menu
+// rather than reaching the scene.
+func (g *Game) menuArea() rect {
+	return rect{menuLeft, menuTop, menuWidth, g.menuPages().current().bottom() + 6 - menuTop}
+}
+
+// menuPages returns the menu's pages of widgets, built the first time the
+// menu is used. They read and change the scene through g, so they follow
+// tab switches without being rebuilt.
+func (g *Game) menuPages() *Pages {
+	if g.menu.pages != nil {
+		return g.menu.pages
+	}
+	tabs := rect{menuLeft + 5, menuTop + 28, menuWidth - 10, widgetHeight}
+	page := func(widgets ...widget) *Panel {
+		return &Panel{area: rect{tabs.x, tabs.y + tabs.h + 8, tabs.w, 0}, widgets: widgets}
+	}
+	g.menu.pages = &Pages{
+		area:   tabs,
+		titles: []string{"Environment", "Weather", "Colors", "Display", "Controls"},
+		panels: []*Panel{
+			page(g.environmentWidgets()...),
+			page(g.weatherWidgets()...),
+			page(g.colorWidgets()...),
+			page(append(g.displayWidgets(), g.calibrationWidgets()...)...),
+			page(g.controlsWidgets()...),
+		},
+	}
+	return g.menu.pages
+}
+
+// environmentWidgets arrange the scene: counts, shadows, props and templates
+func (g *Game) environmentWidgets() []widget {
+	names := make([]string, len(scene.Templates))
+	for i, t := range scene.Templates {
+		names[i] = t.Name
+	}
+	return []widget{
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Clouds: %d", g.CloudCount) },
+			value:  func() float64 { return float64(g.CloudCount) },
+			set:    func(v float64) { g.setCloudCount(int(v)) },
+			limits: func() (float64, float64) { return 0, float64(g.MaxClouds) },
+			step:   10,
+			help:   "How many clouds there are, drag or use Left/Right",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Trees: %d", g.TreeCount) },
+			value:  func() float64 { return float64(g.TreeCount) },
+			set:    func(v float64) { g.setTreeCount(int(v)) },
+			limits: func() (float64, float64) { return 1, 20 },
+			step:   1,
+			help:   "How many trees are planted, new ones at random spots",
+		},
+		&Slider{
+			label: func() string {
+				return fmt.Sprintf("Shadow: %.1f (%s/%s)", g.TreeShadow, g.keyName("shadowDown"), g.keyName("shadowUp"))
+			},
+			value:  func() float64 { return g.TreeShadow },
+			set:    g.setTreeShadow,
+			limits: func() (float64, float64) { return 0.2, 2 },
+			step:   0.1,
+			help:   "How dark tree shadows are",
+		},
+		&Button{
+			label: func() string {
+				return fmt.Sprintf("Prop: %s (Shift+%s)", placeableProps[g.menu.placeKind], g.keyName("prop"))
+			},
+			click: func() { g.menu.placeKind = cycle(g.menu.placeKind, 1, len(placeableProps)) },
+			step:  func(dir int) { g.menu.placeKind = cycle(g.menu.placeKind, dir, len(placeableProps)) },
+			help:  "Which prop is placed at the pointer, click to change",
+		},
+		&Button{
+			label: func() string {
+				if g.sunSnap {
+					return "Sun Drag: Along Its Arc"
+				}
+				return "Sun Drag: Free"
+			},
+			click: func() { g.sunSnap = !g.sunSnap },
+			step:  func(int) { g.sunSnap = !g.sunSnap },
+			help:  "Keep a dragged sun on its daily arc, setting the time of day. Shift-drag places it freely",
+		},
+		&ButtonRow{
+			labels: names,
+			click: func(i int) {
+				g.menu.template = i
+				g.applyTemplate()
+			},
+			selected: func() int { return g.menu.template },
+			help:     "Regenerate the trees, ground and clouds from a template and the seed",
+		},
+		&Button{
+			label: func() string { return fmt.Sprintf("Seed: %d (Shift+%s)", g.menu.seed, g.keyName("template")) },
+			click: g.editSeed,
+			help:  "Type the seed templates are generated from",
+		},
+	}
+}
+
+// controlsWidgets rebind keys, then list the ones that can't be rebound
+func (g *Game) controlsWidgets() []widget {
+	var ws []widget
+	for _, a := range bindable {
+		ws = append(ws, &Button{
+			label: func() string {
+				if g.menu.rebinding == a.name {
+					return a.label + ": press a key (ESC: cancel)"
+				}
+				return a.label + ": " + g.keyName(a.name)
+			},
+			click: func() { g.startRebinding(a.name) },
+			help:  "Click, then press the new key for " + a.label,
+		})
+	}
+	ws = append(ws, &Button{
+		label: func() string { return "Reset Keys to Defaults" },
+		click: g.resetKeys,
+		help:  "Put back the default key for every action",
+	})
+	return append(ws, labels(
+		"- Click/Drag or Arrows/Enter: Menu",
+		"- Tab/Shift+Tab: Menu Pages, ESC: Close",
+		"- LMB: Drag, RMB: Edit Sun/Tree/Cloud",
+		"- Ctrl+S/O/E: Save/Load/Export Scene",
+		"- Ctrl+Z/Y, Ctrl+C/V: Undo/Redo, Share",
+		"- 1-9: Load Slot (Shift: Save)",
+		"- N: Rename, F: Focus, C: Cloud Types, H: Tour",
+		"- Ctrl+N/Ctrl+T: New Scene/Tab, ESC: Exit",
+	)...)
+}
+
+// weatherWidgets set the clouds, wind and ground
+func (g *Game) weatherWidgets() []widget {
+	moods := []string{"Off"}
+	for _, m := range sim.Moods {
+		moods = append(moods, m.Name)
+	}
+	return []widget{
+		&ButtonRow{
+			labels: moods,
+			click: func(i int) {
+				if i == 0 {
+					g.setMood(nil)
+				} else {
+					g.setMood(&sim.Moods[i-1])
+				}
+			},
+			selected: func() int { return g.moodIndex() + 1 },
+			help:     "Keep the weather, sun and wind to a mood instead of setting them yourself",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Density: %.1f", g.Density) },
+			value:  func() float64 { return g.Density },
+			set:    g.setDensity,
+			limits: func() (float64, float64) { return 0, 1 },
+			step:   0.1,
+			help:   "Share of the clouds in the sky. A cloudier sky fills them with rain faster",
+		},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Wind: %.1f", g.Wind.Base) },
+			value:  func() float64 { return g.Wind.Base },
+			set:    g.setWind,
+			limits: func() (float64, float64) { return 0, 5 },
+			step:   0.1,
+			help:   "Prevailing wind. Clouds stretch and lean as it gusts",
+		},
+		&Label{text: func() string { return fmt.Sprintf("Gusting at %.2f", g.Wind.Speed) }},
+		&Slider{
+			label:  func() string { return fmt.Sprintf("Wet Ground: %.1f", g.Wetness) },
+			value:  func() float64 { return g.Wetness },
+			set:    g.setWetness,
+			limits: func() (float64, float64) { return 0, 1 },
+			step:   0.1,
+			help:   "Wet ground mirrors the sky and dries over a few minutes",
+		},
+	}
+}
+
+// displayWidgets choose what is drawn over the scene and how it moves
+func (g *Game) displayWidgets() []widget {
+	// Each toggle shows the key that flips it, with Shift for notes
+	toggle := func(name, action, shift, help string, on *bool) widget {
+		flip := func() { *on = !*on }
+		return &Button{
+			label: func() string { return fmt.Sprintf("%s (%s%s): %s", name, shift, g.keyName(action), onOff(*on)) },
+			click: flip,
+			step:  func(int) { flip() },
+			help:  help,
+		}
+	}
+	return []widget{
+		&Button{
+			label: func() string { return "Reduced Motion: " + onOff(g.ReducedMotion) },
+			click: func() { g.setReducedMotion(!g.ReducedMotion) },
+			step:  func(int) { g.setReducedMotion(!g.ReducedMotion) },
+			help:  "Slow drifting and gliding, for vestibular or photosensitivity issues",
+		},
+		&Button{
+			label: func() string { return "Tooltips: " + onOff(!g.tooltip.hidden) },
+			click: func() { g.tooltip.hidden = !g.tooltip.hidden },
+			step:  func(int) { g.tooltip.hidden = !g.tooltip.hidden },
+			help:  "Help like this when the pointer rests on a control or object",
+		},
+		toggle("Sticky Notes", "note", "Shift+", "Show the notes pinned to the scene", &g.notesVisible),
+		toggle("Diagnostics", "diagnostics", "", "Frame rates, counts, draw calls and cache use", &g.diagnostics.visible),
+		toggle("Frame Graph", "frameGraph", "", "Graph of the last few seconds of frame times, marking shadow redraws", &g.diagnostics.graph),
+		toggle("Light Probes", "probes", "", "Heatmap of the light trees get across the ground", &g.diagnostics.probes),
+		toggle("Uptime", "uptime", "", "Uptime, frame rate and memory use", &g.soak.visible),
+		&Button{
+			label: func() string { return "Map: " + mapModeNames[g.mapMode] + " (" + g.keyName("map") + ")" },
+			click: func() { g.stepMapMode(1) },
+			step:  g.stepMapMode,
+			help:  "Top-down map of trees, cloud shadows and wind, in a corner or beside the scene",
+		},
+		&Button{
+			label: func() string { return "Gamepad Rumble: " + onOff(g.config.current.Rumble) },
+			click: func() { g.setRumble(!g.config.current.Rumble) },
+			step:  func(int) { g.setRumble(!g.config.current.Rumble) },
+			help:  "Shake connected gamepads when a storm cloud starts to rain or a strong gust blows",
+		},
+		&Button{
+			label: func() string { return "Hide HUD (" + g.keyName("hud") + ")" },
+			click: func() { g.setHUDHidden(true, false) },
+			help:  "Hide the menu and every overlay for a clean view or screenshot, ESC brings them back",
+		},
+		&Button{
+			label: func() string { return "Presentation Mode (Shift+" + g.keyName("hud") + ")" },
+			click: func() { g.setHUDHidden(true, true) },
+			help:  "Hide the HUD, and the pointer whenever it is left alone for a few seconds",
+		},
+	}
+}
+
+func onOff(v bool) string {
+	if v {
+		return "On"
+	}
+	return "Off"
+}
+
+// updateMenu handles the menu's mouse and keys, reporting whether it took
+// the mouse. Dragging a slider is undone in one step, however many values
+// it passed through.
+func (g *Game) updateMenu() bool {
+	pages := g.menuPages()
+	m := g.readMouse()
+	if !pages.current().dragging() {
+		g.menu.dragFrom = len(g.history.undo)
+	}
+	used := pages.update(m)
+	if !pages.current().dragging() {
+		g.groupHistory(g.menu.dragFrom)
+	}
+	pages.current().updateKeys()
+	return used || (m.pressed && g.menuArea().contains(m.x, m.y))
+}
+
+// drawMenu draws the menu's tabs and the page showing
+func (g *Game) drawMenu(screen *ebiten.Image) {
+	area := g.menuArea()
+	drawRect(screen, area.x, area.y, area.w, area.h, color.RGBA{0, 0, 0, 180})
+	ebitenutil.DebugPrintAt(screen, "=== Environment Controls ===", menuLeft+5, menuTop+10)
+	g.menuPages().draw(screen, g.readMouse())
+}
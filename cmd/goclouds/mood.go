@@ -0,0 +1,31 @@
+package main
+
+import (
+	"cloudapp/pkg/sim"
+)
+
+// setMood hands the weather, light and wind over to the director to keep to
+// a mood, or takes them back with nil
+func (g *Game) setMood(m *sim.Mood) {
+	if m == g.Director.Mood {
+		return
+	}
+	g.Direct(m)
+	if m == nil {
+		g.setStatus("Mood off, the weather stays as it is")
+		g.logEvent("mood off")
+		return
+	}
+	g.setStatus("Mood: " + m.Name)
+	g.logEvent("mood set to %s", m.Name)
+}
+
+// moodIndex is the mood being kept to as its place in sim.Moods, -1 for none
+func (g *Game) moodIndex() int {
+	for i := range sim.Moods {
+		if g.Director.Mood == &sim.Moods[i] {
+			return i
+		}
+	}
+	return -1
+}
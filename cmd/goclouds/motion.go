@@ -0,0 +1,12 @@
+package main
+
+func (g *Game) setReducedMotion(on bool) {
+	g.ReducedMotion = on
+	if on {
+		g.ShootingStar.Active = false
+		g.FinishTransition()
+		g.setStatus("Reduced motion on")
+	} else {
+		g.setStatus("Reduced motion off")
+	}
+}
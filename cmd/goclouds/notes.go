@@ -0,0 +1,80 @@
+package main
+
+import (
+	"image/color"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const maxNoteLength = 40
+
+// Note is a sticky note pinned to a point in the scene, used to label areas
+// of a planned layout
+type Note struct {
+	x, y float64 // Pin position
+	text string
+}
+
+// noteAt returns the index of the note whose pin or card is under the point,
+// or -1 when there is none
+func (g *Game) noteAt(x, y float64) int {
+	for i := len(g.notes) - 1; i >= 0; i-- {
+		n := g.notes[i]
+		width := float64(len(n.text)*6 + 10)
+		if x >= n.x-4 && x <= n.x+width && y >= n.y-24 && y <= n.y+4 {
+			return i
+		}
+	}
+	return -1
+}
+
+// editNoteAt edits the note under the point, or pins a new one there.
+// Clearing a note's text removes it.
+func (g *Game) editNoteAt(x, y float64) {
+	i := g.noteAt(x, y)
+	initial := ""
+	if i >= 0 {
+		x, y = g.notes[i].x, g.notes[i].y
+		initial = g.notes[i].text
+	}
+	g.notesVisible = true
+
+	g.startTextEdit("Note", initial, maxNoteLength, func(text string) {
+		after := slices.Clone(g.notes)
+		i := g.noteAt(x, y)
+		switch {
+		case i >= 0 && text == "":
+			after = slices.Delete(after, i, i+1)
+		case i >= 0:
+			after[i].text = text
+		case text != "":
+			after = append(after, Note{x: x, y: y, text: text})
+		default:
+			return
+		}
+		g.exec(notesCmd{before: g.notes, after: after})
+	})
+}
+
+// drawNotes draws the sticky notes as an overlay on top of the scene. They
+// are annotations for whoever arranges the scene, not part of it, so any
+// export of the scene itself should leave them out.
+func (g *Game) drawNotes(screen *ebiten.Image) {
+	if !g.notesVisible {
+		return
+	}
+	for _, n := range g.notes {
+		width := float64(len(n.text)*6 + 10)
+
+		// Card sits just above and to the right of its pin
+		drawRect(screen, n.x+2, n.y-22, width, 18, color.RGBA{60, 50, 0, 80}) // Drop shadow
+		drawRect(screen, n.x, n.y-24, width, 18, color.RGBA{255, 235, 120, 240})
+		ebitenutil.DebugPrintAt(screen, n.text, int(n.x)+5, int(n.y)-23)
+
+		// Pin
+		drawLine(screen, n.x, n.y-6, n.x, n.y, color.RGBA{80, 80, 80, 255})
+		drawCircle(screen, n.x, n.y-7, 2.5, color.RGBA{220, 40, 40, 255})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"time"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/scene"
+)
+
+const paletteFile = "palettes.json"
+
+// PaletteWatch notices edits to the palette file while the app runs and
+// fades the scene over to the new colors
+type PaletteWatch struct {
+	path     string
+	modTime  time.Time
+	nextPoll float64
+
+	from, to scene.Palette
+	progress float64 // 0-1 through the fade, 1 when not fading
+}
+
+// loadPalettes reads the palette file into scene.Palettes, writing the
+// built-in palettes to it on first run so they can be edited
+func loadPalettes(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return os.WriteFile(path, scene.DefaultPalettes, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+
+	palettes, err := scene.ParsePalettes(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	scene.Palettes = palettes
+	return nil
+}
+
+// watchPalettes starts watching the palette file loaded at startup
+func (g *Game) watchPalettes(path string) {
+	g.palettes = PaletteWatch{path: path, progress: 1}
+	if info, err := os.Stat(path); err == nil {
+		g.palettes.modTime = info.ModTime()
+	}
+}
+
+// updatePaletteWatch polls the palette file and steps a running fade
+func (g *Game) updatePaletteWatch(dt float64) {
+	w := &g.palettes
+	if w.progress < 1 {
+		w.progress = math.Min(1, w.progress+dt/configTweenTime)
+		ease := w.progress * w.progress * (3 - 2*w.progress)
+		g.Palette = lerpPalette(w.from, w.to, ease)
+	}
+
+	if w.path == "" || g.SimTime < w.nextPoll {
+		return
+	}
+	w.nextPoll = g.SimTime + configPollInterval
+	info, err := os.Stat(w.path)
+	if err != nil || !info.ModTime().After(w.modTime) {
+		return
+	}
+	w.modTime = info.ModTime()
+
+	// A broken file keeps the palettes already loaded, it is most likely
+	// saved halfway through an edit
+	if err := loadPalettes(w.path); err != nil {
+		g.setStatus("Palettes not reloaded: " + err.Error())
+		return
+	}
+	g.logEvent("palettes reloaded from %s", w.path)
+	p, ok := g.PaletteFor(g.PaletteName)
+	if !ok {
+		g.setStatus(fmt.Sprintf("Palettes reloaded, %q is gone so its colors are kept", g.PaletteName))
+		return
+	}
+	g.setStatus("Palettes reloaded")
+	w.from, w.to, w.progress = g.Palette, p, 0
+}
+
+// lerpPalette blends every color of two palettes
+func lerpPalette(a, b scene.Palette, t float64) scene.Palette {
+	var out scene.Palette
+	fa, fb, fo := a.Fields(), b.Fields(), out.Fields()
+	for i := range fo {
+		*fo[i].Color = render.Mix(*fa[i].Color, *fb[i].Color, t)
+	}
+	return out
+}
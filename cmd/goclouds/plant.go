@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// plantShape is the shape of a tree planted by double-clicking the ground:
+// Shift for a triangle, Ctrl for an oval, Alt for a circle and otherwise a
+// branching tree like the ones the scene grows
+func plantShape() int {
+	switch {
+	case ebiten.IsKeyPressed(ebiten.KeyShift):
+		return scene.ShapeTriangle
+	case ebiten.IsKeyPressed(ebiten.KeyControl):
+		return scene.ShapeOval
+	case ebiten.IsKeyPressed(ebiten.KeyAlt):
+		return scene.ShapeCircle
+	}
+	return scene.ShapeBranching
+}
+
+// spawnSize is the size of a cloud spawned by double-clicking the sky:
+// Shift for a big heaped one, which is a cumulonimbus low in the sky, Ctrl
+// for a small wisp and otherwise anything in between. Its type follows
+// from where it is, as for every cloud.
+func (g *Game) spawnSize() float64 {
+	switch {
+	case ebiten.IsKeyPressed(ebiten.KeyShift):
+		return 75 + g.Rand.Float64()*5
+	case ebiten.IsKeyPressed(ebiten.KeyControl):
+		return 30 + g.Rand.Float64()*5
+	}
+	return 30 + g.Rand.Float64()*50
+}
+
+// plantAt plants a tree at a spot on the ground or spawns a cloud at one in
+// the sky, as one undoable edit
+func (g *Game) plantAt(x, y float64) {
+	if y >= g.GroundTop() {
+		if len(g.Trees) >= maxTrees {
+			g.setStatus(fmt.Sprintf("A scene holds at most %d trees", maxTrees))
+			return
+		}
+		t := g.PlantTree(x, y, plantShape())
+		g.editTrees("plant "+treeLabel(&t), g.TreeCount+1, func(trees []sim.Tree) []sim.Tree {
+			return append(trees, t)
+		})
+		g.selectedID = t.ID
+		return
+	}
+	if len(g.Clouds) >= scene.MaxClouds {
+		g.setStatus(fmt.Sprintf("A scene holds at most %d clouds", scene.MaxClouds))
+		return
+	}
+	size := g.spawnSize()
+	c := g.NewCloud(x-size*0.35, y, size) // Its lobes heap up to the right of X
+	// First in the list, as the clouds shown are the first few, however
+	// thin the density makes them
+	g.editClouds("spawn "+g.CloudKind(c).String()+" cloud", g.CloudCount+1, func(clouds []sim.Cloud) []sim.Cloud {
+		return slices.Insert(clouds, 0, c)
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cloudapp/pkg/scene"
+)
+
+// A player is a copy of the executable with a scene appended, followed by a
+// trailer holding the scene's length and playerMagic. Copying the file is
+// all it takes to hand a finished scene to someone else.
+const (
+	playerMagic   = "GOCLOUDS-PLAYER1"
+	playerTrailer = 8 + len(playerMagic)
+	playerName    = "goclouds-player"
+	playerData    = ".scene.json" // Companion file checked when nothing is appended
+)
+
+// Player is what an exported player boots into. The window size and spawn
+// margins are kept with the scene, as scenes made by the wizard can differ
+// in size.
+type Player struct {
+	Width   int           `json:"width"`
+	Height  int           `json:"height"`
+	Margins scene.Margins `json:"margins"`
+	Scene   scene.Scene   `json:"scene"`
+}
+
+// exportPlayer writes a copy of the running executable with the scene in
+// front appended, returning the path written
+func (g *Game) exportPlayer() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	program, err := os.ReadFile(exe)
+	if err != nil {
+		return "", err
+	}
+	// Exporting from a player replaces its scene rather than stacking another
+	program = program[:len(program)-appendedLength(program)]
+
+	data, err := json.Marshal(Player{Width: screenWidth, Height: screenHeight, Margins: g.Margins, Scene: g.scene()})
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	out.Write(program)
+	out.Write(data)
+	binary.Write(&out, binary.LittleEndian, uint64(len(data)))
+	out.WriteString(playerMagic)
+
+	path := playerName + filepath.Ext(exe) // Keeps .exe on Windows
+	if err := os.WriteFile(path, out.Bytes(), 0o755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// exportScenePlayer exports a player and reports where it went
+func (g *Game) exportScenePlayer() {
+	path, err := g.exportPlayer()
+	if err != nil {
+		g.setStatus("Export failed: " + err.Error())
+		return
+	}
+	g.setStatus("Player exported to " + path)
+	g.logEvent("scene exported as a player to %s", path)
+}
+
+// appendedLength is how many bytes at the end of an executable are an
+// appended scene and its trailer, 0 when there is none
+func appendedLength(program []byte) int {
+	if len(program) < playerTrailer || !bytes.HasSuffix(program, []byte(playerMagic)) {
+		return 0
+	}
+	n := binary.LittleEndian.Uint64(program[len(program)-playerTrailer:])
+	if n > uint64(len(program)-playerTrailer) {
+		return 0
+	}
+	return int(n) + playerTrailer
+}
+
+// loadPlayer finds the scene a player was exported with, appended to the
+// executable or in a companion file beside it for platforms where the
+// executable can't be changed, such as signed macOS apps. It reports false
+// for an ordinary copy of the app.
+func loadPlayer() (Player, bool, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return Player{}, false, err
+	}
+	data, err := readAppended(exe)
+	if err != nil {
+		return Player{}, false, err
+	}
+	if data == nil {
+		companion := strings.TrimSuffix(exe, filepath.Ext(exe)) + playerData
+		if data, err = os.ReadFile(companion); errors.Is(err, os.ErrNotExist) {
+			return Player{}, false, nil
+		} else if err != nil {
+			return Player{}, false, err
+		}
+	}
+
+	var p Player
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Player{}, true, fmt.Errorf("parse player scene: %w", err)
+	}
+	if p.Width <= 0 || p.Height <= scene.GroundHeight {
+		return Player{}, true, fmt.Errorf("player size %dx%d is too small", p.Width, p.Height)
+	}
+	// Run the scene itself through Decode so older versions are migrated
+	raw, err := json.Marshal(p.Scene)
+	if err != nil {
+		return Player{}, true, err
+	}
+	if p.Scene, err = scene.Decode(raw); err != nil {
+		return Player{}, true, fmt.Errorf("parse player scene: %w", err)
+	}
+	return p, true, nil
+}
+
+// readAppended reads the scene appended to an executable without reading
+// the rest of it, returning nil when nothing is appended
+func readAppended(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < int64(playerTrailer) {
+		return nil, nil
+	}
+	trailer := make([]byte, playerTrailer)
+	if _, err := f.ReadAt(trailer, size-int64(playerTrailer)); err != nil {
+		return nil, err
+	}
+	if string(trailer[8:]) != playerMagic {
+		return nil, nil
+	}
+	n := binary.LittleEndian.Uint64(trailer)
+	if n > uint64(size)-uint64(playerTrailer) {
+		return nil, nil
+	}
+	data := make([]byte, n)
+	if _, err := f.ReadAt(data, size-int64(playerTrailer)-int64(n)); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// startPlayer switches the game into kiosk mode for an exported scene: no
+// menus, hints or editing, nothing written to disk, and errors recovered
+// from as in soak mode. Escape still quits.
+func (g *Game) startPlayer(p Player) {
+	g.applyScene(p.Scene)
+	g.kiosk = true
+	if !g.soak.enabled {
+		g.startSoak()
+	}
+	g.logEvent("playing an exported scene")
+}
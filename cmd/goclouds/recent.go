@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	recentFile = "goclouds-recent.json"
+	maxRecent  = 5 // Scene files remembered
+)
+
+// loadRecent returns the scene files opened or saved most recently, newest
+// first, leaving out any that have since been deleted
+func loadRecent() []string {
+	data, err := os.ReadFile(recentFile)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil
+	}
+	return slices.DeleteFunc(paths, func(path string) bool {
+		_, err := os.Stat(path)
+		return err != nil
+	})
+}
+
+// rememberScene moves a scene file to the top of the recent list. Failing
+// to is only logged, as the scene itself was saved or opened fine.
+func rememberScene(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	paths := slices.DeleteFunc(loadRecent(), func(p string) bool { return p == path })
+	paths = append([]string{path}, paths...)
+	paths = paths[:min(len(paths), maxRecent)]
+	data, err := json.MarshalIndent(paths, "", "  ")
+	if err == nil {
+		err = os.WriteFile(recentFile, data, 0o644)
+	}
+	if err != nil {
+		slog.Warn("saving recent scenes", "path", recentFile, "err", err)
+	}
+}
+
+// openScene loads a scene file into the scene in front, which Ctrl+S then
+// saves back to
+func (g *Game) openScene(path string) error {
+	if err := g.loadScene(path); err != nil {
+		return err
+	}
+	g.scenePath = path
+	rememberScene(path)
+	return nil
+}
+
+// StartScreen is shown at launch when there is something to pick up from,
+// offering the recent scene files, the autosave of the last session and a
+// fresh start
+type StartScreen struct {
+	visible bool
+	cursor  int
+	choices []startChoice
+}
+
+type startChoice struct {
+	label string
+	start func(g *Game)
+}
+
+// openStartScreen offers whatever there is to resume, reporting whether
+// there was anything
+func (g *Game) openStartScreen() bool {
+	var choices []startChoice
+	for _, path := range loadRecent() {
+		choices = append(choices, startChoice{
+			label: "Open " + filepath.Base(path) + " (" + filepath.Dir(path) + ")",
+			start: func(g *Game) { g.resumeScene(path) },
+		})
+	}
+	if hasAutosave() {
+		choices = append(choices, startChoice{label: "Continue the last session", start: (*Game).resumeAutosave})
+	}
+	if len(choices) == 0 {
+		return false
+	}
+	choices = append(choices, startChoice{label: "Start fresh", start: (*Game).openWizard})
+	g.start = StartScreen{visible: true, choices: choices}
+	return true
+}
+
+// resumeScene opens a recent scene file
+func (g *Game) resumeScene(path string) {
+	if err := g.openScene(path); err != nil {
+		g.setStatus("Load failed: " + err.Error())
+		return
+	}
+	g.setStatus("Scene loaded from " + path)
+	g.logEvent("scene loaded from %s", path)
+}
+
+// resumeAutosave restores the snapshot the last session left behind
+func (g *Game) resumeAutosave() {
+	if err := g.loadScene(autosavePath); err != nil {
+		g.setStatus("Restore failed: " + err.Error())
+		return
+	}
+	g.setStatus("Previous scene restored")
+	g.logEvent("scene restored from autosave")
+}
+
+// resumeLast picks up the most recent scene file for -last, or the
+// autosave when no file has been opened yet
+func (g *Game) resumeLast() {
+	if recent := loadRecent(); len(recent) > 0 {
+		g.resumeScene(recent[0])
+	} else if hasAutosave() {
+		g.resumeAutosave()
+	} else {
+		g.setStatus("No recent scene to resume")
+	}
+}
+
+// updateStartScreen handles the start screen's keys, reporting whether it
+// is still waiting for a choice. Up/Down and Enter pick a choice, or 1-9,
+// a click or a tap picks one directly.
+func (g *Game) updateStartScreen() bool {
+	s := &g.start
+	if !s.visible {
+		return false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		s.cursor = cycle(s.cursor, -1, len(s.choices))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		s.cursor = cycle(s.cursor, 1, len(s.choices))
+	}
+	chosen := -1
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		chosen = s.cursor
+	}
+	for i := range min(len(s.choices), 9) {
+		if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+			chosen = i
+		}
+	}
+	if x, y, width, _ := g.startScreenBox(); chosen == -1 {
+		chosen = g.pressedRow(x, y, width, len(s.choices))
+	}
+	if chosen != -1 {
+		s.visible = false
+		s.choices[chosen].start(g)
+	}
+	return s.visible
+}
+
+// startScreenBox is where the start screen sits, wide enough for its
+// longest choice
+func (g *Game) startScreenBox() (x, y, width, height float64) {
+	width = 300.0
+	for _, c := range g.start.choices {
+		width = max(width, float64(len(c.label)*6+60))
+	}
+	height = float64(60 + len(g.start.choices)*20)
+	return float64(screenWidth)/2 - width/2, float64(screenHeight)/2 - height/2, width, height
+}
+
+// drawStartScreen lists the start choices in the middle of the screen
+func (g *Game) drawStartScreen(screen *ebiten.Image) {
+	s := &g.start
+	if !s.visible {
+		return
+	}
+	x, y, width, height := g.startScreenBox()
+	drawRect(screen, x, y, width, height, color.RGBA{0, 0, 0, 200})
+	ebitenutil.DebugPrintAt(screen, "=== Welcome back ===", int(x)+15, int(y)+10)
+	rowY := int(y) + 32
+	for i, c := range s.choices {
+		if i == s.cursor {
+			drawRect(screen, x+2, float64(rowY)-2, width-4, 18, color.RGBA{255, 255, 255, 60})
+		}
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d. %s", i+1, c.label), int(x)+15, rowY)
+		rowY += 20
+	}
+	ebitenutil.DebugPrintAt(screen, "Arrows and Enter, or 1-9, to choose", int(x)+15, rowY+4)
+}
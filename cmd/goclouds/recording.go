@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	maxRecordSeconds = 30
+	maxRecordFPS     = 50 // GIF frame delays are in hundredths of a second
+)
+
+// Recording says how the record key makes an animated GIF of the scene
+type Recording struct {
+	Folder  string  `json:"folder"`  // Made if need be
+	Seconds float64 `json:"seconds"` // Longest a recording runs before it is saved, 1-30
+	FPS     int     `json:"fps"`     // Frames a second, 1-50
+	Scale   float64 `json:"scale"`   // Size of the GIF against the scene's, 0.1-1
+}
+
+var defaultRecording = Recording{Folder: "recordings", Seconds: 5, FPS: 15, Scale: 0.5}
+
+// validate puts back settings that can't be used, reporting what it had to
+// change
+func (r *Recording) validate() []error {
+	var problems []error
+	if r.Folder == "" {
+		problems = append(problems, errors.New("recording.folder is empty"))
+		r.Folder = defaultRecording.Folder
+	}
+	if r.Seconds < 1 || r.Seconds > maxRecordSeconds {
+		problems = append(problems, fmt.Errorf("recording.seconds %.0f is outside 1-%d", r.Seconds, maxRecordSeconds))
+		r.Seconds = min(maxRecordSeconds, max(1, r.Seconds))
+	}
+	if r.FPS < 1 || r.FPS > maxRecordFPS {
+		problems = append(problems, fmt.Errorf("recording.fps %d is outside 1-%d", r.FPS, maxRecordFPS))
+		r.FPS = min(maxRecordFPS, max(1, r.FPS))
+	}
+	if r.Scale < 0.1 || r.Scale > 1 {
+		problems = append(problems, fmt.Errorf("recording.scale %.2f is outside 0.1-1", r.Scale))
+		r.Scale = min(1, max(0.1, r.Scale))
+	}
+	return problems
+}
+
+// Recorder gathers frames of the scene for an animated GIF. The frames are
+// shrunk on the GPU and read back into images kept from one recording to
+// the next, then handed to a goroutine to pick a palette and encode, so
+// neither recording nor saving holds up a frame for long.
+type Recorder struct {
+	active   bool
+	started  time.Time
+	last     time.Time     // When the latest frame was taken
+	small    *ebiten.Image // Each frame shrunk to the GIF's size, fixed when recording starts
+	frames   []*image.RGBA // Reused, the first count hold this recording
+	delays   []int         // Hundredths of a second each frame shows for
+	count    int
+	encoding bool        // The last recording is still being saved, and holds the frames
+	done     chan string // Status once it has been
+}
+
+// toggleRecording starts recording, or stops and saves what has been
+// recorded so far
+func (g *Game) toggleRecording() {
+	r := &g.recorder
+	switch {
+	case r.active:
+		g.stopRecording()
+	case r.encoding:
+		g.setStatus("Still saving the last recording")
+	default:
+		r.active, r.count = true, 0
+		r.started = time.Now()
+		r.last = time.Time{}
+		r.delays = r.delays[:0]
+		// The size is kept however the scene or config changes before the
+		// recording stops, as every frame of a GIF is the same size
+		scale := g.config.current.Recording.Scale
+		width, height := max(1, int(float64(g.Width)*scale)), max(1, int(float64(g.Height)*scale))
+		if r.small == nil || r.small.Bounds().Dx() != width || r.small.Bounds().Dy() != height {
+			if r.small != nil {
+				r.small.Deallocate()
+			}
+			r.small = ebiten.NewImage(width, height)
+			r.frames = r.frames[:0] // Sized for the last recording
+		}
+		g.setStatus(fmt.Sprintf("Recording up to %.0f seconds (%s again to stop)", g.config.current.Recording.Seconds, g.keyName("record")))
+		g.logEvent("recording started")
+	}
+}
+
+// recordFrame takes the scene as drawn this frame, before any overlays,
+// when the next frame of the GIF is due, and saves the recording once it
+// is as long as the config allows. A scene of another size, such as in
+// another tab, is stretched to the size the recording started at.
+func (g *Game) recordFrame(view *ebiten.Image) {
+	r := &g.recorder
+	if !r.active {
+		return
+	}
+	cfg := g.config.current.Recording
+	now := time.Now()
+	if !r.last.IsZero() && now.Sub(r.last) < time.Second/time.Duration(cfg.FPS) {
+		return
+	}
+	size := r.small.Bounds().Size()
+	opts := &ebiten.DrawImageOptions{Filter: ebiten.FilterLinear}
+	opts.GeoM.Scale(float64(size.X)/float64(view.Bounds().Dx()), float64(size.Y)/float64(view.Bounds().Dy()))
+	r.small.DrawImage(view, opts)
+
+	if r.count == len(r.frames) {
+		r.frames = append(r.frames, image.NewRGBA(r.small.Bounds()))
+	}
+	r.small.ReadPixels(r.frames[r.count].Pix)
+	if r.count > 0 {
+		// The previous frame shows until this one, however late it came
+		r.delays[r.count-1] = max(2, int(now.Sub(r.last)/(10*time.Millisecond)))
+	}
+	r.delays = append(r.delays, 100/cfg.FPS)
+	r.count++
+	r.last = now
+	if now.Sub(r.started).Seconds() >= cfg.Seconds {
+		g.stopRecording()
+	}
+}
+
+// stopRecording hands the frames to a goroutine to save as a GIF
+func (g *Game) stopRecording() {
+	r := &g.recorder
+	r.active = false
+	if r.count == 0 {
+		g.setStatus("Nothing was recorded")
+		return
+	}
+	folder := g.config.current.Recording.Folder
+	path := filepath.Join(folder, time.Now().Format("2006-01-02-150405")+".gif")
+	frames, delays := r.frames[:r.count], slices.Clone(r.delays[:r.count])
+	r.encoding = true
+	if r.done == nil {
+		r.done = make(chan string, 1)
+	}
+	done := r.done
+	g.setStatus(fmt.Sprintf("Saving %d frames to %s...", len(frames), path))
+	go func() {
+		if err := writeGIF(folder, path, frames, delays); err != nil {
+			slog.Warn("saving recording", "path", path, "err", err)
+			done <- "Recording not saved: " + err.Error()
+			return
+		}
+		done <- "Recording saved to " + path
+	}()
+}
+
+// updateRecording hears back from the goroutine saving a recording
+func (g *Game) updateRecording() {
+	r := &g.recorder
+	if !r.encoding {
+		return
+	}
+	select {
+	case msg := <-r.done:
+		r.encoding = false
+		g.setStatus(msg)
+		g.logEvent("%s", msg)
+	default:
+	}
+}
+
+// drawRecording shows a red dot and the time recorded so far at the top of
+// the screen while recording
+func (g *Game) drawRecording(screen *ebiten.Image) {
+	r := &g.recorder
+	if !r.active {
+		return
+	}
+	x := float64(screenWidth)/2 - 42
+	drawRect(screen, x, 4, 84, 20, color.RGBA{0, 0, 0, 160})
+	if time.Since(r.started)%time.Second < 700*time.Millisecond {
+		drawCircle(screen, x+11, 14, 5, color.RGBA{230, 40, 40, 255})
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("REC %4.1fs", time.Since(r.started).Seconds()), int(x)+20, 6)
+}
+
+// writeGIF quantizes the frames to one palette picked from all of them and
+// saves them as a looping GIF
+func writeGIF(folder, path string, frames []*image.RGBA, delays []int) error {
+	palette := gifPalette(frames)
+	anim := &gif.GIF{Image: make([]*image.Paletted, len(frames)), Delay: delays}
+	for i, f := range frames {
+		p := image.NewPaletted(f.Bounds(), palette)
+		draw.FloydSteinberg.Draw(p, f.Bounds(), f, image.Point{})
+		anim.Image[i] = p
+	}
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(out, anim); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// gifPalette picks the 256 colors most used across the frames, counting
+// colors to 5 bits a channel and taking the average of each. Skies are
+// mostly smooth gradients of a few hues, which this keeps well.
+func gifPalette(frames []*image.RGBA) color.Palette {
+	type bucket struct {
+		n       int
+		r, g, b int
+	}
+	buckets := make([]bucket, 1<<15)
+	for _, f := range frames {
+		// Every third pixel is plenty to find the colors
+		for i := 0; i+3 < len(f.Pix); i += 12 {
+			r, g, b := int(f.Pix[i]), int(f.Pix[i+1]), int(f.Pix[i+2])
+			k := &buckets[r>>3<<10|g>>3<<5|b>>3]
+			k.n++
+			k.r += r
+			k.g += g
+			k.b += b
+		}
+	}
+	slices.SortFunc(buckets, func(a, b bucket) int { return b.n - a.n })
+	var palette color.Palette
+	for _, k := range buckets[:256] {
+		if k.n == 0 {
+			break
+		}
+		palette = append(palette, color.RGBA{uint8(k.r / k.n), uint8(k.g / k.n), uint8(k.b / k.n), 255})
+	}
+	if len(palette) == 0 {
+		palette = append(palette, color.Black)
+	}
+	return palette
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/scene"
+)
+
+// Ruler measures distances and angles in the scene. While it is active, LMB
+// drags out a measurement instead of moving things.
+type Ruler struct {
+	active     bool
+	measured   bool // A measurement has been taken
+	start, end point
+}
+
+func (g *Game) updateRuler(cursorX, cursorY float64) {
+	if g.pointerPressed() {
+		g.ruler.start = point{cursorX, cursorY}
+		g.ruler.measured = true
+	}
+	if g.pointerDown() {
+		g.ruler.end = point{cursorX, cursorY}
+	}
+}
+
+// sunElevation is the angle of the sun above the horizon as seen from a
+// point, in degrees
+func (g *Game) sunElevation(from point) float64 {
+	horizon := float64(screenHeight - scene.GroundHeight + scene.GroundOffset)
+	rise := horizon - g.SunY
+	run := math.Abs(g.SunX - from.x)
+	return math.Atan2(rise, run) * 180 / math.Pi
+}
+
+func (g *Game) drawRuler(screen *ebiten.Image) {
+	if !g.ruler.active {
+		return
+	}
+
+	rulerColor := color.RGBA{255, 255, 0, 255}
+	lines := []string{"=== Ruler (R to close) ==="}
+
+	if g.ruler.measured {
+		start, end := g.ruler.start, g.ruler.end
+		dx := end.x - start.x
+		dy := end.y - start.y
+		length := math.Hypot(dx, dy)
+		angle := math.Atan2(-dy, dx) * 180 / math.Pi // Counter-clockwise from the +x axis
+
+		drawLine(screen, start.x, start.y, end.x, end.y, rulerColor)
+
+		// Ticks across both ends
+		nx, ny := 0.0, 6.0
+		if length > 0 {
+			nx, ny = -dy/length*6, dx/length*6
+		}
+		drawLine(screen, start.x-nx, start.y-ny, start.x+nx, start.y+ny, rulerColor)
+		drawLine(screen, end.x-nx, end.y-ny, end.x+nx, end.y+ny, rulerColor)
+
+		// Dotted sight line from the ruler's start to the sun
+		steps := 30
+		for i := 0; i < steps; i += 2 {
+			t0 := float64(i) / float64(steps)
+			t1 := float64(i+1) / float64(steps)
+			drawLine(
+				screen,
+				start.x+(g.SunX-start.x)*t0, start.y+(g.SunY-start.y)*t0,
+				start.x+(g.SunX-start.x)*t1, start.y+(g.SunY-start.y)*t1,
+				color.RGBA{255, 220, 0, 160},
+			)
+		}
+
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.1f px  %.1f deg", length, angle), int(end.x)+8, int(end.y)+4)
+		lines = append(lines,
+			fmt.Sprintf("Length: %.1f px", length),
+			fmt.Sprintf("Angle: %.1f deg", angle),
+			fmt.Sprintf("Sun elevation: %.1f deg", g.sunElevation(start)),
+		)
+	} else {
+		lines = append(lines, "Drag with LMB to measure")
+	}
+
+	// Compare the selected tree's height with the shadow it casts
+	if t := g.FindTree(g.selectedID); t != nil {
+		shadowLength, _ := g.ShadowGeometry(t)
+		shadowLength *= 0.8 // The shadow image is drawn at 80% of its nominal length
+		lines = append(lines,
+			treeLabel(t)+":",
+			fmt.Sprintf("  Height: %.1f px", t.Height()),
+			fmt.Sprintf("  Shadow: %.1f px (%.2fx height)", shadowLength, shadowLength/t.Height()),
+		)
+	}
+
+	x := float64(screenWidth)/2 - 110
+	drawRect(screen, x, 10, 220, float64(len(lines)*16+10), color.RGBA{0, 0, 0, 180})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, int(x)+6, 14+i*16)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	gustRumbleAt    = 0.45 // Gust, as a share above the prevailing wind, that rumbles
+	gustRumbleReset = 0.3  // Gust the wind has to drop back below before the next can rumble
+)
+
+// rumblePulse is one burst of vibration in a pattern, starting a while after
+// the pattern does
+type rumblePulse struct {
+	after        time.Duration
+	length       time.Duration
+	strong, weak float64 // Magnitudes of the low and high frequency motors, 0-1
+}
+
+// Rumble shakes the connected gamepads when the weather does something
+// worth feeling: a rolling double thump like thunder when a storm cloud
+// starts to rain, and a long shudder on a strong gust. Each is stronger the
+// bigger the cloud or gust. The sim has no lightning or fireworks, so storm
+// clouds stand in for thunder.
+type Rumble struct {
+	world   *sim.World // Scene the cloud states are for, so a new one doesn't rumble for clouds already raining
+	raining []bool     // Whether each cloud was raining last frame
+	gusting bool       // A gust has rumbled and the wind hasn't dropped back yet
+	queue   []queuedPulse
+}
+
+type queuedPulse struct {
+	at time.Time
+	rumblePulse
+}
+
+// updateRumble looks for storms starting and strong gusts, then plays the
+// pulses that are due on every connected gamepad
+func (g *Game) updateRumble() {
+	r := &g.rumble
+	if !g.config.current.Rumble || len(g.gamepad.ids) == 0 {
+		r.queue = r.queue[:0]
+		r.world = nil
+		return
+	}
+	g.watchStorms()
+	g.watchGusts()
+
+	now := time.Now()
+	due := 0
+	for _, p := range r.queue {
+		if now.Before(p.at) {
+			r.queue[due] = p
+			due++
+			continue
+		}
+		for _, id := range g.gamepad.ids {
+			ebiten.VibrateGamepad(id, &ebiten.VibrateGamepadOptions{
+				Duration:        p.length,
+				StrongMagnitude: p.strong,
+				WeakMagnitude:   p.weak,
+			})
+		}
+	}
+	r.queue = r.queue[:due]
+}
+
+// watchStorms rumbles like thunder for each storm cloud that starts to rain,
+// harder the bigger it is
+func (g *Game) watchStorms() {
+	r := &g.rumble
+	fresh := r.world != g.World || len(r.raining) != len(g.Clouds)
+	if fresh {
+		r.world = g.World
+		r.raining = make([]bool, len(g.Clouds))
+	}
+	for i, c := range g.Clouds {
+		started := c.Raining && !r.raining[i]
+		r.raining[i] = c.Raining
+		if fresh || !started || i >= g.ActiveCloudCount() || g.CloudKind(c) != sim.Cumulonimbus {
+			continue
+		}
+		s := math.Min(1, c.Size/80)
+		g.playRumble(
+			rumblePulse{0, 120 * time.Millisecond, s, s},
+			rumblePulse{200 * time.Millisecond, 700 * time.Millisecond, 0.6 * s, 0.3 * s},
+		)
+	}
+}
+
+// watchGusts shudders once as a gust rises well above the prevailing wind,
+// harder the stronger the wind it brings
+func (g *Game) watchGusts() {
+	r := &g.rumble
+	if g.Wind.Base <= 0 {
+		return
+	}
+	gust := g.Wind.Speed/g.Wind.Base - 1
+	switch {
+	case !r.gusting && gust > gustRumbleAt:
+		r.gusting = true
+		s := math.Min(1, g.Wind.Speed/2)
+		g.playRumble(rumblePulse{0, 600 * time.Millisecond, 0.3 * s, s})
+	case r.gusting && gust < gustRumbleReset:
+		r.gusting = false
+	}
+}
+
+// playRumble queues a pattern of pulses starting now
+func (g *Game) playRumble(pattern ...rumblePulse) {
+	now := time.Now()
+	for _, p := range pattern {
+		g.rumble.queue = append(g.rumble.queue, queuedPulse{now.Add(p.after), p})
+	}
+}
+
+// setRumble turns gamepad rumble on or off, saving the choice to the config
+// file as it belongs to the controller rather than the scene
+func (g *Game) setRumble(on bool) {
+	g.config.current.Rumble = on
+	if on {
+		g.playRumble(rumblePulse{0, 150 * time.Millisecond, 0.5, 0.5})
+	}
+	if err := g.saveConfig(func(c *Config) { c.Rumble = on }); err != nil {
+		slog.Warn("saving rumble", "path", g.config.path, "err", err)
+		g.setStatus("Rumble changed but not saved: " + err.Error())
+	}
+}
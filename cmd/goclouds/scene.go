@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"cloudapp/pkg/scene"
+)
+
+const sceneFile = "scene.json"
+
+// scene captures the current arrangement
+func (g *Game) scene() scene.Scene {
+	s := g.World.Scene()
+	for _, n := range g.notes {
+		s.Notes = append(s.Notes, scene.Note{X: n.x, Y: n.y, Text: n.text})
+	}
+	return s
+}
+
+// applyScene replaces the current arrangement with a saved one
+func (g *Game) applyScene(s scene.Scene) {
+	g.World.Apply(s)
+	g.menu.selectedCloud = -1
+	g.notes = make([]Note, len(s.Notes))
+	for i, n := range s.Notes {
+		g.notes[i] = Note{x: n.X, y: n.Y, text: n.Text}
+	}
+
+	// Drop any in-progress interaction that points into the old slices
+	g.isDraggingSun = false
+	g.draggedTree = -1
+	g.draggedProp = -1
+	g.selectedID = 0
+	g.textEdit.active = false
+	g.clearHistory()
+	g.rewind.Clear()
+}
+
+// rewindMinAge is how old a snapshot has to be for B to go back to it, so a
+// press just after a snapshot still goes back noticeably
+const rewindMinAge = 2
+
+// rewindSimulation returns the scene to the last snapshot, taken every few
+// seconds. Edits made since are lost with it, so the undo history is too.
+func (g *Game) rewindSimulation() {
+	back, ok := g.rewind.Back(g.World, rewindMinAge)
+	if !ok {
+		g.setStatus("Nothing to rewind to yet")
+		return
+	}
+	g.resetInteraction()
+	g.clearHistory()
+	g.setStatus(fmt.Sprintf("Rewound %.0f seconds (B again to go further)", back))
+	g.logEvent("simulation rewound %.0f seconds", back)
+}
+
+func (g *Game) saveScene(path string) error {
+	data, err := json.MarshalIndent(g.scene(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (g *Game) loadScene(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s, err := scene.Decode(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	g.applyScene(s)
+	return nil
+}
+
+// loadAnimation plays an animation file over the scene
+func (g *Game) loadAnimation(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	a, err := scene.ParseAnimation(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	g.Play(a)
+	g.logEvent("playing animation %s", path)
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/render"
+)
+
+const (
+	maxScreenshotScale  = 8
+	maxSupersample      = 4
+	maxScreenshotFactor = 8 // Most scale times supersample, keeping the image drawn within a few hundred megabytes
+)
+
+// Screenshots says where F12 saves screenshots and how large scene-only
+// ones are made
+type Screenshots struct {
+	Folder string `json:"folder"` // Made if need be
+	Scale  int    `json:"scale"`  // Times the scene's size a scene-only screenshot is saved at, 1-8
+
+	// Samples across and down each pixel of a scene-only screenshot
+	// averages, 1-4, smoothing the edges of shapes
+	Supersample int `json:"supersample"`
+}
+
+var defaultScreenshots = Screenshots{Folder: "screenshots", Scale: 1, Supersample: 1}
+
+// validate puts back a folder, scale or supersampling that can't be used,
+// reporting what it had to change
+func (s *Screenshots) validate() []error {
+	var problems []error
+	if s.Folder == "" {
+		problems = append(problems, errors.New("screenshots.folder is empty"))
+		s.Folder = defaultScreenshots.Folder
+	}
+	if s.Scale < 1 || s.Scale > maxScreenshotScale {
+		problems = append(problems, fmt.Errorf("screenshots.scale %d is outside 1-%d", s.Scale, maxScreenshotScale))
+		s.Scale = min(maxScreenshotScale, max(1, s.Scale))
+	}
+	if s.Supersample < 1 || s.Supersample > maxSupersample {
+		problems = append(problems, fmt.Errorf("screenshots.supersample %d is outside 1-%d", s.Supersample, maxSupersample))
+		s.Supersample = min(maxSupersample, max(1, s.Supersample))
+	}
+	if s.Scale*s.Supersample > maxScreenshotFactor {
+		problems = append(problems, fmt.Errorf("screenshots.scale times supersample is over %d", maxScreenshotFactor))
+		s.Supersample = max(1, maxScreenshotFactor/s.Scale)
+	}
+	return problems
+}
+
+// Screenshot is a screenshot waiting for the next frame to be drawn
+type Screenshot struct {
+	screen bool // Of the window as it shows, HUD and all
+	scene  bool // Of the whole scene alone, at its own size
+}
+
+// screenshot saves a picture: of the window as it is with F12, or with
+// Shift+F12 of the whole scene without the HUD, panned and zoomed out,
+// scaled up and supersampled as the config asks. Larger scene shots are
+// drawn in software away from the window, so they can be bigger than it.
+func (g *Game) screenshot(sceneOnly bool) {
+	cfg := g.config.current.Screenshots
+	switch {
+	case !sceneOnly:
+		g.shot.screen = true
+	case cfg.Scale == 1 && cfg.Supersample == 1:
+		g.shot.scene = true
+	default:
+		started := time.Now()
+		k := cfg.Scale * cfg.Supersample
+		frame := render.NewRaster(g.Width*k, g.Height*k, g.Width, g.Height)
+		g.clock.Blend(g.World, func() { render.New().Draw(frame, g.World) })
+		img := downsample(frame.Image, cfg.Supersample)
+		slog.Debug("drew screenshot", "size", img.Bounds().Size(), "took", time.Since(started))
+		g.saveScreenshot(img)
+	}
+}
+
+// takeScreenshot saves img, just drawn, if a screenshot of it was asked for
+func (g *Game) takeScreenshot(img *ebiten.Image, wanted *bool) {
+	if !*wanted {
+		return
+	}
+	*wanted = false
+	pixels := image.NewRGBA(img.Bounds())
+	img.ReadPixels(pixels.Pix)
+	g.saveScreenshot(pixels)
+}
+
+// saveScreenshot writes a timestamped PNG in the background, so a slow disk
+// never stalls a frame
+func (g *Game) saveScreenshot(img image.Image) {
+	folder := g.config.current.Screenshots.Folder
+	path := filepath.Join(folder, time.Now().Format("2006-01-02-150405.000")+".png")
+	go func() {
+		if err := writeCapture(folder, path, img); err != nil {
+			slog.Warn("saving screenshot", "path", path, "err", err)
+		}
+	}()
+	size := img.Bounds().Size()
+	g.setStatus(fmt.Sprintf("Screenshot saved to %s (%dx%d)", path, size.X, size.Y))
+	g.logEvent("screenshot saved to %s", path)
+}
+
+// downsample averages each k x k block of img into one pixel
+func downsample(img *image.RGBA, k int) *image.RGBA {
+	if k <= 1 {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()/k, b.Dy()/k))
+	n := uint32(k * k)
+	for y := range out.Rect.Dy() {
+		for x := range out.Rect.Dx() {
+			var sum [4]uint32
+			for sy := range k {
+				i := img.PixOffset(b.Min.X+x*k, b.Min.Y+y*k+sy)
+				for sx := range k {
+					p := img.Pix[i+sx*4 : i+sx*4+4 : i+sx*4+4]
+					sum[0] += uint32(p[0])
+					sum[1] += uint32(p[1])
+					sum[2] += uint32(p[2])
+					sum[3] += uint32(p[3])
+				}
+			}
+			j := out.PixOffset(x, y)
+			for c := range 4 {
+				out.Pix[j+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return out
+}
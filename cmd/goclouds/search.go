@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxSearchLength = 32
+
+// startSearch prompts for an entity to find by name, kind or ID
+func (g *Game) startSearch() {
+	g.startTextEdit("Find (name, kind or #ID)", g.lastSearch, maxSearchLength, func(text string) {
+		g.lastSearch = text
+		g.findNext(text)
+	})
+}
+
+// searchLabel is what a search is matched against for an entity
+func (g *Game) searchLabel(id int) string {
+	if id == sunID {
+		return "Sun"
+	}
+	if t := g.FindTree(id); t != nil {
+		return treeLabel(t)
+	}
+	if p := g.FindProp(id); p != nil {
+		return propLabel(p)
+	}
+	return ""
+}
+
+// searchMatches lists the entities matching a search in Tab order. A number,
+// with or without a #, matches that ID exactly; anything else matches part
+// of a name or kind, ignoring case.
+func (g *Game) searchMatches(query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	wantID, err := strconv.Atoi(strings.TrimPrefix(query, "#"))
+	var ids []int
+	for _, id := range g.focusOrder() {
+		if err == nil {
+			if id == wantID {
+				ids = append(ids, id)
+			}
+		} else if strings.Contains(strings.ToLower(g.searchLabel(id)), query) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// findNext selects the next entity after the selection matching a search
+// and focuses the camera on it, so repeating a search steps through them
+func (g *Game) findNext(query string) {
+	ids := g.searchMatches(query)
+	if len(ids) == 0 {
+		g.setStatus(fmt.Sprintf("Nothing matches '%s'", query))
+		return
+	}
+	i := (slices.Index(ids, g.selectedID) + 1) % len(ids)
+	g.selectedID = ids[i]
+	g.usingKeyboard = true
+	g.focusSelection()
+	g.setStatus(fmt.Sprintf("Found %s (%d of %d, Ctrl+F Enter for the next)", g.searchLabel(ids[i]), i+1, len(ids)))
+}
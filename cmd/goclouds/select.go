@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const minBand = 4.0 // Pixels a band has to be dragged across before it selects, smaller is a click
+
+// Selection is a group of trees and clouds picked by dragging a band around
+// them, moved together by dragging any of them and removed with Delete.
+// Trees are kept by ID; clouds have none, so by index, and the group lets go
+// of them whenever the cloud list changes.
+type Selection struct {
+	trees   []int      // IDs
+	clouds  []int      // Indexes into the clouds
+	world   *sim.World // Scene the cloud indexes are for
+	banding bool       // A band is being dragged out from from to to
+	moving  bool       // The group is being dragged from grab
+	from    point
+	to      point
+	grab    point   // Where the group was taken hold of
+	last    point   // Cursor last frame, as clouds drift and so move by the change
+	starts  []point // Where each tree stood when the group was taken hold of
+}
+
+func (s *Selection) empty() bool {
+	return len(s.trees) == 0 && len(s.clouds) == 0
+}
+
+func (s *Selection) clear() {
+	s.trees, s.clouds = nil, nil
+}
+
+// band is the rectangle dragged out so far
+func (s *Selection) band() rect {
+	return rect{math.Min(s.from.x, s.to.x), math.Min(s.from.y, s.to.y), math.Abs(s.to.x - s.from.x), math.Abs(s.to.y - s.from.y)}
+}
+
+// startBand begins dragging out a band on an empty spot. With Shift held
+// what it takes in joins the group rather than replacing it.
+func (g *Game) startBand(x, y float64) {
+	s := &g.selection
+	if !ebiten.IsKeyPressed(ebiten.KeyShift) {
+		s.clear()
+	}
+	s.banding = true
+	s.from, s.to = point{x, y}, point{x, y}
+}
+
+// endBand selects every tree and shown cloud whose middle is inside the band
+func (g *Game) endBand() {
+	s := &g.selection
+	band := s.band()
+	if band.w < minBand && band.h < minBand {
+		return
+	}
+	for _, t := range g.Trees {
+		if band.contains(t.X, t.Y-t.Size*0.6) && !slices.Contains(s.trees, t.ID) {
+			s.trees = append(s.trees, t.ID)
+		}
+	}
+	if s.world != g.World {
+		s.clouds = nil
+	}
+	s.world = g.World
+	for i, c := range g.ActiveClouds() {
+		if b := g.cloudBounds(c); band.contains(b.x+b.w/2, b.y+b.h/2) && !slices.Contains(s.clouds, i) {
+			s.clouds = append(s.clouds, i)
+		}
+	}
+	// A lone tree is selected as a click would, so it can be renamed
+	if len(s.trees) == 1 && len(s.clouds) == 0 {
+		g.selectedID = s.trees[0]
+	}
+	if !s.empty() {
+		g.setStatus(fmt.Sprintf("Selected %s (drag one to move them all, %s deletes them)", g.selectionLabel(), g.keyName("delete")))
+	}
+}
+
+// selectionLabel counts what is in the group, such as "3 trees, 1 cloud"
+func (g *Game) selectionLabel() string {
+	plural := func(n int, what string) string {
+		if n == 1 {
+			return "1 " + what
+		}
+		return fmt.Sprintf("%d %ss", n, what)
+	}
+	s := &g.selection
+	switch {
+	case len(s.clouds) == 0:
+		return plural(len(s.trees), "tree")
+	case len(s.trees) == 0:
+		return plural(len(s.clouds), "cloud")
+	}
+	return plural(len(s.trees), "tree") + ", " + plural(len(s.clouds), "cloud")
+}
+
+// grabSelection starts moving the group when the press is on one of its
+// members, reporting whether it was
+func (g *Game) grabSelection(x, y float64) bool {
+	s := &g.selection
+	if s.empty() || ebiten.IsKeyPressed(ebiten.KeyShift) || ebiten.IsKeyPressed(ebiten.KeyAlt) {
+		return false
+	}
+	onTree := false
+	if i := g.treeAt(x, y); i != -1 {
+		onTree = slices.Contains(s.trees, g.Trees[i].ID)
+	}
+	if !onTree && !slices.Contains(s.clouds, g.cloudIndexAt(x, y)) {
+		return false
+	}
+	g.FinishTransition()
+	s.moving = true
+	s.grab, s.last = point{x, y}, point{x, y}
+	s.starts = s.starts[:0]
+	for _, id := range s.trees {
+		var start point
+		if t := g.FindTree(id); t != nil {
+			start = point{t.X, t.Y}
+		}
+		s.starts = append(s.starts, start)
+	}
+	return true
+}
+
+// moveSelection drags the group with the pointer, keeping every tree on the
+// ground and leaving locked members where they are
+func (g *Game) moveSelection(x, y float64) {
+	s := &g.selection
+	dx, dy := x-s.grab.x, y-s.grab.y
+	groundY := float64(screenHeight - scene.GroundHeight + scene.GroundOffset)
+	for i, id := range s.trees {
+		if t := g.FindTree(id); t != nil && !t.Locked {
+			dy = math.Max(dy, groundY-s.starts[i].y)
+		}
+	}
+	for i, id := range s.trees {
+		if t := g.FindTree(id); t != nil && !t.Locked {
+			t.X, t.Y = s.starts[i].x+dx, s.starts[i].y+dy
+		}
+	}
+	for _, i := range s.clouds {
+		if i < len(g.Clouds) && !g.Clouds[i].Locked {
+			g.Clouds[i].X += x - s.last.x
+			g.Clouds[i].Y += y - s.last.y
+		}
+	}
+	s.last = point{x, y}
+}
+
+// endMove records the group's move as one undoable step
+func (g *Game) endMove() {
+	s := &g.selection
+	cmd := moveGroupCmd{dx: s.last.x - s.grab.x, dy: s.last.y - s.grab.y}
+	for i, id := range s.trees {
+		if t := g.FindTree(id); t != nil && (point{t.X, t.Y}) != s.starts[i] {
+			cmd.trees = append(cmd.trees, moveTreeCmd{id, s.starts[i], point{t.X, t.Y}})
+		}
+	}
+	for _, i := range s.clouds {
+		if i < len(g.Clouds) && !g.Clouds[i].Locked {
+			cmd.clouds = append(cmd.clouds, i)
+		}
+	}
+	if len(cmd.trees) > 0 || (len(cmd.clouds) > 0 && (cmd.dx != 0 || cmd.dy != 0)) {
+		g.record(cmd)
+	}
+}
+
+// deleteSelection removes the whole group as one undoable step, keeping a
+// tree back if it would take the last
+func (g *Game) deleteSelection() {
+	s := &g.selection
+	if s.empty() {
+		return
+	}
+	status := "Deleted " + g.selectionLabel()
+	from := len(g.history.undo)
+	trees := slices.Clone(s.trees)
+	if len(trees) >= len(g.Trees) {
+		trees = trees[1:]
+		status += ", keeping one tree as a scene needs at least one"
+	}
+	if len(trees) > 0 {
+		g.editTrees(fmt.Sprintf("delete %d trees", len(trees)), max(1, g.TreeCount-len(trees)), func(all []sim.Tree) []sim.Tree {
+			return slices.DeleteFunc(all, func(t sim.Tree) bool { return slices.Contains(trees, t.ID) })
+		})
+	}
+	if clouds := slices.Clone(s.clouds); len(clouds) > 0 {
+		kept := len(g.Clouds) - len(clouds)
+		g.editClouds(fmt.Sprintf("delete %d clouds", len(clouds)), min(g.CloudCount, kept), func(all []sim.Cloud) []sim.Cloud {
+			out := make([]sim.Cloud, 0, kept)
+			for i, c := range all {
+				if !slices.Contains(clouds, i) {
+					out = append(out, c)
+				}
+			}
+			return out
+		})
+	}
+	g.groupHistory(from)
+	s.clear()
+	g.setStatus(status)
+}
+
+// forgetSelection drops trees from the group that no longer exist, and the
+// clouds when the scene has been replaced
+func (g *Game) forgetSelection() {
+	s := &g.selection
+	s.trees = slices.DeleteFunc(s.trees, func(id int) bool { return g.FindTree(id) == nil })
+	if s.world != g.World {
+		s.clouds = nil
+	}
+	s.banding, s.moving = false, false
+}
+
+// moveGroupCmd is a group of trees and clouds dragged together. Clouds
+// drift, so they are moved by the drag rather than to where they were.
+type moveGroupCmd struct {
+	trees  []moveTreeCmd
+	clouds []int
+	dx, dy float64
+}
+
+func (c moveGroupCmd) Do(g *Game)   { c.apply(g, 1) }
+func (c moveGroupCmd) Undo(g *Game) { c.apply(g, -1) }
+
+func (c moveGroupCmd) String() string {
+	return fmt.Sprintf("move %d selected", len(c.trees)+len(c.clouds))
+}
+
+func (c moveGroupCmd) apply(g *Game, dir float64) {
+	for _, t := range c.trees {
+		if dir > 0 {
+			t.Do(g)
+		} else {
+			t.Undo(g)
+		}
+	}
+	for _, i := range c.clouds {
+		if i < len(g.Clouds) {
+			g.Clouds[i].X += c.dx * dir
+			g.Clouds[i].Y += c.dy * dir
+		}
+	}
+}
+
+// cloudBounds is the box around a cloud's lobes
+func (g *Game) cloudBounds(c sim.Cloud) rect {
+	r := c.Size * 0.3
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, l := range g.CloudLobes(c) {
+		minX, maxX = math.Min(minX, c.X+l.DX-r), math.Max(maxX, c.X+l.DX+r)
+		minY, maxY = math.Min(minY, c.Y+l.DY-r), math.Max(maxY, c.Y+l.DY+r)
+	}
+	return rect{minX, minY, maxX - minX, maxY - minY}
+}
+
+// drawGroupSelection outlines each member of the group and the band being
+// dragged out
+func (g *Game) drawGroupSelection(screen *ebiten.Image) {
+	s := &g.selection
+	outline := color.RGBA{120, 200, 255, 230}
+	for _, id := range s.trees {
+		if t := g.FindTree(id); t != nil {
+			drawFrame(screen, rect{t.X - t.Size*0.45, t.Y - t.Size*1.3, t.Size * 0.9, t.Size*1.3 + 3}, outline)
+		}
+	}
+	for _, i := range s.clouds {
+		if i < len(g.Clouds) {
+			b := g.cloudBounds(g.Clouds[i])
+			drawFrame(screen, rect{b.x - 3, b.y - 3, b.w + 6, b.h + 6}, outline)
+		}
+	}
+	if s.banding {
+		b := s.band()
+		drawRect(screen, b.x, b.y, b.w, b.h, color.RGBA{60, 110, 160, 50})
+		drawFrame(screen, b, outline)
+	}
+}
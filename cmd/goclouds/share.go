@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"cloudapp/pkg/scene"
+)
+
+// copyShare puts the current scene on the clipboard as a share string. The
+// string also goes to the event log in case the clipboard is unavailable.
+func (g *Game) copyShare() {
+	text := scene.EncodeShare(g.scene(), rng.Int63())
+	g.logEvent("share string: %s", text)
+	if err := writeClipboard(text); err != nil {
+		g.setStatus("Copy failed, share string is in the event log: " + err.Error())
+		return
+	}
+	g.setStatus(fmt.Sprintf("Scene copied to clipboard (%d characters)", len(text)))
+}
+
+// pasteShare loads a scene from a share string on the clipboard
+func (g *Game) pasteShare() {
+	text, err := readClipboard()
+	if err != nil {
+		g.setStatus("Paste failed: " + err.Error())
+		return
+	}
+	s, err := scene.DecodeShare(text, g.World.Layout)
+	if err != nil {
+		g.setStatus("Paste failed: " + err.Error())
+		return
+	}
+	g.applyScene(s)
+	g.setStatus("Scene pasted from clipboard")
+	g.logEvent("scene pasted from a share string")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/scene"
+)
+
+const numSlots = 9
+
+type point struct{ x, y float64 }
+
+func slotPath(slot int) string {
+	return fmt.Sprintf("goclouds-slot-%d.json", slot)
+}
+
+// updateSlots saves the scene to a slot with Shift+1-9 and loads one with 1-9
+func (g *Game) updateSlots() {
+	for slot := 1; slot <= numSlots; slot++ {
+		if !inpututil.IsKeyJustPressed(ebiten.Key0 + ebiten.Key(slot)) {
+			continue
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			if err := g.saveScene(slotPath(slot)); err != nil {
+				g.setStatus("Save failed: " + err.Error())
+			} else {
+				g.setStatus(fmt.Sprintf("Scene saved to slot %d", slot))
+				g.logEvent("scene saved to slot %d", slot)
+			}
+		} else {
+			if err := g.loadSlot(slot); err != nil {
+				g.setStatus(fmt.Sprintf("Slot %d: %v", slot, err))
+			} else {
+				g.setStatus(fmt.Sprintf("Scene loaded from slot %d", slot))
+				g.logEvent("scene loaded from slot %d", slot)
+			}
+		}
+	}
+}
+
+// loadSlot applies a slot's scene and starts a transition so the sun and any
+// trees present in both arrangements glide into place
+func (g *Game) loadSlot(slot int) error {
+	data, err := os.ReadFile(slotPath(slot))
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.New("slot is empty")
+	}
+	if err != nil {
+		return err
+	}
+	s, err := scene.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	trees := slices.Clone(g.Trees)
+	sunX, sunY := g.SunX, g.SunY
+	g.applyScene(s)
+	g.GlideFrom(sunX, sunY, trees)
+	return nil
+}
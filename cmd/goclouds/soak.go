@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	soakCheckInterval  = time.Minute // Between checks that the scene is still in range
+	soakHealthInterval = time.Hour   // Between health entries in the event log
+	healthInterval     = time.Second // Between refreshes of the readout's memory figures
+)
+
+// Soak is the mode for running unattended around the clock, such as on a
+// kiosk. The scene is checked for out-of-range state every minute, a
+// crashing simulation or renderer is recovered instead of ending the
+// program, and health is logged every hour.
+type Soak struct {
+	enabled    bool
+	started    time.Time
+	nextCheck  time.Time
+	nextHealth time.Time
+	recoveries int // Panics recovered from
+	repairs    int // Problems fixed by the checks
+
+	visible    bool // Health readout shown
+	nextSample time.Time
+	heapMB     float64
+	goroutines int
+}
+
+func (g *Game) startSoak() {
+	now := time.Now()
+	g.soak.enabled = true
+	g.soak.nextCheck = now.Add(soakCheckInterval)
+	g.soak.nextHealth = now.Add(soakHealthInterval)
+	g.logEvent("soak mode on")
+}
+
+// safely runs one part of the game. In soak mode a panic is logged and
+// reset is called to put things right, instead of the program crashing.
+func (g *Game) safely(name string, run, reset func()) {
+	if !g.soak.enabled {
+		run()
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			g.soak.recoveries++
+			slog.Error("recovered from panic", "part", name, "err", err, "stack", string(debug.Stack()))
+			g.logEvent("%s failed and was recovered: %v", name, err)
+			reset()
+		}
+	}()
+	run()
+}
+
+// recoverSimulation goes back to the last snapshot after the simulation
+// panicked, or fixes what it can when there isn't one
+func (g *Game) recoverSimulation() {
+	if _, ok := g.rewind.Back(g.World, 0); !ok {
+		g.World.Sanitize()
+	}
+	g.resetInteraction()
+	g.clearHistory()
+}
+
+// updateSoak runs the periodic checks and health log of soak mode and keeps
+// the health readout's figures fresh
+func (g *Game) updateSoak() {
+	now := time.Now()
+	s := &g.soak
+	if (s.visible || s.enabled) && now.After(s.nextSample) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		s.heapMB = float64(mem.HeapAlloc) / (1 << 20)
+		s.goroutines = runtime.NumGoroutine()
+		s.nextSample = now.Add(healthInterval)
+	}
+	if !s.enabled {
+		return
+	}
+
+	if now.After(s.nextCheck) {
+		s.nextCheck = now.Add(soakCheckInterval)
+		for _, fix := range g.World.Sanitize() {
+			s.repairs++
+			g.logEvent("check: %s", fix)
+		}
+	}
+	if now.After(s.nextHealth) {
+		s.nextHealth = now.Add(soakHealthInterval)
+		g.logEvent("health: %s", g.health())
+	}
+}
+
+// health sums up how the program is holding up
+func (g *Game) health() string {
+	s := &g.soak
+	return fmt.Sprintf("up %s, %.0f TPS, %.0f FPS, %.1f MB heap, %d goroutines, %d recoveries, %d repairs",
+		time.Since(s.started).Round(time.Second), ebiten.ActualTPS(), ebiten.ActualFPS(),
+		s.heapMB, s.goroutines, s.recoveries, s.repairs)
+}
+
+// drawHealth shows the health readout in the top right corner
+func (g *Game) drawHealth(screen *ebiten.Image) {
+	if !g.soak.visible {
+		return
+	}
+	text := g.health()
+	width := float64(len(text)*6 + 8)
+	x := float64(screenWidth) - width - 10
+	drawRect(screen, x, 10, width, 18, color.RGBA{0, 0, 0, 160})
+	ebitenutil.DebugPrintAt(screen, text, int(x)+4, 12)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"math"
+)
+
+// wishOn makes a wish if the point is on the current shooting star,
+// reporting whether it was
+func (g *Game) wishOn(x, y float64) bool {
+	star := &g.ShootingStar
+	if !star.Active || math.Hypot(x-star.X, y-star.Y) > 20 {
+		return false
+	}
+	star.Active = false
+	g.wishes++
+	g.logEvent("wish #%d made on a shooting star", g.wishes)
+	return true
+}
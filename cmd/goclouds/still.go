@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// stillFlags are the flags `goclouds render` and `goclouds timelapse` share,
+// picking the scene file and how it is drawn
+type stillFlags struct {
+	scene              *string
+	width, height      *int
+	seed               *int64
+	scale, supersample *int
+}
+
+// stillConfig loads the palettes and config as the app would and adds the
+// shared flags to fs, defaulting to them
+func stillConfig(fs *flag.FlagSet) (Config, stillFlags) {
+	setupLogging(false)
+	if err := loadPalettes(paletteFile); err != nil {
+		slog.Warn("loading palettes", "path", paletteFile, "err", err)
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		slog.Warn("loading config", "path", configFile, "err", err)
+	}
+	return cfg, stillFlags{
+		scene:       fs.String("scene", sceneFile, "scene file to draw"),
+		width:       fs.Int("width", 0, "width the scene is laid out at, the config's if 0"),
+		height:      fs.Int("height", 0, "height the scene is laid out at, the config's if 0"),
+		seed:        fs.Int64("seed", 1, "random seed for birds and other details the scene doesn't store"),
+		scale:       fs.Int("scale", cfg.Screenshots.Scale, "times the scene's size to draw the picture at"),
+		supersample: fs.Int("supersample", cfg.Screenshots.Supersample, "samples per pixel along each side, to smooth edges"),
+	}
+}
+
+// parse reads args into fs and the shared flags over cfg, returning the
+// exit code to stop with if they can't be used
+func (f stillFlags) parse(fs *flag.FlagSet, args []string, cfg *Config) (code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
+	}
+	if *f.width > 0 {
+		cfg.Width = *f.width
+	}
+	if *f.height > 0 {
+		cfg.Height = *f.height
+	}
+	cfg.Seed = *f.seed
+	cfg.Screenshots.Scale, cfg.Screenshots.Supersample = *f.scale, *f.supersample
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Name(), err)
+		return 2, false
+	}
+	screenWidth, screenHeight = cfg.Width, cfg.Height
+	return 0, true
+}
+
+// renderCommand is `goclouds render`, which draws a still of a scene file at
+// a time of day in software and writes it as a PNG without opening a
+// window, for scripts and wiki pages. It returns the exit code.
+func renderCommand(args []string) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	cfg, shared := stillConfig(fs)
+	at := fs.String("t", "", "time of day as HH:MM between 06:00 and 18:00, such as 14:30; the scene's own sun if empty")
+	out := fs.String("o", "render.png", "PNG file to write")
+	if code, ok := shared.parse(fs, args, &cfg); !ok {
+		return code
+	}
+
+	w, err := loadStill(cfg, *shared.scene)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "render:", err)
+		return 1
+	}
+	if *at != "" {
+		t, err := parseClock(*at)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "render:", err)
+			return 2
+		}
+		w.SetTimeOfDay(t)
+	}
+
+	started := time.Now()
+	img := drawStill(render.New(), w, cfg.Screenshots)
+	if err := writeCapture(filepath.Dir(*out), *out, img); err != nil {
+		fmt.Fprintln(os.Stderr, "render:", err)
+		return 1
+	}
+	size := img.Bounds().Size()
+	slog.Info("rendered", "scene", *shared.scene, "path", *out, "size", fmt.Sprintf("%dx%d", size.X, size.Y), "took", time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+// timelapseCommand is `goclouds timelapse`, which runs a scene file across
+// the day in software, writing a numbered PNG every so many simulated
+// minutes to assemble into a time-lapse. It returns the exit code.
+func timelapseCommand(args []string) int {
+	fs := flag.NewFlagSet("timelapse", flag.ContinueOnError)
+	cfg, shared := stillConfig(fs)
+	from := fs.String("from", clockTime(0), "time of day of the first frame, as HH:MM")
+	to := fs.String("to", clockTime(1), "time of day of the last frame, as HH:MM")
+	every := fs.Duration("every", time.Minute, "simulated time of day between frames, such as 1m or 30s")
+	drift := fs.Float64("drift", 2, "seconds the weather runs for between frames, so clouds cross the sky; 0 holds it still")
+	out := fs.String("o", "timelapse", "folder to write frame-00000.png and on into")
+	if code, ok := shared.parse(fs, args, &cfg); !ok {
+		return code
+	}
+	start, err := parseClock(*from)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "timelapse:", err)
+		return 2
+	}
+	end, err := parseClock(*to)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "timelapse:", err)
+		return 2
+	}
+	if end < start || *every <= 0 || *drift < 0 {
+		fmt.Fprintf(os.Stderr, "timelapse: no frames from %s to %s every %s with %gs drift\n", *from, *to, *every, *drift)
+		return 2
+	}
+
+	w, err := loadStill(cfg, *shared.scene)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "timelapse:", err)
+		return 1
+	}
+	step := every.Hours() / (sunset - sunrise) // Time of day between frames
+	frames := int(math.Floor((end-start)/step+1e-9)) + 1
+	steps := int(math.Round(*drift / sim.FixedStep))
+	painter := render.New()
+	started := time.Now()
+	for i := range frames {
+		for range steps {
+			w.Step(sim.FixedStep)
+		}
+		t := start + float64(i)*step
+		w.SetTimeOfDay(t)
+		path := filepath.Join(*out, fmt.Sprintf("frame-%05d.png", i))
+		if err := writeCapture(*out, path, drawStill(painter, w, cfg.Screenshots)); err != nil {
+			fmt.Fprintln(os.Stderr, "timelapse:", err)
+			return 1
+		}
+		if (i+1)%60 == 0 {
+			slog.Info("time-lapse", "frames", i+1, "of", frames, "at", clockTime(t))
+		}
+	}
+	slog.Info("wrote time-lapse", "scene", *shared.scene, "folder", *out, "frames", frames, "took", time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+// loadStill builds a world at the configured size holding the scene in a file
+func loadStill(cfg Config, path string) (*sim.World, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := scene.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	w := sim.New(worldOptions(cfg, rand.New(rand.NewSource(cfg.Seed))))
+	w.Apply(s)
+	return w, nil
+}
+
+// drawStill draws the whole world in software, scaled and supersampled as
+// screenshots are. Frames of one world share a painter, which caches shapes.
+func drawStill(painter *render.Painter, w *sim.World, shots Screenshots) *image.RGBA {
+	k := shots.Scale * shots.Supersample
+	frame := render.NewRaster(w.Width*k, w.Height*k, w.Width, w.Height)
+	painter.Draw(frame, w)
+	return downsample(frame.Image, shots.Supersample)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	sunrise = 6.0  // Hour the sun rises at, time of day 0
+	sunset  = 18.0 // Hour it sets at, time of day 1
+)
+
+// snappingSun reports whether the dragged sun keeps to its arc: in snap
+// mode unless Shift is held for free placement
+func (g *Game) snappingSun() bool {
+	return g.sunSnap && !ebiten.IsKeyPressed(ebiten.KeyShift)
+}
+
+// snapSun puts the sun on its arc at the time of day where it crosses x,
+// so a mood directing the scene carries on from the time it was dragged to
+func (g *Game) snapSun(x float64) {
+	g.SetTimeOfDay((x - sim.SunRadius) / (float64(g.Width) - 2*sim.SunRadius))
+}
+
+// clockTime is a time of day as the hour on a clock, such as "14:30"
+func clockTime(timeOfDay float64) string {
+	minutes := int(math.Round((sunrise + timeOfDay*(sunset-sunrise)) * 60))
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// parseClock is the time of day at an hour on a clock such as "14:30", the
+// reverse of clockTime. The sun is only up from sunrise to sunset.
+func parseClock(s string) (float64, error) {
+	var hour, minute int
+	if n, err := fmt.Sscanf(s, "%d:%d", &hour, &minute); n != 2 || err != nil || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("time %q is not HH:MM", s)
+	}
+	hours := float64(hour) + float64(minute)/60
+	if hours < sunrise || hours > sunset {
+		return 0, fmt.Errorf("time %s is outside the day, the sun is up from %s to %s", s, clockTime(0), clockTime(1))
+	}
+	return (hours - sunrise) / (sunset - sunrise), nil
+}
+
+// drawSunArc dots the path the sun takes across the day and labels the sun
+// with the time it stands at
+func (g *Game) drawSunArc(screen *ebiten.Image) {
+	dot := color.RGBA{255, 240, 180, 120}
+	for i := 0; i <= 40; i++ {
+		x, y := g.SunAt(float64(i) / 40)
+		drawCircle(screen, x, y, 1.5, dot)
+	}
+	label := clockTime(g.TimeOfDay())
+	x, y := g.SunX-float64(len(label)*6+8)/2, g.SunY-sim.SunRadius-26
+	drawRect(screen, x, y, float64(len(label)*6+8), 18, color.RGBA{0, 0, 0, 160})
+	ebitenutil.DebugPrintAt(screen, label, int(x)+4, int(y)+1)
+}
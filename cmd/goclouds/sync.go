@@ -0,0 +1,290 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"time"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	defaultSyncPort   = 47474
+	syncBeaconEvery   = 500 * time.Millisecond // Between beacons from each display
+	syncPeerTimeout   = 3 * time.Second        // Silence after which a display is taken to have left
+	syncJump          = 1.0                    // Seconds off the leader past which a follower fast-forwards or waits rather than slewing
+	syncSlew          = 0.5                    // Share of a small gap to the leader made up each second
+	syncMaxSlew       = 0.2                    // Most a follower's clock runs fast or slow while slewing
+	syncCatchUpBudget = 10 * time.Millisecond  // Time a frame spends fast-forwarding
+)
+
+// syncScene is everything a synced scene is built from. Displays in a group
+// that build from the same one and take the same number of fixed steps show
+// the same sky, so only this and a step count cross the network.
+type syncScene struct {
+	Seed            int64         `json:"seed"`
+	Width           int           `json:"width"`
+	Height          int           `json:"height"`
+	WorldWidth      float64       `json:"worldWidth"`
+	CloudCount      int           `json:"cloudCount"`
+	TreeCount       int           `json:"treeCount"`
+	Density         float64       `json:"density"`
+	Palette         string        `json:"palette"`
+	Wind            float64       `json:"wind"`
+	ReducedMotion   bool          `json:"reducedMotion"`
+	ScarecrowRadius float64       `json:"scarecrowRadius"`
+	Margins         scene.Margins `json:"margins"`
+	Mood            string        `json:"mood"`
+}
+
+// syncBeacon is what each display broadcasts twice a second
+type syncBeacon struct {
+	Group   string    `json:"group"`
+	ID      uint64    `json:"id"`
+	Started int64     `json:"started"` // Unix nanoseconds, the display running longest leads
+	Step    int64     `json:"step"`    // Fixed steps the scene has taken
+	Scene   syncScene `json:"scene"`
+}
+
+// before reports whether b's display has been running longer than o's,
+// and so has the better claim to lead
+func (b syncBeacon) before(o syncBeacon) bool {
+	if b.Started != o.Started {
+		return b.Started < o.Started
+	}
+	return b.ID < o.ID
+}
+
+// syncPeer is another display in the group as last heard from
+type syncPeer struct {
+	beacon syncBeacon
+	heard  time.Time
+}
+
+// Sync keeps the scenes of several displays on a network in lockstep, for
+// a wall of screens showing one sky. Every display in a group broadcasts a
+// beacon, and the one running longest leads: the others build their scene
+// from its seed and options and speed their clocks up or slow them down to
+// match its step count. Scene edits aren't shared, only the seed and clock.
+type Sync struct {
+	enabled  bool
+	conn     *net.UDPConn
+	to       *net.UDPAddr
+	self     syncBeacon
+	received chan syncPeer
+	peers    map[uint64]syncPeer
+	leader   uint64 // ID of the display being followed, self.ID while leading
+	next     time.Time
+	behind   bool // Fast-forwarding to catch up with the leader
+}
+
+// syncID picks an ID for this display from the system's randomness, not
+// rng, which a config shared by a wall of displays seeds the same on each
+func syncID() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return uint64(time.Now().UnixNano()) ^ uint64(os.Getpid())<<32
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// startSync joins the sync group named in the config, building the scene
+// afresh from the seed so a display joining later can reproduce it. Without
+// a network the display carries on alone.
+func (g *Game) startSync(cfg Config) {
+	s := &g.sync
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rng.Int63()
+	}
+	s.self = syncBeacon{
+		Group:   cfg.Sync,
+		ID:      syncID(),
+		Started: time.Now().UnixNano(),
+		Scene: syncScene{
+			Seed:            seed,
+			Width:           screenWidth,
+			Height:          screenHeight,
+			WorldWidth:      cfg.WorldWidth,
+			CloudCount:      cfg.CloudCount,
+			TreeCount:       cfg.TreeCount,
+			Density:         cfg.Density,
+			Palette:         cfg.Palette,
+			Wind:            cfg.Wind,
+			ReducedMotion:   cfg.ReducedMotion,
+			ScarecrowRadius: cfg.ScarecrowRadius,
+			Margins:         cfg.Margins,
+			Mood:            cfg.Mood,
+		},
+	}
+	s.leader = s.self.ID
+	s.peers = map[uint64]syncPeer{}
+	g.buildSyncScene(s.self.Scene)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: cfg.SyncPort})
+	if err != nil {
+		slog.Warn("joining sync group", "group", cfg.Sync, "port", cfg.SyncPort, "err", err)
+		g.setStatus("Sync unavailable, running alone: " + err.Error())
+		return
+	}
+	s.enabled = true
+	s.conn = conn
+	s.to = &net.UDPAddr{IP: net.IPv4bcast, Port: cfg.SyncPort}
+	s.received = make(chan syncPeer, 64)
+	go listenSync(conn, s.self, s.received)
+	g.logEvent("joined sync group %q on port %d", cfg.Sync, cfg.SyncPort)
+}
+
+// listenSync passes on beacons from the rest of the group until the
+// connection closes
+func listenSync(conn *net.UDPConn, self syncBeacon, received chan<- syncPeer) {
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			slog.Warn("reading sync beacon", "err", err)
+			time.Sleep(syncBeaconEvery)
+			continue
+		}
+		var b syncBeacon
+		if json.Unmarshal(buf[:n], &b) != nil || b.Group != self.Group || b.ID == self.ID {
+			continue
+		}
+		// Dropped rather than waited on while the game is busy, as another
+		// follows soon
+		select {
+		case received <- syncPeer{b, time.Now()}:
+		default:
+		}
+	}
+}
+
+// buildSyncScene replaces the scene in front with a fresh one built from sc
+func (g *Game) buildSyncScene(sc syncScene) {
+	if sc.Width != screenWidth || sc.Height != screenHeight {
+		slog.Warn("sync leader's scene is a different size", "leader", fmt.Sprintf("%dx%d", sc.Width, sc.Height), "this", fmt.Sprintf("%dx%d", screenWidth, screenHeight))
+		g.setStatus(fmt.Sprintf("The leader is %dx%d, so this %dx%d display won't match it", sc.Width, sc.Height, screenWidth, screenHeight))
+	}
+	cfg := g.config.current
+	cfg.CloudCount, cfg.TreeCount, cfg.Density, cfg.Palette, cfg.Wind = sc.CloudCount, sc.TreeCount, sc.Density, sc.Palette, sc.Wind
+	cfg.ReducedMotion, cfg.ScarecrowRadius, cfg.Margins, cfg.Mood = sc.ReducedMotion, sc.ScarecrowRadius, sc.Margins, sc.Mood
+	cfg.WorldWidth = sc.WorldWidth
+	g.World = g.newWorld(cfg, rand.New(rand.NewSource(sc.Seed)))
+	g.clock = sim.Clock{}
+	g.rewind = sim.Rewind{}
+	g.notes = nil
+	g.clearHistory()
+	g.resetInteraction()
+	g.resetCamera()
+}
+
+// stepCount is how many fixed steps the scene in front has taken
+func (g *Game) stepCount() int64 {
+	return int64(math.Round(g.SimTime / sim.FixedStep))
+}
+
+// updateSync hears from the rest of the group, picks the leader, follows
+// its scene and sends this display's beacon when one is due
+func (g *Game) updateSync() {
+	s := &g.sync
+	if !s.enabled {
+		return
+	}
+	now := time.Now()
+	for drained := false; !drained; {
+		select {
+		case p := <-s.received:
+			if _, known := s.peers[p.beacon.ID]; !known {
+				g.logEvent("sync display %x joined", p.beacon.ID)
+			}
+			s.peers[p.beacon.ID] = p
+		default:
+			drained = true
+		}
+	}
+
+	leader := s.self
+	for id, p := range s.peers {
+		if now.Sub(p.heard) > syncPeerTimeout {
+			delete(s.peers, id)
+			g.logEvent("sync display %x left", id)
+			continue
+		}
+		if p.beacon.before(leader) {
+			leader = p.beacon
+		}
+	}
+	if leader.ID != s.leader {
+		s.leader = leader.ID
+		if leader.ID == s.self.ID {
+			g.setStatus("Leading the sync group")
+			g.logEvent("leading sync group %q", s.self.Group)
+		} else {
+			g.setStatus(fmt.Sprintf("Following display %x", leader.ID))
+			g.logEvent("following sync display %x", leader.ID)
+		}
+	}
+	if leader.ID != s.self.ID && leader.Scene != s.self.Scene {
+		s.self.Scene = leader.Scene
+		g.buildSyncScene(leader.Scene)
+	}
+
+	if now.Before(s.next) {
+		return
+	}
+	s.next = now.Add(syncBeaconEvery)
+	s.self.Step = g.stepCount()
+	data, err := json.Marshal(s.self)
+	if err == nil {
+		_, err = s.conn.WriteToUDP(data, s.to)
+	}
+	if err != nil {
+		slog.Debug("sending sync beacon", "err", err)
+	}
+}
+
+// syncElapsed is how far to run the clock this frame: dt while leading or
+// alone, a little more or less while following to close a small gap to the
+// leader, and nothing while well ahead of it. Well behind, it fast-forwards
+// as far as a frame allows first.
+func (g *Game) syncElapsed(dt float64) float64 {
+	s := &g.sync
+	leader, ok := s.peers[s.leader]
+	if !s.enabled || !ok {
+		return dt
+	}
+	target := float64(leader.beacon.Step) + time.Since(leader.heard).Seconds()/sim.FixedStep
+	gap := (target - float64(g.stepCount())) * sim.FixedStep
+	switch {
+	case gap > syncJump:
+		if !s.behind {
+			s.behind = true
+			g.logEvent("catching up %.0f seconds with the sync leader", gap)
+		}
+		start := time.Now()
+		for ; gap > syncJump && time.Since(start) < syncCatchUpBudget; gap -= 1 {
+			g.clock.Advance(g.World, 1)
+		}
+		g.setStatus(fmt.Sprintf("Catching up with the leader, %s behind", time.Duration(gap*float64(time.Second)).Round(time.Second)))
+		return dt
+	case gap < -syncJump:
+		return 0
+	}
+	if s.behind {
+		s.behind = false
+		g.setStatus(fmt.Sprintf("In step with display %x", s.leader))
+	}
+	return dt * (1 + math.Max(-syncMaxSlew, math.Min(syncMaxSlew, gap*syncSlew)))
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	tabWidth  = 90 // Width of each tab in the strip
+	tabHeight = 18
+)
+
+// Tab is one of the scenes open in the window, each with its own seed,
+// weather and settings. The scene in front lives in the Game's own fields
+// and is only stored back in its Tab when another is switched to. Scenes in
+// the back are paused.
+type Tab struct {
+	world     *sim.World
+	scenePath string
+	notes     []Note
+	history   History
+	rewind    sim.Rewind
+	camera    Camera
+	renderer  *render.Painter
+}
+
+// Tabs are the scenes open in the window, shown as a strip once there is
+// more than one
+type Tabs struct {
+	open   []Tab
+	active int // Index of the scene in front, whose Tab is out of date
+}
+
+// newWorld makes a scene from the config's options, drawing its randomness
+// from r
+func (g *Game) newWorld(cfg Config, r *rand.Rand) *sim.World {
+	w := sim.New(worldOptions(cfg, r))
+	w.OnEvent = func(text string) { g.logEvent("%s", text) }
+	if m, ok := sim.FindMood(cfg.Mood); ok {
+		w.Direct(m)
+	}
+	return w
+}
+
+// worldOptions are the simulation options a scene is made with, as tall as
+// the window and worldWidth windows wide
+func worldOptions(cfg Config, r *rand.Rand) sim.Options {
+	return sim.Options{
+		Width:           int(math.Round(float64(screenWidth) * cfg.WorldWidth)),
+		Height:          screenHeight,
+		CloudCount:      cfg.CloudCount,
+		TreeCount:       cfg.TreeCount,
+		Density:         cfg.Density,
+		Palette:         cfg.Palette,
+		Wind:            cfg.Wind,
+		ReducedMotion:   cfg.ReducedMotion,
+		ScarecrowRadius: cfg.ScarecrowRadius,
+		Margins:         cfg.Margins,
+		Rand:            r,
+	}
+}
+
+// stashTab stores the scene in front back into its tab
+func (g *Game) stashTab() {
+	t := &g.tabs.open[g.tabs.active]
+	t.world = g.World
+	t.scenePath = g.scenePath
+	t.notes = g.notes
+	t.history = g.history
+	t.rewind = g.rewind
+	t.camera = g.camera
+	t.renderer = g.renderer
+}
+
+// showTab brings a tab's scene to the front, dropping anything in progress
+// in the old one
+func (g *Game) showTab(i int) {
+	g.tabs.active = i
+	t := g.tabs.open[i]
+	g.World = t.world
+	// Scenes made by the wizard can differ in size, and the window follows
+	// as it does when the wizard makes one
+	size := [2]int{int(math.Round(float64(g.Width) / g.config.current.WorldWidth)), g.Height}
+	if size != [2]int{screenWidth, screenHeight} {
+		screenWidth, screenHeight = size[0], size[1]
+		ebiten.SetWindowSize(screenWidth, screenHeight)
+	}
+	g.scenePath = t.scenePath
+	g.notes = t.notes
+	g.history = t.history
+	g.rewind = t.rewind
+	g.camera = t.camera
+	g.renderer = t.renderer
+	g.renderer.Economy = g.throttle.active
+	g.renderer.LowEnd = g.lowEnd.active
+	g.clock = sim.Clock{}
+
+	g.isDraggingSun = false
+	g.draggedTree = -1
+	g.draggedProp = -1
+	g.selectedID = 0
+	g.menu.selectedCloud = -1
+	g.textEdit.active = false
+}
+
+// openTab starts a new scene with a fresh seed in a tab of its own and
+// brings it to the front
+func (g *Game) openTab() {
+	g.stashTab()
+	seed := rng.Int63()
+	t := Tab{
+		world:     g.newWorld(g.config.current, rand.New(rand.NewSource(seed))),
+		scenePath: sceneFile,
+		renderer:  render.New(),
+		camera:    Camera{lastClick: math.Inf(-1)},
+	}
+	g.tabs.open = append(g.tabs.open, t)
+	g.showTab(len(g.tabs.open) - 1)
+	g.resetCamera()
+	g.setStatus(fmt.Sprintf("Opened scene %d, seed %d", len(g.tabs.open), seed))
+	g.logEvent("scene %d opened with seed %d", len(g.tabs.open), seed)
+}
+
+// closeTab closes the scene in front and shows the one beside it. The last
+// scene can't be closed, ESC exits instead.
+func (g *Game) closeTab() {
+	if len(g.tabs.open) == 1 {
+		g.setStatus("This is the only scene open")
+		return
+	}
+	closed := g.tabs.active
+	g.tabs.open = append(g.tabs.open[:closed], g.tabs.open[closed+1:]...)
+	g.showTab(min(closed, len(g.tabs.open)-1))
+	g.logEvent("scene %d closed", closed+1)
+}
+
+// switchTab brings the scene step tabs along to the front, wrapping around
+func (g *Game) switchTab(step int) {
+	n := len(g.tabs.open)
+	if n == 1 {
+		return
+	}
+	g.stashTab()
+	g.showTab(((g.tabs.active+step)%n + n) % n)
+}
+
+// updateTabs opens a scene with Ctrl+T, closes it with Ctrl+W, cycles with
+// Ctrl+Tab and Ctrl+Shift+Tab and switches on a click on the strip. It
+// reports whether the click was taken.
+func (g *Game) updateTabs(ctrl bool) bool {
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		g.openTab()
+	}
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyW) {
+		g.closeTab()
+	}
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.switchTab(-1)
+		} else {
+			g.switchTab(1)
+		}
+	}
+
+	if !g.pointerPressed() {
+		return false
+	}
+	x, y := g.pointerPosition()
+	i := g.tabAt(x, y)
+	if i == -1 {
+		return false
+	}
+	if i != g.tabs.active {
+		g.stashTab()
+		g.showTab(i)
+	}
+	return true
+}
+
+// tabRect is where tab i sits in the strip along the bottom right
+func (g *Game) tabRect(i int) (x, y int) {
+	n := len(g.tabs.open)
+	return screenWidth - 10 - (n-i)*tabWidth, screenHeight - tabHeight - 6
+}
+
+// tabAt returns the tab under a point on the screen, or -1
+func (g *Game) tabAt(px, py int) int {
+	if len(g.tabs.open) < 2 {
+		return -1
+	}
+	for i := range g.tabs.open {
+		x, y := g.tabRect(i)
+		if px >= x && px < x+tabWidth-2 && py >= y && py < y+tabHeight {
+			return i
+		}
+	}
+	return -1
+}
+
+// tabLabel names a tab after its scene file, or its number for the default
+func (g *Game) tabLabel(i int) string {
+	path := g.tabs.open[i].scenePath
+	if i == g.tabs.active {
+		path = g.scenePath
+	}
+	if path == sceneFile {
+		return fmt.Sprintf("Scene %d", i+1)
+	}
+	return fmt.Sprintf("%d %s", i+1, filepath.Base(path))
+}
+
+// drawTabs draws the strip of open scenes, once there is more than one
+func (g *Game) drawTabs(screen *ebiten.Image) {
+	if len(g.tabs.open) < 2 {
+		return
+	}
+	for i := range g.tabs.open {
+		x, y := g.tabRect(i)
+		bg := color.RGBA{0, 0, 0, 140}
+		if i == g.tabs.active {
+			bg = color.RGBA{255, 255, 255, 90}
+		}
+		drawRect(screen, float64(x), float64(y), tabWidth-2, tabHeight, bg)
+		label := g.tabLabel(i)
+		if maxChars := (tabWidth - 10) / 6; len(label) > maxChars {
+			label = label[:maxChars]
+		}
+		ebitenutil.DebugPrintAt(screen, label, x+4, y+1)
+	}
+}
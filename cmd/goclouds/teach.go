@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// drawShadowLesson draws the geometric construction of the selected tree's
+// shadow for classroom demonstrations. The scene is treated as a side view:
+// the ground is level with the tree's base, and the ray from the sun past the
+// top of the crown lands where the shadow ends.
+func (g *Game) drawShadowLesson(screen *ebiten.Image) {
+	if !g.teaching {
+		return
+	}
+
+	lines := []string{"=== Shadow Lesson (E to close) ==="}
+	t := g.FindTree(g.selectedID)
+	if t == nil {
+		lines = append(lines, "Click a tree to see how its", "shadow is constructed")
+		g.drawLessonPanel(screen, lines)
+		return
+	}
+
+	base := point{t.X, t.Y}
+	top := point{t.X, t.Y - t.Height()}
+	sun := point{g.SunX, g.SunY}
+	foot := point{sun.x, base.y} // Straight below the sun on the tree's ground line
+
+	sunHeight := base.y - sun.y
+	sunDistance := math.Abs(sun.x - base.x)
+	// Angle the ray grazing the crown makes with the ground
+	elevation := math.Atan2(sunHeight-t.Height(), sunDistance)
+
+	rayColor := color.RGBA{255, 220, 0, 220}
+	bigColor := color.RGBA{255, 140, 0, 200}
+	smallColor := color.RGBA{0, 220, 255, 230}
+
+	// Ground line the construction is measured along
+	drawLine(screen, 0, base.y, float64(g.Width), base.y, color.RGBA{255, 255, 255, 90})
+
+	lines = append(lines,
+		treeLabel(t),
+		fmt.Sprintf("Tree height h:    %6.1f px", t.Height()),
+		fmt.Sprintf("Sun height H:     %6.1f px", sunHeight),
+		fmt.Sprintf("Sun distance D:   %6.1f px", sunDistance),
+		fmt.Sprintf("Ray angle a:      %6.1f deg", elevation*180/math.Pi),
+	)
+
+	if sun.y >= top.y {
+		// The ray past the crown never comes down to the ground
+		drawLine(screen, sun.x, sun.y, top.x, top.y, rayColor)
+		lines = append(lines, "Sun is level with or below the", "top of the tree: no shadow tip")
+		g.drawLessonPanel(screen, lines)
+		return
+	}
+
+	// Extend the ray from the sun past the crown until it meets the ground
+	s := (base.y - sun.y) / (top.y - sun.y)
+	tip := point{sun.x + (top.x-sun.x)*s, base.y}
+	shadowLength := math.Abs(tip.x - base.x)
+
+	// Large triangle: sun, point below it, shadow tip
+	drawLine(screen, sun.x, sun.y, foot.x, foot.y, bigColor)
+	drawLine(screen, foot.x, foot.y, tip.x, tip.y, bigColor)
+	drawLine(screen, sun.x, sun.y, tip.x, tip.y, rayColor)
+
+	// Small triangle: crown, trunk base, shadow tip
+	drawLine(screen, top.x, top.y, base.x, base.y, smallColor)
+	drawLine(screen, base.x, base.y, tip.x, tip.y, smallColor)
+	drawCircle(screen, tip.x, tip.y, 3, smallColor)
+
+	ebitenutil.DebugPrintAt(screen, "h", int(base.x)+4, int((base.y+top.y)/2))
+	ebitenutil.DebugPrintAt(screen, "L", int((base.x+tip.x)/2), int(base.y)+2)
+	ebitenutil.DebugPrintAt(screen, "H", int(foot.x)+4, int((foot.y+sun.y)/2))
+
+	modelLength, _ := g.ShadowGeometry(t)
+	lines = append(lines,
+		"Similar triangles: h / L = H / (D + L)",
+		"  so L = h / tan(a)",
+		fmt.Sprintf("Shadow length L:  %6.1f px", shadowLength),
+		fmt.Sprintf("Drawn shadow:     %6.1f px", modelLength*0.8),
+	)
+	g.drawLessonPanel(screen, lines)
+}
+
+func (g *Game) drawLessonPanel(screen *ebiten.Image, lines []string) {
+	height := float64(len(lines)*16 + 10)
+	y := float64(screenHeight) - height - 30
+	drawRect(screen, 10, y, 250, height, color.RGBA{0, 0, 0, 180})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, 16, int(y)+4+i*16)
+	}
+}
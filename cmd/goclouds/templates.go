@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cloudapp/pkg/scene"
+)
+
+// applyTemplate regenerates the scene from the template selected in the menu
+func (g *Game) applyTemplate() {
+	t := scene.Templates[g.menu.template]
+	g.applyScene(t.Generate(g.scene(), g.menu.seed, g.World.Layout))
+	g.logEvent("generated %s template with seed %d", t.Name, g.menu.seed)
+	g.setStatus(fmt.Sprintf("Generated %s (seed %d)", t.Name, g.menu.seed))
+}
+
+// editSeed prompts for the seed used by the next template
+func (g *Game) editSeed() {
+	g.startTextEdit("Seed", strconv.FormatInt(g.menu.seed, 10), 18, func(text string) {
+		seed, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			g.setStatus("Seed must be a whole number")
+			return
+		}
+		g.menu.seed = seed
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// TextEdit is a single-line text prompt. While it is active typed keys go
+// to the text instead of triggering shortcuts.
+type TextEdit struct {
+	active bool
+	prompt string
+	text   []rune
+	limit  int
+	commit func(text string)
+}
+
+// startTextEdit opens the prompt with some initial text; commit is called
+// with the result when Enter is pressed
+func (g *Game) startTextEdit(prompt, initial string, limit int, commit func(string)) {
+	g.textEdit = TextEdit{
+		active: true,
+		prompt: prompt,
+		text:   []rune(initial),
+		limit:  limit,
+		commit: commit,
+	}
+}
+
+// updateTextEdit collects typed characters. Enter confirms, Escape (handled
+// in Update) cancels.
+func (g *Game) updateTextEdit() {
+	te := &g.textEdit
+	te.text = ebiten.AppendInputChars(te.text)
+	if len(te.text) > te.limit {
+		te.text = te.text[:te.limit]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(te.text) > 0 {
+		te.text = te.text[:len(te.text)-1]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		te.active = false
+		te.commit(string(te.text))
+	}
+}
+
+func (g *Game) drawTextEdit(screen *ebiten.Image) {
+	te := g.textEdit
+	if !te.active {
+		return
+	}
+	line := te.prompt + ": " + string(te.text) + "_"
+	width := float64(max(200, len(line)*6+16))
+	x := (float64(screenWidth) - width) / 2
+	y := float64(screenHeight) - 60
+	drawRect(screen, x, y, width, 24, color.RGBA{0, 0, 0, 200})
+	ebitenutil.DebugPrintAt(screen, line, int(x)+8, int(y)+5)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Throttle slows the app right down while it is in the background, so an
+// ambient scene left running doesn't drain a laptop's battery
+type Throttle struct {
+	active bool // The window is unfocused or minimized and the app is slowed
+	drawn  bool // The screen already shows the latest update
+}
+
+// updateThrottle drops to the configured background rate when the window
+// loses focus or is minimized, and back to full speed as soon as it returns.
+// Ebiten can't tell when a window is hidden behind others, so that still
+// runs at full speed.
+func (g *Game) updateThrottle() {
+	g.throttle.drawn = false
+	rate := g.config.current.BackgroundFPS
+	background := rate > 0 && (!ebiten.IsFocused() || ebiten.IsWindowMinimized())
+	if background == g.throttle.active {
+		return
+	}
+	g.throttle.active = background
+	g.renderer.Economy = background
+	if background {
+		ebiten.SetTPS(rate)
+	} else {
+		ebiten.SetTPS(g.foregroundTPS())
+	}
+}
+
+// skipDraw reports whether the frame can be skipped in the background,
+// leaving the last one on screen because nothing has changed since. In the
+// foreground every frame is drawn, as clouds blend on between updates, and
+// benchmarks draw every frame too.
+func (g *Game) skipDraw() bool {
+	if g.throttle.drawn && g.throttle.active && g.bench == nil {
+		return true
+	}
+	g.throttle.drawn = true
+	return false
+}
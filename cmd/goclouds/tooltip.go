@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+
+	"cloudapp/pkg/sim"
+)
+
+const tooltipDelay = 0.5 // Seconds the pointer rests on something before its help shows
+
+// Tooltip is the help for whatever the pointer rests on, a menu control or
+// something in the scene
+type Tooltip struct {
+	hidden bool    // Turned off on the menu's Display page
+	text   string  // Help for what is under the pointer, empty for nothing
+	rested float64 // Seconds the pointer has been over it
+}
+
+// updateTooltip follows what the pointer is over. Moving onto something
+// else starts the delay again.
+func (g *Game) updateTooltip(dt float64) {
+	t := &g.tooltip
+	text := ""
+	if !t.hidden && !g.hud.hidden && !g.textEdit.active {
+		text = g.hoverHelp(g.readMouse())
+	}
+	if text != t.text {
+		t.text, t.rested = text, 0
+		return
+	}
+	t.rested += dt
+}
+
+// hoverHelp returns the help for what is under the pointer: the context
+// menu, the inspector, the menu, then the sun, a tree, a prop or a cloud, in the order
+// clicks reach them
+func (g *Game) hoverHelp(m mouse) string {
+	if m.down {
+		return "" // Not over a drag
+	}
+	if c := &g.context; c.panel != nil && c.area().contains(m.x, m.y) {
+		return c.panel.hovered(m)
+	}
+	if p := g.inspectorPanel(); p != nil && g.inspectorArea(p).contains(m.x, m.y) {
+		return p.hovered(m)
+	}
+	if g.menu.visible && g.menuArea().contains(m.x, m.y) {
+		return g.menuPages().hovered(m)
+	}
+	pos := g.cursor()
+	if dx, dy := pos.x-g.SunX, pos.y-g.SunY; dx*dx+dy*dy <= sim.SunRadius*sim.SunRadius {
+		if g.SunLocked {
+			return "The sun is locked, right-click to unlock it"
+		}
+		return "Drag to move the sun, shadows follow it. Right-click to lock it"
+	}
+	if i := g.treeAt(pos.x, pos.y); i != -1 {
+		t := &g.Trees[i]
+		if t.Locked {
+			return treeLabel(t) + " is locked, right-click to unlock it"
+		}
+		return "Drag to move " + treeLabel(t) + ", click it and press N to rename. Right-click for more"
+	}
+	if i := g.propAt(pos.x, pos.y); i != -1 {
+		return "Drag to move " + propLabel(&g.Props[i]) + ", click it and press N to rename"
+	}
+	if i := g.cloudIndexAt(pos.x, pos.y); i != -1 {
+		c := g.Clouds[i]
+		state := fmt.Sprintf("%.0f%% full of water", c.Water*100)
+		if c.Raining {
+			state = "raining"
+		}
+		return fmt.Sprintf("%s cloud, %s. Click to inspect it, right-click to delete, copy or lock it", g.CloudKind(c), state)
+	}
+	return ""
+}
+
+// drawTooltip shows the help beside the pointer once it has rested there,
+// kept on screen near the edges
+func (g *Game) drawTooltip(screen *ebiten.Image) {
+	t := &g.tooltip
+	if t.text == "" || t.rested < tooltipDelay {
+		return
+	}
+	m := g.readMouse()
+	width := float64(len(t.text)*charWidth + 8)
+	x := math.Max(0, math.Min(m.x+12, float64(screenWidth)-width))
+	y := m.y + 20
+	if y+widgetHeight > float64(screenHeight) {
+		y = m.y - widgetHeight - 4
+	}
+	drawRect(screen, x, y, width, widgetHeight, color.RGBA{20, 20, 30, 230})
+	drawRect(screen, x, y+widgetHeight-1, width, 1, widgetEdge)
+	ebitenutil.DebugPrintAt(screen, t.text, int(x)+4, int(y)+1)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	longPressTicks = 30   // Updates a finger is held still to open the context menu, half a second
+	longPressSlop  = 10.0 // Pixels a finger can wander and still count as held still
+)
+
+// Touch is the state kept between frames for a touchscreen. One finger
+// stands in for the left mouse button, so tapping and dragging work as
+// clicking and dragging do; holding it still stands in for a right-click,
+// and two fingers pan the camera.
+type Touch struct {
+	ids            []ebiten.TouchID
+	active         bool // A finger was used last, so the pointer is where it is rather than the mouse
+	x, y           int  // Where the finger is, or last was
+	startX, startY int  // Where it went down
+	still          bool // Hasn't wandered since it went down, so it may be a long press
+	down           bool // One finger is down and acting as the left button
+	pressed        bool // The finger went down this frame
+	longPress      bool // The finger has been held still long enough, this frame
+	panning        bool // Two fingers are down, or one is left over from them
+	panX, panY     float64
+	mouseX, mouseY int // The cursor when a finger was last used, so moving the mouse takes over again
+}
+
+// updateTouch reads the fingers on the screen for this frame
+func (g *Game) updateTouch() {
+	t := &g.touch
+	t.pressed, t.longPress = false, false
+	if x, y := ebiten.CursorPosition(); t.active && (x != t.mouseX || y != t.mouseY) {
+		t.active = false
+	}
+	t.ids = ebiten.AppendTouchIDs(t.ids[:0])
+	if len(t.ids) == 0 {
+		t.down, t.panning = false, false
+		return
+	}
+	t.active = true
+	t.mouseX, t.mouseY = ebiten.CursorPosition()
+
+	if len(t.ids) > 1 {
+		g.updateTwoFingerPan()
+		return
+	}
+	// A finger left from a pan does nothing until it too is lifted
+	if t.panning {
+		return
+	}
+	id := t.ids[0]
+	t.x, t.y = ebiten.TouchPosition(id)
+	held := inpututil.TouchPressDuration(id)
+	if held == 1 {
+		t.pressed, t.down, t.still = true, true, true
+		t.startX, t.startY = t.x, t.y
+	}
+	if math.Hypot(float64(t.x-t.startX), float64(t.y-t.startY)) > longPressSlop {
+		t.still = false
+	}
+	// The long press lets go of whatever the finger took hold of, so it
+	// isn't dragged once the context menu is open
+	if t.down && t.still && held >= longPressTicks {
+		t.longPress, t.down = true, false
+	}
+}
+
+// updateTwoFingerPan moves the camera with the middle of the first two
+// fingers, letting go of anything the first finger was dragging
+func (g *Game) updateTwoFingerPan() {
+	t := &g.touch
+	ax, ay := ebiten.TouchPosition(t.ids[0])
+	bx, by := ebiten.TouchPosition(t.ids[1])
+	x, y := float64(ax+bx)/2, float64(ay+by)/2
+	if t.panning {
+		g.panCamera(x-t.panX, y-t.panY)
+	}
+	t.panning, t.down = true, false
+	t.panX, t.panY = x, y
+}
+
+// pointerPosition is where the mouse is on the screen, or the finger when
+// one was used last
+func (g *Game) pointerPosition() (int, int) {
+	if g.touch.active {
+		return g.touch.x, g.touch.y
+	}
+	return ebiten.CursorPosition()
+}
+
+// pointerDown reports whether the left mouse button or a finger is held
+func (g *Game) pointerDown() bool {
+	return ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || g.touch.down
+}
+
+// pointerPressed reports whether the left mouse button or a finger went
+// down this frame
+func (g *Game) pointerPressed() bool {
+	return inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || g.touch.pressed
+}
+
+// secondaryPressed reports a right-click or long press this frame
+func (g *Game) secondaryPressed() bool {
+	return inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight) || g.touch.longPress
+}
+
+// pressedRow is the row of a start screen or wizard style box clicked or
+// tapped this frame, -1 for none. Rows are 20 pixels apart from 32 below
+// the box's top.
+func (g *Game) pressedRow(x, y, width float64, rows int) int {
+	if !g.pointerPressed() {
+		return -1
+	}
+	px, py := g.pointerPosition()
+	if float64(px) < x || float64(px) >= x+width {
+		return -1
+	}
+	i := int(math.Floor((float64(py) - y - 30) / 20))
+	if i < 0 || i >= rows {
+		return -1
+	}
+	return i
+}
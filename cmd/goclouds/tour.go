@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/scene"
+)
+
+// tourStep is one caption of the guided tour, shown for a while after enter
+// has set the scene up for it
+type tourStep struct {
+	caption  string
+	duration float64 // Seconds
+	enter    func(g *Game)
+}
+
+var tourSteps = []tourStep{
+	{
+		caption:  "Welcome to GoClouds! This tour walks through how the scene works.\nSpace skips ahead, H or ESC ends the tour.",
+		duration: 6,
+	},
+	{
+		caption:  "The sun lights everything. High in the sky it casts short shadows.\nYou can drag it anywhere with the mouse.",
+		duration: 7,
+		enter: func(g *Game) {
+			g.GlideSun(float64(screenWidth)/2, 60)
+		},
+	},
+	{
+		caption:  "As the sun sinks towards the horizon, shadows stretch out\nand the trees on the far side fall into shade.",
+		duration: 7,
+		enter: func(g *Game) {
+			g.GlideSun(120, float64(screenHeight-scene.GroundHeight-10))
+		},
+	},
+	{
+		caption:  "Why? The ray grazing the top of a tree lands further away the lower\nthe sun is. The shadow lesson (E) draws the similar triangles.",
+		duration: 9,
+		enter: func(g *Game) {
+			if len(g.Trees) > 0 {
+				g.selectedID = g.Trees[0].ID
+			}
+			g.teaching = true
+			g.GlideSun(200, float64(screenHeight)/3)
+		},
+	},
+	{
+		caption:  "Clouds form at different heights: wispy cirrus up high, heaped\ncumulus low down. C labels them, Shift+C starts a quiz.",
+		duration: 9,
+		enter: func(g *Game) {
+			g.teaching = false
+			g.lesson.labels = true
+			g.Density = 0.6
+		},
+	},
+	{
+		caption:  "Gusting wind carries the clouds along. The windsock on the right\nshows how hard it is blowing right now.",
+		duration: 7,
+		enter: func(g *Game) {
+			g.lesson.labels = false
+		},
+	},
+	{
+		caption:  "Templates build whole scenes from a seed. This is the Orchard.\nOpen the menu with M, pick one with G and press Enter.",
+		duration: 8,
+		enter: func(g *Game) {
+			for i, t := range scene.Templates {
+				if t.Name == "Orchard" {
+					g.applyScene(t.Generate(g.scene(), 7, g.World.Layout))
+					g.menu.template = i
+				}
+			}
+		},
+	},
+	{
+		caption:  "Birds perch on the trees. Place birdhouses and feeders with P\nto draw them in, or a scarecrow to keep them away.",
+		duration: 8,
+	},
+	{
+		caption:  "That's the tour! Your scene is back as it was.\nPress M for all the controls.",
+		duration: 6,
+	},
+}
+
+// Tour is the guided tour's progress. The scene and overlays in place when
+// it started are put back when it ends.
+type Tour struct {
+	active  bool
+	step    int
+	elapsed float64
+
+	saved        scene.Scene
+	savedHistory History
+	savedLessons [2]bool // Shadow lesson, cloud labels
+}
+
+func (g *Game) startTour() {
+	g.tour = Tour{
+		active:       true,
+		saved:        g.scene(),
+		savedHistory: g.history,
+		savedLessons: [2]bool{g.teaching, g.lesson.labels},
+		step:         -1,
+	}
+	g.logEvent("guided tour started")
+	g.nextTourStep()
+}
+
+func (g *Game) stopTour() {
+	if !g.tour.active {
+		return
+	}
+	g.tour.active = false
+	g.Transition.Active = false
+	g.applyScene(g.tour.saved)
+	g.history = g.tour.savedHistory
+	g.teaching, g.lesson.labels = g.tour.savedLessons[0], g.tour.savedLessons[1]
+	g.logEvent("guided tour ended")
+}
+
+func (g *Game) nextTourStep() {
+	g.tour.step++
+	g.tour.elapsed = 0
+	if g.tour.step >= len(tourSteps) {
+		g.stopTour()
+		return
+	}
+	if enter := tourSteps[g.tour.step].enter; enter != nil {
+		enter(g)
+	}
+}
+
+// updateTour starts and stops the tour with H and moves it along, Space
+// skips to the next step
+func (g *Game) updateTour(dt float64) {
+	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
+		if g.tour.active {
+			g.stopTour()
+		} else {
+			g.startTour()
+		}
+		return
+	}
+	if !g.tour.active {
+		return
+	}
+	g.tour.elapsed += dt
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || g.tour.elapsed >= tourSteps[g.tour.step].duration {
+		g.nextTourStep()
+	}
+}
+
+func (g *Game) drawTour(screen *ebiten.Image) {
+	if !g.tour.active {
+		return
+	}
+	step := tourSteps[g.tour.step]
+	lines := strings.Split(step.caption, "\n")
+	lines = append(lines, fmt.Sprintf("(%d/%d)", g.tour.step+1, len(tourSteps)))
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line)*6+20)
+	}
+	height := float64(len(lines)*16 + 12)
+	x := (float64(screenWidth) - float64(width)) / 2
+	y := float64(screenHeight) - height - 40
+	drawRect(screen, x, y, float64(width), height, color.RGBA{0, 0, 40, 210})
+
+	// Progress bar along the bottom of the caption
+	progress := g.tour.elapsed / step.duration
+	drawRect(screen, x, y+height-3, float64(width)*progress, 3, color.RGBA{255, 220, 0, 255})
+
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, int(x)+10, int(y)+6+i*16)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/sim"
+)
+
+const windowFile = "goclouds-window.json"
+
+// WindowState is how the window, the overlays on it and the menu's
+// settings were left, restored on the next launch so the app opens as it
+// was closed
+type WindowState struct {
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	X          int    `json:"x"` // Relative to the monitor's top left
+	Y          int    `json:"y"`
+	Monitor    string `json:"monitor,omitempty"`
+	Fullscreen bool   `json:"fullscreen"`
+	Maximized  bool   `json:"maximized"`
+	Panels     Panels `json:"panels"`
+
+	Settings *Settings `json:"settings,omitempty"`
+}
+
+// Panels is which overlays are showing, the menu page, whether tooltips
+// are turned off and whether the HUD is hidden
+type Panels struct {
+	Menu        bool `json:"menu"`
+	MenuPage    int  `json:"menuPage"`
+	EventLog    bool `json:"eventLog"`
+	Notes       bool `json:"notes"`
+	Diagnostics bool `json:"diagnostics"`
+	Probes      bool `json:"probes"`
+	FrameGraph  bool `json:"frameGraph,omitempty"`
+	Map         int  `json:"map,omitempty"` // 1 in a corner, 2 beside the scene
+	Uptime      bool `json:"uptime"`
+	CloudLabels bool `json:"cloudLabels"`
+	NoTooltips  bool `json:"noTooltips,omitempty"`
+	SunSnap     bool `json:"sunSnap,omitempty"`
+
+	HUDHidden    bool `json:"hudHidden,omitempty"`
+	Presentation bool `json:"presentation,omitempty"`
+}
+
+// Settings are the menu's scene settings, carried over to the fresh scene
+// the next launch starts with. A scene that is opened or resumed brings its
+// own.
+type Settings struct {
+	CloudCount int     `json:"cloudCount"`
+	TreeCount  int     `json:"treeCount"`
+	Density    float64 `json:"density"`
+	TreeShadow float64 `json:"treeShadow"`
+	Wind       float64 `json:"wind"`
+	Mood       string  `json:"mood,omitempty"`
+}
+
+// loadWindowState reads how the window was left, reporting false when it
+// wasn't saved or can't be read
+func loadWindowState() (WindowState, bool) {
+	data, err := os.ReadFile(windowFile)
+	if err != nil {
+		return WindowState{}, false
+	}
+	var s WindowState
+	if err := json.Unmarshal(data, &s); err != nil {
+		slog.Warn("reading window state", "path", windowFile, "err", err)
+		return WindowState{}, false
+	}
+	return s, true
+}
+
+// restoreWindow puts the window back where it was left. The size is only
+// restored when no size was asked for, and the position only on a monitor
+// that is still connected.
+func restoreWindow(s WindowState, keepSize bool) {
+	if !keepSize && s.Width > 0 && s.Height > 0 {
+		ebiten.SetWindowSize(s.Width, s.Height)
+	}
+	for _, m := range ebiten.AppendMonitors(nil) {
+		if m.Name() == s.Monitor {
+			ebiten.SetMonitor(m)
+			ebiten.SetWindowPosition(s.X, s.Y)
+			break
+		}
+	}
+	if s.Maximized {
+		ebiten.MaximizeWindow()
+	}
+	if s.Fullscreen {
+		ebiten.SetFullscreen(true)
+	}
+}
+
+// restorePanels shows the overlays that were showing
+func (g *Game) restorePanels(p Panels) {
+	g.menu.visible = p.Menu
+	if pages := g.menuPages(); p.MenuPage >= 0 && p.MenuPage < len(pages.panels) {
+		pages.page = p.MenuPage
+	}
+	g.events.visible = p.EventLog
+	g.notesVisible = p.Notes
+	g.diagnostics.visible = p.Diagnostics
+	g.diagnostics.probes = p.Probes
+	g.soak.visible = p.Uptime
+	g.lesson.labels = p.CloudLabels
+	g.diagnostics.graph = p.FrameGraph
+	if p.Map > 0 && p.Map < int(numMapModes) {
+		g.mapMode = MapMode(p.Map)
+	}
+	g.tooltip.hidden = p.NoTooltips
+	g.sunSnap = p.SunSnap
+	if p.HUDHidden && !p.Menu {
+		g.setHUDHidden(true, p.Presentation)
+	}
+}
+
+// restoreSettings sets the fresh scene up as the menu was left, kept in
+// range in case the file was edited. It isn't an undoable step, as nothing
+// was changed in this session.
+func (g *Game) restoreSettings(s Settings) {
+	g.CloudCount = min(g.MaxClouds, max(0, s.CloudCount))
+	if n := min(20, max(1, s.TreeCount)); n != g.TreeCount {
+		g.TreeCount = n
+		g.UpdateTreeCount()
+	}
+	g.Density = math.Max(0, math.Min(1, s.Density))
+	g.TreeShadow = math.Max(0.2, math.Min(2, s.TreeShadow))
+	g.Wind.Base = math.Max(0, math.Min(5, s.Wind))
+	if m, ok := sim.FindMood(s.Mood); ok {
+		g.Direct(m)
+	} else {
+		g.Direct(nil)
+	}
+}
+
+// saveWindow writes how the window and overlays are now. Failing to is only
+// logged, as nothing but the layout is lost.
+func (g *Game) saveWindow() {
+	if g.kiosk {
+		return
+	}
+	s := WindowState{
+		Fullscreen: ebiten.IsFullscreen(),
+		Maximized:  ebiten.IsWindowMaximized(),
+		Panels: Panels{
+			Menu:        g.menu.visible,
+			MenuPage:    g.menuPages().page,
+			EventLog:    g.events.visible,
+			Notes:       g.notesVisible,
+			Diagnostics: g.diagnostics.visible,
+			Probes:      g.diagnostics.probes,
+			FrameGraph:  g.diagnostics.graph,
+			Map:         int(g.mapMode),
+			Uptime:      g.soak.visible,
+			CloudLabels: g.lesson.labels,
+			NoTooltips:  g.tooltip.hidden,
+			SunSnap:     g.sunSnap,
+
+			HUDHidden:    g.hud.hidden,
+			Presentation: g.hud.presentation,
+		},
+		Settings: &Settings{
+			CloudCount: g.CloudCount,
+			TreeCount:  g.TreeCount,
+			Density:    g.Density,
+			TreeShadow: g.TreeShadow,
+			Wind:       g.Wind.Base,
+		},
+	}
+	if m := g.Director.Mood; m != nil {
+		s.Settings.Mood = m.Name
+	}
+	s.Width, s.Height = ebiten.WindowSize()
+	s.X, s.Y = ebiten.WindowPosition()
+	if m := ebiten.Monitor(); m != nil {
+		s.Monitor = m.Name()
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = os.WriteFile(windowFile, data, 0o644)
+	}
+	if err != nil {
+		slog.Warn("saving window state", "path", windowFile, "err", err)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// timesOfDay are the times a new scene can start at, as a share of the
+// sun's path across the sky
+var timesOfDay = []struct {
+	name string
+	t    float64
+}{
+	{"Dawn", 0.05},
+	{"Morning", 0.25},
+	{"Noon", 0.5},
+	{"Afternoon", 0.75},
+	{"Dusk", 0.95},
+}
+
+// worldSizes are the sizes a new scene can be laid out at
+var worldSizes = [][2]int{{640, 480}, {800, 600}, {1024, 768}, {1280, 720}, {1920, 1080}}
+
+// Wizard asks how a new scene should look before generating it, in place of
+// the random default scene
+type Wizard struct {
+	visible bool
+	cursor  int // Focused row of wizardRows
+	biome   int // Index into scene.Templates
+	season  int // Index into scene.Seasons
+	time    int // Index into timesOfDay
+	size    int // Index into worldSizes
+	seed    int64
+}
+
+// wizardRow is one question in the wizard. Left/Right call adjust and Enter
+// calls activate.
+type wizardRow struct {
+	label    func(w *Wizard) string
+	adjust   func(w *Wizard, d int)
+	activate func(g *Game)
+}
+
+// cycle steps i by d through n choices, wrapping around
+func cycle(i, d, n int) int {
+	return ((i+d)%n + n) % n
+}
+
+var wizardRows = []wizardRow{
+	{
+		label:  func(w *Wizard) string { return "Biome: " + scene.Templates[w.biome].Name },
+		adjust: func(w *Wizard, d int) { w.biome = cycle(w.biome, d, len(scene.Templates)) },
+	},
+	{
+		label:  func(w *Wizard) string { return "Season: " + scene.Seasons[w.season].Name },
+		adjust: func(w *Wizard, d int) { w.season = cycle(w.season, d, len(scene.Seasons)) },
+	},
+	{
+		label:  func(w *Wizard) string { return "Time of day: " + timesOfDay[w.time].name },
+		adjust: func(w *Wizard, d int) { w.time = cycle(w.time, d, len(timesOfDay)) },
+	},
+	{
+		label: func(w *Wizard) string {
+			return fmt.Sprintf("World size: %dx%d", worldSizes[w.size][0], worldSizes[w.size][1])
+		},
+		adjust: func(w *Wizard, d int) { w.size = cycle(w.size, d, len(worldSizes)) },
+	},
+	{
+		label:    func(w *Wizard) string { return fmt.Sprintf("Seed: %d (Enter to type)", w.seed) },
+		adjust:   func(w *Wizard, d int) { w.seed += int64(d) },
+		activate: (*Game).editWizardSeed,
+	},
+	{
+		label:    func(w *Wizard) string { return "> Create scene" },
+		activate: (*Game).createWizardScene,
+	},
+}
+
+// openWizard shows the new scene wizard, starting from the current size
+// and a fresh seed
+func (g *Game) openWizard() {
+	g.wizard.visible = true
+	g.wizard.cursor = len(wizardRows) - 1
+	g.wizard.seed = rng.Int63n(1_000_000)
+	g.wizard.size = 0
+	for i, size := range worldSizes {
+		if size == [2]int{screenWidth, screenHeight} {
+			g.wizard.size = i
+		}
+	}
+}
+
+// updateWizard handles the wizard's keys while it is shown, reporting
+// whether it took them. ESC, handled with the other uses of ESC, closes it
+// and keeps the scene as it is.
+func (g *Game) updateWizard() bool {
+	w := &g.wizard
+	if !w.visible {
+		return false
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+		w.cursor = cycle(w.cursor, -1, len(wizardRows))
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+		w.cursor = cycle(w.cursor, 1, len(wizardRows))
+	}
+	row := wizardRows[w.cursor]
+	if row.adjust != nil {
+		if keyRepeat(ebiten.KeyLeft) {
+			row.adjust(w, -1)
+		}
+		if keyRepeat(ebiten.KeyRight) {
+			row.adjust(w, 1)
+		}
+	}
+	if row.activate != nil && inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		row.activate(g)
+	}
+
+	// Clicking or tapping a row picks it, and again changes its answer or
+	// presses it
+	x, y, width, _ := wizardBox()
+	if i := g.pressedRow(x, y, width, len(wizardRows)); i != -1 && i != w.cursor {
+		w.cursor = i
+	} else if i != -1 && row.activate != nil {
+		row.activate(g)
+	} else if i != -1 && row.adjust != nil {
+		row.adjust(w, 1)
+	}
+	return true
+}
+
+// editWizardSeed prompts for the new scene's seed
+func (g *Game) editWizardSeed() {
+	g.startTextEdit("Seed", strconv.FormatInt(g.wizard.seed, 10), 18, func(text string) {
+		seed, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			g.setStatus("Seed must be a whole number")
+			return
+		}
+		g.wizard.seed = seed
+	})
+}
+
+// createWizardScene replaces the scene in front with one generated from the
+// wizard's answers. The same answers always give the same scene.
+func (g *Game) createWizardScene() {
+	w := &g.wizard
+	w.visible = false
+	size := worldSizes[w.size]
+	if size != [2]int{screenWidth, screenHeight} {
+		screenWidth, screenHeight = size[0], size[1]
+		ebiten.SetWindowSize(screenWidth, screenHeight)
+	}
+
+	g.World = g.newWorld(g.config.current, rand.New(rand.NewSource(w.seed)))
+	g.renderer = render.New()
+	g.renderer.Economy = g.throttle.active
+	g.renderer.LowEnd = g.lowEnd.active
+	g.clock = sim.Clock{}
+	g.camera = Camera{lastClick: math.Inf(-1)}
+	g.notes = nil
+
+	biome, season := scene.Templates[w.biome], scene.Seasons[w.season]
+	s := season.Apply(biome.Generate(g.scene(), w.seed, g.World.Layout))
+	s.SunX, s.SunY = g.SunAt(timesOfDay[w.time].t)
+	g.applyScene(s)
+	g.resetCamera()
+
+	g.logEvent("new %s %s scene at %s, %dx%d, seed %d", strings.ToLower(season.Name), biome.Name, strings.ToLower(timesOfDay[w.time].name), size[0], size[1], w.seed)
+	g.setStatus(fmt.Sprintf("New %s scene in %s (seed %d)", biome.Name, strings.ToLower(season.Name), w.seed))
+}
+
+// wizardBox is where the wizard sits, in the middle of the screen
+func wizardBox() (x, y, width, height float64) {
+	width, height = 280, float64(60+len(wizardRows)*20)
+	return float64(screenWidth)/2 - width/2, float64(screenHeight)/2 - height/2, width, height
+}
+
+// drawWizard draws the wizard's questions in the middle of the screen
+func (g *Game) drawWizard(screen *ebiten.Image) {
+	w := &g.wizard
+	if !w.visible {
+		return
+	}
+	x, y, width, height := wizardBox()
+	drawRect(screen, x, y, width, height, color.RGBA{0, 0, 0, 200})
+	ebitenutil.DebugPrintAt(screen, "=== New Scene ===", int(x)+15, int(y)+10)
+	rowY := int(y) + 32
+	for i, row := range wizardRows {
+		if i == w.cursor {
+			drawRect(screen, x+2, float64(rowY)-2, width-4, 18, color.RGBA{255, 255, 255, 60})
+		}
+		ebitenutil.DebugPrintAt(screen, row.label(w), int(x)+15, rowY)
+		rowY += 20
+	}
+	ebitenutil.DebugPrintAt(screen, "Arrows: Choose, Enter: Create, ESC: Cancel", int(x)+15, rowY+4)
+}
@@ -0,0 +1,196 @@
+// Package clouds is the simplest way to embed the cloud scene in another
+// program: build a Scene with NewScene and call its Update and Draw from your
+// own game loop, passing Draw an ebitenrender.Screen, or use package widget to
+// draw it into part of the screen. The package doesn't import Ebiten itself,
+// so it also works headless through RenderFrame.
+package clouds
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+	"time"
+
+	"cloudapp/pkg/render"
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// tickRate is how many times a second Update is expected to be called
+const tickRate = 60
+
+// Scene is a running cloud scene that can be stepped and drawn
+type Scene struct {
+	world    *sim.World
+	clock    sim.Clock
+	renderer *render.Painter
+	headless *render.Painter // Used by RenderFrame, made on first use
+	frame    image.Point     // Size headless last drew at
+	updated  time.Time       // When Update last ran, for Draw to blend on from
+}
+
+// Option changes how NewScene sets up a scene
+type Option func(*config)
+
+type config struct {
+	opts      sim.Options
+	seed      int64
+	wetness   float64
+	animation *scene.Animation
+}
+
+// WithSize sets the area the scene fills, in pixels. The default is 800x600.
+func WithSize(width, height int) Option {
+	return func(c *config) {
+		c.opts.Width = width
+		c.opts.Height = height
+	}
+}
+
+// WithCloudCount sets how many clouds are in the sky
+func WithCloudCount(n int) Option {
+	return func(c *config) { c.opts.CloudCount = n }
+}
+
+// WithTreeCount sets how many trees are planted
+func WithTreeCount(n int) Option {
+	return func(c *config) { c.opts.TreeCount = n }
+}
+
+// WithDensity sets the share of the clouds shown, 0-1
+func WithDensity(density float64) Option {
+	return func(c *config) { c.opts.Density = density }
+}
+
+// WithSeed makes the scene reproducible. Without it every scene is different.
+func WithSeed(seed int64) Option {
+	return func(c *config) { c.seed = seed }
+}
+
+// WithWind sets the prevailing wind strength, 1 is a gentle breeze
+func WithWind(strength float64) Option {
+	return func(c *config) { c.opts.Wind = strength }
+}
+
+// WithPalette picks one of the named color palettes in scene.Palettes
+func WithPalette(name string) Option {
+	return func(c *config) { c.opts.Palette = name }
+}
+
+// WithReducedMotion slows ambient movement and drops shooting stars
+func WithReducedMotion(on bool) Option {
+	return func(c *config) { c.opts.ReducedMotion = on }
+}
+
+// WithWetness starts the scene with wet ground, 0-1, which mirrors the sky
+// until it dries
+func WithWetness(wetness float64) Option {
+	return func(c *config) { c.wetness = wetness }
+}
+
+// WithAnimation plays a keyframed animation over the scene, such as one
+// read with scene.ParseAnimation
+func WithAnimation(a *scene.Animation) Option {
+	return func(c *config) { c.animation = a }
+}
+
+// NewScene creates a scene with the same defaults as the desktop app,
+// changed by any options given
+func NewScene(opts ...Option) (*Scene, error) {
+	c := config{
+		opts: sim.Options{
+			Width:           800,
+			Height:          600,
+			CloudCount:      scene.MaxClouds,
+			TreeCount:       5,
+			Density:         0.2,
+			Palette:         "default",
+			Wind:            1.0,
+			ScarecrowRadius: 120,
+		},
+		seed: time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.opts.Width <= 0 || c.opts.Height <= scene.GroundHeight {
+		return nil, fmt.Errorf("size %dx%d is too small", c.opts.Width, c.opts.Height)
+	}
+	if c.opts.CloudCount < 0 || c.opts.TreeCount < 0 {
+		return nil, fmt.Errorf("cloud and tree counts must not be negative")
+	}
+	if _, ok := scene.Palettes[c.opts.Palette]; !ok {
+		return nil, fmt.Errorf("unknown palette %q", c.opts.Palette)
+	}
+	if c.wetness < 0 || c.wetness > 1 {
+		return nil, fmt.Errorf("wetness %g is outside 0-1", c.wetness)
+	}
+	c.opts.Rand = rand.New(rand.NewSource(c.seed))
+
+	world := sim.New(c.opts)
+	world.Wetness = c.wetness
+	if c.animation != nil {
+		if err := c.animation.Validate(); err != nil {
+			return nil, err
+		}
+		world.Play(c.animation)
+	}
+	return &Scene{
+		world:    world,
+		renderer: render.New(),
+	}, nil
+}
+
+// Update moves the scene forward by one tick at Ebiten's default rate
+func (s *Scene) Update() error {
+	s.clock.Advance(s.world, 1.0/tickRate)
+	s.updated = time.Now()
+	return nil
+}
+
+// Draw paints the scene onto any render backend, such as an
+// ebitenrender.Screen or render.NewSVG. Stick to one kind of backend, as
+// drawn parts are cached between calls.
+func (s *Scene) Draw(dst render.Renderer) {
+	if !s.updated.IsZero() {
+		s.clock.Since(time.Since(s.updated).Seconds())
+	}
+	s.clock.Blend(s.world, func() { s.renderer.Draw(dst, s.world) })
+}
+
+// RenderFrame draws the scene as it is now into a new width x height image,
+// without needing a window. The whole scene is scaled to fit, so frames can
+// be made at thumbnail size.
+func (s *Scene) RenderFrame(width, height int) *image.RGBA {
+	// Cached parts are drawn at the frame's scale, so they're started
+	// afresh when the size changes
+	if size := image.Pt(width, height); s.headless == nil || size != s.frame {
+		s.headless = render.New()
+		s.frame = size
+	}
+	frame := render.NewRaster(width, height, s.world.Width, s.world.Height)
+	s.clock.Blend(s.world, func() { s.headless.Draw(frame, s.world) })
+	return frame.Image
+}
+
+// Step advances the scene by dt seconds, for driving it outside a game loop.
+// The simulation always moves in steps of sim.FixedStep, so a scene stepped
+// at any rate plays out the same, with what's left over carried to the next
+// call.
+func (s *Scene) Step(dt float64) {
+	s.clock.Advance(s.world, dt)
+	s.updated = time.Time{}
+}
+
+// Describe sums up the scene in a sentence, for screen readers and other
+// text output
+func (s *Scene) Describe() string {
+	return s.world.Describe()
+}
+
+// World gives access to the underlying simulation for callers that need
+// more than the options offer
+func (s *Scene) World() *sim.World {
+	return s.world
+}
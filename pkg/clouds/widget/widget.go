@@ -0,0 +1,102 @@
+// Package widget puts the cloud scene inside another Ebiten game, drawn into
+// a rectangle of that game's screen as a background layer. It is kept out of
+// package clouds, which stays free of Ebiten so it can run headless.
+package widget
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"cloudapp/pkg/clouds"
+	"cloudapp/pkg/render/ebitenrender"
+)
+
+// minHeight keeps the sky visible above the ground band in short widgets.
+// Shorter rectangles show a taller scene scaled down to fit.
+const minHeight = 300
+
+// Widget is a cloud scene drawn into part of a host game's screen. Call
+// Update from the game's Update and Draw from its Draw.
+type Widget struct {
+	Scene *clouds.Scene
+
+	rect          image.Rectangle // Where on the host screen the scene goes
+	width, height int             // Size the scene is laid out at
+	offscreen     *ebiten.Image
+}
+
+// New makes a widget filling rect. The scene is laid out at the rectangle's
+// size, or taller with the same shape for short rectangles, unless an option
+// sets a size. Other options are passed on to clouds.NewScene.
+func New(rect image.Rectangle, opts ...clouds.Option) (*Widget, error) {
+	w, h := layoutSize(rect)
+	scene, err := clouds.NewScene(append([]clouds.Option{clouds.WithSize(w, h)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	world := scene.World()
+	return &Widget{
+		Scene:  scene,
+		rect:   rect,
+		width:  world.Width,
+		height: world.Height,
+	}, nil
+}
+
+// layoutSize picks the scene size for a rectangle
+func layoutSize(rect image.Rectangle) (int, int) {
+	w, h := max(1, rect.Dx()), max(1, rect.Dy())
+	if h < minHeight {
+		w = w * minHeight / h
+		h = minHeight
+	}
+	return w, h
+}
+
+// Rect is where the widget draws on the host screen
+func (w *Widget) Rect() image.Rectangle {
+	return w.rect
+}
+
+// SetRect moves or resizes the widget. The scene keeps its layout and is
+// scaled to the new rectangle.
+func (w *Widget) SetRect(rect image.Rectangle) {
+	w.rect = rect
+}
+
+// Update steps the scene by one tick
+func (w *Widget) Update() error {
+	return w.Scene.Update()
+}
+
+// Draw paints the scene into the widget's rectangle of screen, scaled to fill
+// it. Nothing outside the rectangle is touched.
+func (w *Widget) Draw(screen *ebiten.Image) {
+	if w.rect.Empty() {
+		return
+	}
+	if w.offscreen == nil {
+		w.offscreen = ebiten.NewImage(w.width, w.height)
+	}
+	w.Scene.Draw(ebitenrender.Screen{Image: w.offscreen})
+
+	dst := screen.SubImage(w.rect).(*ebiten.Image)
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(float64(w.rect.Dx())/float64(w.width), float64(w.rect.Dy())/float64(w.height))
+	opts.GeoM.Translate(float64(w.rect.Min.X), float64(w.rect.Min.Y))
+	opts.Filter = ebiten.FilterLinear
+	dst.DrawImage(w.offscreen, opts)
+}
+
+// ScenePoint converts a position on the host screen to scene coordinates,
+// for games that let players interact with the sky. ok is false outside the
+// widget.
+func (w *Widget) ScenePoint(x, y int) (sx, sy float64, ok bool) {
+	if !image.Pt(x, y).In(w.rect) {
+		return 0, 0, false
+	}
+	sx = float64(x-w.rect.Min.X) * float64(w.width) / float64(w.rect.Dx())
+	sy = float64(y-w.rect.Min.Y) * float64(w.height) / float64(w.rect.Dy())
+	return sx, sy, true
+}
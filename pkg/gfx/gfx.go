@@ -0,0 +1,64 @@
+// Package gfx holds the drawing interface shared by the simulation's
+// entities and the render backends, so entities can draw themselves without
+// depending on how the scene is displayed.
+package gfx
+
+import (
+	"image/color"
+)
+
+// Renderer is a surface the scene can be drawn onto. Everything the painter
+// draws goes through these few calls, so supporting a new output only means
+// implementing them.
+type Renderer interface {
+	// Fill covers the whole surface with c
+	Fill(c color.Color)
+	DrawCircle(x, y, r float64, c color.Color)
+	DrawLine(x1, y1, x2, y2 float64, c color.Color)
+	DrawRect(x, y, width, height float64, c color.Color)
+
+	// NewImage makes an offscreen surface of the same kind, for parts that
+	// are drawn once and reused
+	NewImage(width, height int) Renderer
+	// DrawImage draws an image made by NewImage with its top left at x, y,
+	// its opacity scaled by alpha (0-1)
+	DrawImage(img Renderer, x, y, alpha float64)
+}
+
+// SpriteDrawer is implemented by renderers that can stamp an image scaled
+// and tinted, so a shape drawn many times a frame can be drawn once into an
+// image and reused. Renderers without it get the shape drawn directly.
+type SpriteDrawer interface {
+	// DrawSprite draws an image made by NewImage with its top left at x, y,
+	// scaled by scale and with its colors multiplied by tint
+	DrawSprite(img Renderer, x, y, scale float64, tint color.Color)
+}
+
+// Affine is a 2x2 linear transform, taking x, y to A*x+B*y, C*x+D*y
+type Affine struct {
+	A, B, C, D float64
+}
+
+// Apply transforms a point
+func (m Affine) Apply(x, y float64) (float64, float64) {
+	return m.A*x + m.B*y, m.C*x + m.D*y
+}
+
+// AffineDrawer is implemented by sprite drawers that can also stretch and
+// shear a sprite, so a stamped shape can be deformed without redrawing it
+type AffineDrawer interface {
+	SpriteDrawer
+	// DrawSpriteAffine draws an image made by NewImage transformed by m
+	// about its center, with its center at x, y and its colors multiplied
+	// by tint
+	DrawSpriteAffine(img Renderer, x, y float64, m Affine, tint color.Color)
+}
+
+// Disposer is implemented by renderers whose images hold memory outside Go,
+// such as GPU textures, that is best freed as soon as an image is dropped
+// rather than whenever it is garbage collected
+type Disposer interface {
+	// Dispose frees the image's memory. The image isn't drawn with or onto
+	// afterwards.
+	Dispose()
+}
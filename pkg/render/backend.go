@@ -0,0 +1,21 @@
+package render
+
+import (
+	"cloudapp/pkg/gfx"
+)
+
+// Renderer is the surface the painter and its backends draw onto. It lives
+// in gfx so simulation entities can draw themselves too.
+type Renderer = gfx.Renderer
+
+// SpriteDrawer is a Renderer that can stamp tinted images, see gfx
+type SpriteDrawer = gfx.SpriteDrawer
+
+// AffineDrawer is a SpriteDrawer that can also stretch and shear, see gfx
+type AffineDrawer = gfx.AffineDrawer
+
+// Affine is a 2x2 linear transform, see gfx
+type Affine = gfx.Affine
+
+// Disposer is a Renderer whose images can be freed early, see gfx
+type Disposer = gfx.Disposer
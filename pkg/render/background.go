@@ -0,0 +1,60 @@
+package render
+
+import (
+	"image/color"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// groundKey is everything the cached ground image depends on
+type groundKey struct {
+	width, height       int
+	ground, dark, light color.RGBA
+}
+
+// cachedGround is the ground and its grid drawn into an image, kept until
+// the scene is resized or the palette changes
+type cachedGround struct {
+	key   groundKey
+	image Renderer
+}
+
+// cachedSun is the sun's disc drawn into an image. The sun only ever moves,
+// so the image is kept until the palette changes its color.
+type cachedSun struct {
+	color color.RGBA
+	image Renderer
+}
+
+// sunPad is the room around the sun's center in its image, enough for the
+// disc with a pixel to spare
+const sunPad = sim.SunRadius + 1
+
+// drawGround draws the ground from its cached image, redrawing the image
+// when the scene's size or colors change
+func (r *Painter) drawGround(screen Renderer, w *sim.World) {
+	key := groundKey{w.Width, w.Height, w.Palette.Ground, w.Palette.GridDark, w.Palette.GridLight}
+	hit := r.ground.image != nil && r.ground.key == key
+	r.Stats.Background.count(hit)
+	if !hit {
+		r.release(r.ground.image)
+		img := r.newImage(screen, w.Width, scene.GroundHeight, nil)
+		drawGround(img, w, 0)
+		r.ground = cachedGround{key: key, image: img}
+	}
+	screen.DrawImage(r.ground.image, 0, w.GroundTop(), 1)
+}
+
+// drawSun draws the sun from its cached image
+func (r *Painter) drawSun(screen Renderer, w *sim.World) {
+	hit := r.sun.image != nil && r.sun.color == w.Palette.Sun
+	r.Stats.Background.count(hit)
+	if !hit {
+		r.release(r.sun.image)
+		img := r.newImage(screen, sunPad*2, sunPad*2, nil)
+		drawSun(img, sunPad, sunPad, w.Palette.Sun)
+		r.sun = cachedSun{color: w.Palette.Sun, image: img}
+	}
+	screen.DrawImage(r.sun.image, w.SunX-sunPad, w.SunY-sunPad, 1)
+}
@@ -0,0 +1,360 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"slices"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const shadowDepth = 35 // How far down cloud shadows appear
+
+// shadowLobeStep is how far, in pixels, a billowing lobe moves before its
+// cloud's cached shadow is redrawn. The shadows are too faint for the steps
+// to show.
+const shadowLobeStep = 2.0
+
+// cloudShadowKey is everything a cloud's cached shadow image depends on
+// besides its lobes. The shadow slides along the ground with the cloud, so
+// only how far down the ground it falls counts.
+type cloudShadowKey struct {
+	anchorY, size      float64
+	stretchX, stretchY float64
+	alpha              uint8 // The darkest line's alpha
+	color              color.RGBA
+}
+
+// cachedCloudShadow is a cloud's shadow image, kept until the sun, the
+// cloud's height or its lobe layout changes
+type cachedCloudShadow struct {
+	key    cloudShadowKey
+	lobes  []sim.Lobe
+	image  Renderer
+	dx, dy float64 // Top left of the image relative to the anchor
+}
+
+// cloudShadowAnchor is where on the ground a cloud's shadow is drawn from,
+// reporting false for a cloud above the sun, which casts none
+func cloudShadowAnchor(w *sim.World, cloud sim.Cloud) (x, y float64, ok bool) {
+	if cloud.Y < w.SunY {
+		return 0, 0, false
+	}
+	groundHorizon := float64(w.Height - scene.GroundHeight + scene.GroundOffset)
+
+	// Calculate shadow position based on sun's position
+	shadowOffsetX := (cloud.X - w.SunX) * 0.2
+	shadowOffsetY := (cloud.Y - w.SunY) * 0.3 // Increased Y offset effect
+	baseY := groundHorizon + shadowDepth      // Base shadow position
+
+	// Adjust shadow angle based on sun position
+	angleToSun := math.Atan2(cloud.Y-w.SunY, cloud.X-w.SunX)
+	shadowAngleAdjust := math.Sin(angleToSun) * 15 // Add some vertical displacement based on sun angle
+
+	// The shadow is drawn relative to an anchor that moves smoothly across
+	// with the cloud and snaps to whole pixels down the ground
+	return cloud.X + shadowOffsetX, math.Round(baseY + shadowOffsetY*0.3 + shadowAngleAdjust), true
+}
+
+// cloudShadowStretch is how much wider and flatter than the cloud its
+// shadow is
+func cloudShadowStretch(w *sim.World, cloud sim.Cloud) (x, y float64) {
+	heightFactor := cloud.Y / float64(w.Height)       // 0 at top, 1 at bottom
+	return 1.5 + heightFactor, 0.3 + heightFactor*0.2 // More stretch and flatter for higher clouds
+}
+
+// drawCloudShadows casts the shadows of the shown clouds on the ground,
+// leaving them out to save time in economy mode
+func (r *Painter) drawCloudShadows(screen Renderer, w *sim.World) {
+	active := w.ActiveClouds()
+	for i := 0; !r.economy() && i < len(active); i++ {
+		r.drawCloudShadow(screen, w, i, active[i])
+	}
+	// Forget the shadows of clouds thinned out or removed
+	for i := range r.cloudShadows {
+		if r.economy() || i >= len(active) {
+			r.dropCloudShadow(i)
+		}
+	}
+}
+
+// drawCloudShadow draws a cloud's shadow as thin lines fading down the
+// ground, drawn once into an image and moved along with the cloud
+func (r *Painter) drawCloudShadow(screen Renderer, w *sim.World, i int, cloud sim.Cloud) {
+	groundHorizon := float64(w.Height - scene.GroundHeight + scene.GroundOffset)
+
+	// Check if cloud is below the sun
+	anchorX, anchorY, ok := cloudShadowAnchor(w, cloud)
+	if !ok {
+		r.dropCloudShadow(i)
+		return // Skip drawing shadow
+	}
+	stretchX, stretchY := cloudShadowStretch(w, cloud)
+
+	// Simple clouds cast one ellipse from their middle, made bigger to cover
+	// about as much ground
+	lobes := w.AppendCloudLobes(r.lobes.Get(), cloud)
+	size := cloud.Size
+	if r.lowDetail(cloud.Size) {
+		lobes = centerLobe(lobes)
+		size *= 1.5
+	}
+	for j := range lobes {
+		lobes[j].DX = math.Round(lobes[j].DX/shadowLobeStep) * shadowLobeStep
+		lobes[j].DY = math.Round(lobes[j].DY/shadowLobeStep) * shadowLobeStep
+	}
+	key := cloudShadowKey{
+		anchorY: anchorY, size: size,
+		stretchX: stretchX, stretchY: stretchY,
+		alpha: uint8(cloud.Opacity * 40), color: w.Palette.Shadow,
+	}
+	shadow, ok := r.cloudShadows[i]
+	hit := ok && shadow.key == key && slices.Equal(shadow.lobes, lobes)
+	r.Stats.CloudShadows.count(hit)
+	// The cache keeps the lobes it was drawn from, handing back the ones it
+	// replaces
+	if hit {
+		r.lobes.Put(lobes)
+	} else {
+		if ok {
+			r.lobes.Put(shadow.lobes)
+			r.release(shadow.image)
+		}
+		shadow = r.drawCloudShadowImage(screen, i, key, lobes, groundHorizon)
+		r.cloudShadows[i] = shadow
+	}
+	if shadow.image != nil {
+		r.touch(shadow.image)
+		screen.DrawImage(shadow.image, anchorX+shadow.dx, anchorY+shadow.dy, 1)
+	}
+}
+
+// dropCloudShadow forgets cloud i's cached shadow, handing back its lobes
+func (r *Painter) dropCloudShadow(i int) {
+	shadow, ok := r.cloudShadows[i]
+	if !ok {
+		return
+	}
+	r.lobes.Put(shadow.lobes)
+	r.release(shadow.image)
+	delete(r.cloudShadows, i)
+}
+
+// drawCloudShadowImage draws cloud i's shadow lines into an image just big
+// enough for them
+func (r *Painter) drawCloudShadowImage(screen Renderer, i int, key cloudShadowKey, lobes []sim.Lobe, groundHorizon float64) cachedCloudShadow {
+	type shadowLine struct {
+		x1, x2, y float64
+		alpha     uint8
+	}
+	var lines []shadowLine
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	// Draw multiple overlapping shadow ellipses
+	for _, c := range lobes {
+		shadowSizeX := key.size * 0.4 * key.stretchX
+		shadowSizeY := key.size * 0.4 * key.stretchY
+
+		// Draw multiple thin ellipses to create elongated shadow
+		steps := 10
+		for i := 0; i < steps; i++ {
+			progress := float64(i) / float64(steps)
+			currentSize := shadowSizeX * (1 - progress*0.5)
+			currentY := key.anchorY + c.DY + progress*shadowSizeY
+
+			// Skip drawing if the shadow line would be above the ground horizon
+			if currentY < groundHorizon {
+				continue
+			}
+
+			// Fade out shadows more quickly near the horizon
+			fadeOffset := 1.0
+			if currentY-groundHorizon < 20 {
+				fadeOffset = (currentY - groundHorizon) / 20
+			}
+
+			line := shadowLine{
+				x1:    c.DX - currentSize,
+				x2:    c.DX + currentSize,
+				y:     c.DY + progress*shadowSizeY,
+				alpha: uint8(float64(key.alpha) * (1 - progress) * fadeOffset), // Fade out towards edges and near horizon
+			}
+			lines = append(lines, line)
+			minX, maxX = math.Min(minX, line.x1), math.Max(maxX, line.x2)
+			minY, maxY = math.Min(minY, line.y), math.Max(maxY, line.y)
+		}
+	}
+	if len(lines) == 0 {
+		return cachedCloudShadow{key: key, lobes: lobes}
+	}
+
+	// A pixel of room around the lines for their width
+	minX, minY = math.Floor(minX)-1, math.Floor(minY)-1
+	img := r.newImage(screen, int(math.Ceil(maxX-minX))+2, int(math.Ceil(maxY-minY))+2, func() { r.dropCloudShadow(i) })
+	for _, l := range lines {
+		img.DrawLine(l.x1-minX, l.y-minY, l.x2-minX, l.y-minY, withAlpha(key.color, l.alpha))
+	}
+	return cachedCloudShadow{key: key, lobes: lobes, image: img, dx: minX, dy: minY}
+}
+
+// cloudSpriteRadii are the lobe sizes pre-drawn into the cloud atlas. Each
+// lobe is stamped from the smallest sprite at least its size, so sprites are
+// only ever shrunk, and by less than half.
+var cloudSpriteRadii = []float64{8, 16, 32, 64}
+
+// cloudSprite is a white lobe drawn once and tinted to each cloud's color
+type cloudSprite struct {
+	radius float64
+	image  Renderer
+}
+
+// lobeSprite returns the atlas sprite to stamp a lobe of the given radius
+// from, drawing the atlas on first use. It returns false when the screen
+// can't stamp sprites or the lobe is bigger than any of them.
+func (r *Painter) lobeSprite(screen Renderer, radius float64) (cloudSprite, bool) {
+	if _, ok := screen.(SpriteDrawer); !ok {
+		return cloudSprite{}, false
+	}
+	if r.cloudAtlas == nil {
+		for _, rad := range cloudSpriteRadii {
+			size := int(math.Ceil(rad * 2))
+			img := r.newImage(screen, size, size, nil)
+			img.DrawCircle(rad, rad, rad, color.White)
+			r.cloudAtlas = append(r.cloudAtlas, cloudSprite{radius: rad, image: img})
+		}
+	}
+	for _, sprite := range r.cloudAtlas {
+		if radius <= sprite.radius {
+			return sprite, true
+		}
+	}
+	return cloudSprite{}, false
+}
+
+const (
+	gustStretch = 0.12 // How much longer clouds get for each unit of wind
+	gustShear   = 0.06 // How far their tops lean downwind for each unit of wind
+	gustLimit   = 4.0  // Wind beyond which clouds stretch no further
+)
+
+// cloudDeform is how the wind bends the clouds: stretched along it and
+// flattened as it picks up, keeping about the same area, with their tops
+// leaning downwind. Calm air leaves them round and puffy.
+func cloudDeform(w *sim.World) Affine {
+	wind := math.Max(0, math.Min(gustLimit, w.Wind.Speed))
+	stretch := 1 + gustStretch*wind
+	return Affine{A: stretch, B: -gustShear * wind, D: 1 / math.Sqrt(stretch)}
+}
+
+// cloudShape returns a cloud's lobes, from the pool, laid out as the wind
+// bends it, and the transform that bent them for the lobes themselves
+func (r *Painter) cloudShape(w *sim.World, cloud sim.Cloud) ([]sim.Lobe, Affine) {
+	m := cloudDeform(w)
+	lobes := w.AppendCloudLobes(r.lobes.Get(), cloud)
+	for i, l := range lobes {
+		lobes[i].DX, lobes[i].DY = m.Apply(l.DX, l.DY)
+	}
+	return lobes, m
+}
+
+// deformedRadius is how far a lobe of the given radius reaches once bent
+// by m, for bounding boxes
+func deformedRadius(radius float64, m Affine) float64 {
+	return radius * math.Max(math.Hypot(m.A, m.B), math.Hypot(m.C, m.D))
+}
+
+const (
+	backlitRange     = sim.SunRadius * 2 // How far from the sun's edge clouds start to be backlit
+	backlitDarken    = 0.35              // How much darker a cloud over the sun is
+	frontlitBrighten = 0.15              // How much brighter a cloud across the sky from the sun is
+	rimGrowth        = 1.15              // Size of a backlit rim's lobes against the cloud's
+	rimAlpha         = 0.8               // Opacity of the rim of a cloud over the sun
+)
+
+// cloudBacklight classifies a cloud against the sun. back runs from 0
+// backlitRange from the sun's edge to 1 where the cloud covers it, lit from
+// behind so the viewer sees its shaded side. front runs from 0 halfway
+// across the sky to 1 at the far side, where the sun lights the side the
+// viewer sees.
+func cloudBacklight(w *sim.World, bounds occluder, distance, maxDistance float64) (back, front float64) {
+	dx := math.Max(0, math.Max(bounds.x0-w.SunX, w.SunX-bounds.x1))
+	dy := math.Max(0, math.Max(bounds.y0-w.SunY, w.SunY-bounds.y1))
+	gap := math.Hypot(dx, dy) - sim.SunRadius
+	back = math.Max(0, math.Min(1, 1-gap/backlitRange))
+	front = math.Max(0, math.Min(1, distance/maxDistance*2-1))
+	return back, front
+}
+
+// drawCloud stamps each lobe from the cloud atlas when the screen supports
+// it, as a hundred clouds of circles a frame add up, falling back to circles.
+// Where the screen can, the stamps are bent by the wind along with the
+// layout, so the atlas never needs redrawing.
+// A cloud in front of the sun is drawn darker inside a bright see-through
+// rim, and one across the sky from it brighter.
+func (r *Painter) drawCloud(screen Renderer, w *sim.World, cloud sim.Cloud) {
+	// Calculate distance from sun to cloud
+	dx := cloud.X - w.SunX
+	dy := cloud.Y - w.SunY
+	distanceToSun := math.Sqrt(dx*dx + dy*dy)
+	maxDistance := math.Sqrt(float64(w.Width*w.Width + w.Height*w.Height))
+	sunlightFactor := math.Max(0, 1-(distanceToSun/maxDistance)) // 1 when close to sun, 0 when far
+
+	// Calculate angle to sun for directional lighting
+	angleToSun := math.Atan2(dy, dx)
+
+	// Draw multiple overlapping circles to create a cloud shape, or just the
+	// outer two a little bigger when the cloud is drawn simply
+	lobes, m := r.cloudShape(w, cloud)
+	radius := cloud.Size * 0.3
+	if r.lowDetail(cloud.Size) {
+		lobes, radius = simpleCloud(lobes, radius)
+		r.Stats.Simplified++
+	}
+	bounds := cloudBounds(cloud, lobes, deformedRadius(radius, m))
+	back, front := cloudBacklight(w, bounds, distanceToSun, maxDistance)
+	if cloud.Raining {
+		drawRain(screen, w, cloud, bounds)
+	}
+	cloudColor := rainColor(w, cloud)
+
+	stamp := func(c sim.Lobe, radius float64, col color.Color) {
+		if sprite, ok := r.lobeSprite(screen, radius); ok {
+			if warp, ok := screen.(AffineDrawer); ok {
+				s := radius / sprite.radius
+				warp.DrawSpriteAffine(sprite.image, cloud.X+c.DX, cloud.Y+c.DY, Affine{A: m.A * s, B: m.B * s, C: m.C * s, D: m.D * s}, col)
+				return
+			}
+			screen.(SpriteDrawer).DrawSprite(sprite.image, cloud.X+c.DX-radius, cloud.Y+c.DY-radius, radius/sprite.radius, col)
+			return
+		}
+		screen.DrawCircle(
+			cloud.X+c.DX,
+			cloud.Y+c.DY,
+			radius,
+			col,
+		)
+	}
+
+	// The rim is the edge of slightly bigger lobes left showing around the
+	// cloud, where sunlight scatters through its thin outer parts
+	if back > 0 {
+		rim := faint(Mix(cloudColor, w.Palette.Sun, 0.5), rimAlpha*back*cloud.Opacity)
+		for _, c := range lobes {
+			stamp(c, radius*rimGrowth, rim)
+		}
+	}
+	for _, c := range lobes {
+		// Calculate how lit this part of the cloud is based on its position relative to the sun
+		relativeAngle := math.Atan2(c.DY, c.DX) - angleToSun
+		lightingFactor := 0.7 + 0.3*math.Cos(relativeAngle) // Creates subtle variation based on position relative to sun
+		lightingFactor *= 1 - backlitDarken*back + frontlitBrighten*front
+
+		// Grayed by the water it holds, and tinted towards the sun's color
+		// when close to it
+		base := withAlpha(Mix(cloudColor, w.Palette.Sun, 0.1*sunlightFactor), uint8(cloud.Opacity*255))
+		stamp(c, radius, scaleColor(base, lightingFactor))
+	}
+	r.lobes.Put(lobes)
+}
@@ -0,0 +1,68 @@
+package render
+
+import (
+	"image/color"
+	"math"
+)
+
+// Colors are stored in sRGB, which spends more of its range on dark tones the
+// way the eye does. Light adds up linearly though, so scaling or mixing sRGB
+// values directly crushes the mid-tones into mud. Lighting is done on linear
+// values and converted back to sRGB at the end.
+
+// srgbToLinear maps each 8-bit sRGB channel value to linear light, 0-1
+var srgbToLinear = func() (table [256]float64) {
+	for i := range table {
+		c := float64(i) / 255
+		if c <= 0.04045 {
+			table[i] = c / 12.92
+		} else {
+			table[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+	return table
+}()
+
+// linearToSRGB encodes linear light back to an 8-bit sRGB channel value,
+// clamping anything brighter than white
+func linearToSRGB(v float64) uint8 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(v * 255))
+}
+
+// scaleColor brightens or darkens c by factor in linear light. Alpha is
+// coverage rather than light, so it is left alone.
+func scaleColor(c color.RGBA, factor float64) color.RGBA {
+	return color.RGBA{
+		linearToSRGB(srgbToLinear[c.R] * factor),
+		linearToSRGB(srgbToLinear[c.G] * factor),
+		linearToSRGB(srgbToLinear[c.B] * factor),
+		c.A,
+	}
+}
+
+// Mix blends from a to b by t (0-1) in linear light, so fades between
+// colors pass through even mid-tones instead of a dark dip
+func Mix(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return linearToSRGB(srgbToLinear[x] + (srgbToLinear[y]-srgbToLinear[x])*t)
+	}
+	return color.RGBA{
+		mix(a.R, b.R),
+		mix(a.G, b.G),
+		mix(a.B, b.B),
+		uint8(math.Round(float64(a.A) + (float64(b.A)-float64(a.A))*t)),
+	}
+}
+
+// withAlpha returns c with its alpha scaled by a/255, for palette colors
+// that fade in and out
+func withAlpha(c color.RGBA, a uint8) color.RGBA {
+	c.A = uint8(uint16(c.A) * uint16(a) / 255)
+	return c
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+// lowEndPuffs is the most contrail puffs drawn on the low-end path. A long
+// trail has several hundred.
+const lowEndPuffs = 80
+
+func (r *Painter) drawContrail(screen Renderer, w *sim.World) {
+	stride := 1
+	if r.LowEnd && len(w.Contrail) > lowEndPuffs {
+		stride = (len(w.Contrail) + lowEndPuffs - 1) / lowEndPuffs
+	}
+	for i := 0; i < len(w.Contrail); i += stride {
+		puff := w.Contrail[i]
+		progress := puff.Age / sim.ContrailLifetime
+
+		// Young trails are thin and bright, old ones wide and faint
+		radius := 1.5 + progress*12
+		alpha := 180 * math.Pow(1-progress, 1.5)
+		c := withAlpha(w.Palette.Contrail, uint8(alpha))
+
+		if r.LowEnd {
+			if sprite, ok := r.lobeSprite(screen, radius); ok {
+				screen.(SpriteDrawer).DrawSprite(sprite.image, puff.X-radius, puff.Y-radius, radius/sprite.radius, c)
+				continue
+			}
+		}
+		screen.DrawCircle(puff.X, puff.Y, radius, c)
+	}
+}
+
+func drawAirplane(screen Renderer, w *sim.World) {
+	plane := w.Airplane
+	if !plane.Active {
+		return
+	}
+
+	dir := math.Copysign(1, plane.Speed)
+	body := w.Palette.Airplane
+
+	// Fuselage
+	screen.DrawLine(plane.X-12*dir, plane.Y, plane.X+10*dir, plane.Y, body)
+	screen.DrawLine(plane.X-12*dir, plane.Y+1, plane.X+8*dir, plane.Y+1, body)
+
+	// Swept wings and tail fin
+	screen.DrawLine(plane.X+2*dir, plane.Y, plane.X-4*dir, plane.Y+6, body)
+	screen.DrawLine(plane.X+2*dir, plane.Y, plane.X-4*dir, plane.Y-5, body)
+	screen.DrawLine(plane.X-10*dir, plane.Y, plane.X-13*dir, plane.Y-4, body)
+}
@@ -0,0 +1,85 @@
+// Package ebitenrender draws scenes with Ebiten. It is kept apart from the
+// render package because importing Ebiten needs a display, which headless
+// programs don't have.
+package ebitenrender
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"cloudapp/pkg/render"
+)
+
+// Screen draws onto an Ebiten image, such as the screen passed to a game's
+// Draw. Shapes are drawn as vector paths without anti-aliasing, which Ebiten
+// batches into as few GPU draw calls as it can while the color source and
+// destination stay the same.
+type Screen struct {
+	Image *ebiten.Image
+}
+
+func (e Screen) Fill(c color.Color) {
+	e.Image.Fill(c)
+}
+
+func (e Screen) DrawCircle(x, y, r float64, c color.Color) {
+	vector.DrawFilledCircle(e.Image, float32(x), float32(y), float32(r), c, false)
+}
+
+func (e Screen) DrawLine(x1, y1, x2, y2 float64, c color.Color) {
+	vector.StrokeLine(e.Image, float32(x1), float32(y1), float32(x2), float32(y2), 1, c, false)
+}
+
+func (e Screen) DrawRect(x, y, width, height float64, c color.Color) {
+	vector.DrawFilledRect(e.Image, float32(x), float32(y), float32(width), float32(height), c, false)
+}
+
+func (e Screen) NewImage(width, height int) render.Renderer {
+	return Screen{ebiten.NewImage(width, height)}
+}
+
+// Dispose frees the image's texture straight away instead of leaving it to
+// the garbage collector
+func (e Screen) Dispose() {
+	e.Image.Deallocate()
+}
+
+func (e Screen) DrawImage(img render.Renderer, x, y, alpha float64) {
+	opts := &ebiten.DrawImageOptions{}
+	opts.ColorScale.ScaleAlpha(float32(alpha))
+	opts.GeoM.Translate(x, y)
+	e.Image.DrawImage(img.(Screen).Image, opts)
+}
+
+// DrawSprite scales with linear filtering, so stamped shapes keep soft edges
+// at any size
+func (e Screen) DrawSprite(img render.Renderer, x, y, scale float64, tint color.Color) {
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Scale(scale, scale)
+	opts.GeoM.Translate(x, y)
+	opts.ColorScale.ScaleWithColor(tint)
+	opts.Filter = ebiten.FilterLinear
+	e.Image.DrawImage(img.(Screen).Image, opts)
+}
+
+// DrawSpriteAffine hands the transform to Ebiten's GeoM, so stretching a
+// sprite costs no more than stamping it
+func (e Screen) DrawSpriteAffine(img render.Renderer, x, y float64, m render.Affine, tint color.Color) {
+	src := img.(Screen).Image
+	size := src.Bounds().Size()
+	var t ebiten.GeoM
+	t.SetElement(0, 0, m.A)
+	t.SetElement(0, 1, m.B)
+	t.SetElement(1, 0, m.C)
+	t.SetElement(1, 1, m.D)
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(-float64(size.X)/2, -float64(size.Y)/2)
+	opts.GeoM.Concat(t)
+	opts.GeoM.Translate(x, y)
+	opts.ColorScale.ScaleWithColor(tint)
+	opts.Filter = ebiten.FilterLinear
+	e.Image.DrawImage(src, opts)
+}
@@ -0,0 +1,127 @@
+package render
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// NamedColor is one color of an exported lighting palette
+type NamedColor struct {
+	Name  string
+	Color color.RGBA
+}
+
+// LightingColors are the scene's main colors as they look right now, lit
+// the way Draw lights them: the sky, sun and clouds, the ground in and out
+// of shadow, the foliage of the best and worst lit trees and the trunks.
+// They are opaque, for reuse as a palette in other tools.
+func LightingColors(w *sim.World) []NamedColor {
+	p := w.Palette
+	shadow := p.Shadow
+	shadow.A = 255
+
+	// The trees in the most and least light, or a full shade tree in the
+	// middle of the ground when there are none
+	bright, dim := math.Inf(-1), math.Inf(1)
+	brightShade, dimShade := 1.0, 1.0
+	for i := range w.Trees {
+		t := &w.Trees[i]
+		light := calcTreeLighting(w, t.X, t.Y)
+		if light > bright {
+			bright, brightShade = light, t.Shade
+		}
+		if light < dim {
+			dim, dimShade = light, t.Shade
+		}
+	}
+	if len(w.Trees) == 0 {
+		bright = calcTreeLighting(w, float64(w.Width)/2, w.GroundTop())
+		dim = bright
+	}
+	foliage := func(shade float64) color.RGBA {
+		return scaleColor(p.Foliage, srgbToLinear[uint8(shade*255)])
+	}
+
+	colors := []NamedColor{
+		{"Sky", p.Sky},
+		{"Sun", p.Sun},
+		{"Cloud", p.Cloud},
+		{"Ground", p.Ground},
+		{"Ground in Shadow", Mix(p.Ground, shadow, shadowAlpha*float64(p.Shadow.A)/255)},
+		{"Sunlit Foliage", blendColors(foliage(brightShade), bright, w.TreeShadow)},
+		{"Shaded Foliage", blendColors(scaleColor(foliage(dimShade), 0.5), dim, w.TreeShadow)},
+		{"Trunk", blendColors(p.Trunk, bright, w.TreeShadow)},
+		{"Trunk in Shade", blendColors(p.TrunkDark, dim, w.TreeShadow)},
+	}
+	for i := range colors {
+		colors[i].Color.A = 255
+	}
+	return colors
+}
+
+// WriteGPL writes colors as a GIMP palette, which Inkscape and Krita read
+// too
+func WriteGPL(out io.Writer, name string, colors []NamedColor) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GIMP Palette\nName: %s\nColumns: %d\n#\n", name, len(colors))
+	for _, c := range colors {
+		fmt.Fprintf(&b, "%3d %3d %3d\t%s\n", c.Color.R, c.Color.G, c.Color.B, c.Name)
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// WriteASE writes colors as an Adobe Swatch Exchange file, each an RGB
+// process color
+func WriteASE(out io.Writer, colors []NamedColor) error {
+	be := binary.BigEndian
+	buf := []byte("ASEF")
+	buf = be.AppendUint16(buf, 1) // Version 1.0
+	buf = be.AppendUint16(buf, 0)
+	buf = be.AppendUint32(buf, uint32(len(colors)))
+	for _, c := range colors {
+		name := append(utf16.Encode([]rune(c.Name)), 0)
+		buf = be.AppendUint16(buf, 0x0001) // Color entry
+		buf = be.AppendUint32(buf, uint32(2+len(name)*2+4+3*4+2))
+		buf = be.AppendUint16(buf, uint16(len(name)))
+		for _, u := range name {
+			buf = be.AppendUint16(buf, u)
+		}
+		buf = append(buf, "RGB "...)
+		for _, v := range []uint8{c.Color.R, c.Color.G, c.Color.B} {
+			buf = be.AppendUint32(buf, math.Float32bits(float32(v)/255))
+		}
+		buf = be.AppendUint16(buf, 2) // Normal rather than global or spot
+	}
+	_, err := out.Write(buf)
+	return err
+}
+
+// WriteColorsJSON writes colors as JSON, each by name with its "#rrggbb"
+// and RGB values
+func WriteColorsJSON(out io.Writer, name string, colors []NamedColor) error {
+	type entry struct {
+		Name string   `json:"name"`
+		Hex  string   `json:"hex"`
+		RGB  [3]uint8 `json:"rgb"`
+	}
+	doc := struct {
+		Name   string  `json:"name"`
+		Colors []entry `json:"colors"`
+	}{Name: name}
+	for _, c := range colors {
+		doc.Colors = append(doc.Colors, entry{c.Name, scene.HexColor(c.Color), [3]uint8{c.Color.R, c.Color.G, c.Color.B}})
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	// lodPixels is how big, in output pixels, a tree or cloud has to be
+	// drawn for its full detail to show. Anything smaller is drawn simply.
+	lodPixels = 24
+	// lodCrowd is how many trees, props and clouds a scene can hold before
+	// everything is drawn simply, whatever its size
+	lodCrowd = 200
+)
+
+// updateDetail decides, once a frame, how crowded the scene is and how big
+// it is being drawn. Only a Raster draws at other than the scene's own size.
+func (r *Painter) updateDetail(screen Renderer, w *sim.World) {
+	r.crowded = len(w.Trees)+len(w.Props)+w.ActiveCloudCount() > lodCrowd
+	r.pixelScale = 1
+	if raster, ok := screen.(*Raster); ok {
+		r.pixelScale = math.Min(raster.ScaleX, raster.ScaleY)
+	}
+}
+
+// lowDetail reports whether something size across in the scene should be
+// drawn simply this frame
+func (r *Painter) lowDetail(size float64) bool {
+	return r.crowded || size*r.pixelScale < lodPixels
+}
+
+// drawSimpleTree draws a tree at low detail as its trunk under a single
+// round crown, straight onto the screen rather than through a cached image
+func drawSimpleTree(screen Renderer, w *sim.World, tree *sim.Tree, lightFactor float64) {
+	trunkWidth := tree.Size * 0.2
+	trunkHeight := tree.Size * 0.4
+	screen.DrawRect(tree.X-trunkWidth/2, tree.Y-trunkHeight, trunkWidth, trunkHeight,
+		blendColors(w.Palette.Trunk, lightFactor, w.TreeShadow))
+
+	foliage := scaleColor(w.Palette.Foliage, srgbToLinear[uint8(tree.Shade*255)])
+	radius := math.Min(tree.Size*0.5, (tree.Height()-trunkHeight)/2)
+	screen.DrawCircle(tree.X, tree.Y-trunkHeight-radius, radius, blendColors(foliage, lightFactor, w.TreeShadow))
+}
+
+// simpleCloud keeps a cloud's two outermost lobes, in place, and returns
+// the radius to draw them at, grown so they overlap and cover the ones
+// between
+func simpleCloud(lobes []sim.Lobe, radius float64) ([]sim.Lobe, float64) {
+	if len(lobes) <= 2 {
+		return lobes, radius
+	}
+	first, last := lobes[0], lobes[len(lobes)-1]
+	gap := math.Hypot(last.DX-first.DX, last.DY-first.DY)
+	lobes[1] = last
+	return lobes[:2], math.Max(radius*1.25, gap*0.6)
+}
+
+// centerLobe replaces a cloud's lobes, in place, with a single one in their
+// middle, for casting its shadow as one ellipse
+func centerLobe(lobes []sim.Lobe) []sim.Lobe {
+	var c sim.Lobe
+	for _, l := range lobes {
+		c.DX += l.DX / float64(len(lobes))
+		c.DY += l.DY / float64(len(lobes))
+	}
+	return append(lobes[:0], c)
+}
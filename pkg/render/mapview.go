@@ -0,0 +1,110 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	mapArrowSpacing = 48.0 // Map pixels between wind arrows
+	mapArrowRows    = 3
+)
+
+var (
+	mapBorder = color.RGBA{0, 0, 0, 200}
+	mapArrow  = color.RGBA{255, 255, 255, 170}
+)
+
+// Map is a top-down view of the ground drawn into a rectangle on the
+// screen: left to right as in the scene, and from the horizon at the top
+// to the front of the ground at the bottom. Trees and props show as dots,
+// cloud shadows as blobs moving with their clouds, the sun as a marker on
+// the top edge and the wind as arrows drifting with it.
+type Map struct {
+	X, Y, Width, Height float64
+}
+
+// point converts a spot on the ground to the map
+func (m Map) point(w *sim.World, x, y float64) (float64, float64) {
+	top := w.GroundTop()
+	return m.X + x/float64(w.Width)*m.Width,
+		m.Y + (y-top)/(float64(w.Height)-top)*m.Height
+}
+
+// Ground converts a spot on the map back to the ground it shows
+func (m Map) Ground(w *sim.World, x, y float64) (float64, float64) {
+	top := w.GroundTop()
+	return (x - m.X) / m.Width * float64(w.Width),
+		top + (y-m.Y)/m.Height*(float64(w.Height)-top)
+}
+
+// Contains reports whether a screen position is on the map
+func (m Map) Contains(x, y float64) bool {
+	return x >= m.X && x < m.X+m.Width && y >= m.Y && y < m.Y+m.Height
+}
+
+// Draw draws the world from above
+func (m Map) Draw(screen Renderer, w *sim.World) {
+	p := w.Palette
+	screen.DrawRect(m.X-2, m.Y-2, m.Width+4, m.Height+4, mapBorder)
+	screen.DrawRect(m.X, m.Y, m.Width, m.Height, p.Ground)
+	scale := m.Width / float64(w.Width)
+	// Shapes near the edges shrink rather than spill over them
+	fit := func(x, y, r float64) float64 {
+		return math.Min(r, math.Min(math.Min(x-m.X, m.X+m.Width-x), math.Min(y-m.Y, m.Y+m.Height-y)))
+	}
+
+	// Cloud shadows, a blob for each lobe
+	shadow := p.Shadow
+	for _, cloud := range w.ActiveClouds() {
+		ax, ay, ok := cloudShadowAnchor(w, cloud)
+		if !ok {
+			continue
+		}
+		stretch, _ := cloudShadowStretch(w, cloud)
+		r := math.Max(2, cloud.Size*0.4*stretch*scale)
+		shadow.A = uint8(float64(p.Shadow.A) * cloud.Opacity * shadowAlpha)
+		for _, l := range w.AppendCloudLobes(nil, cloud) {
+			x, y := m.point(w, ax+l.DX, ay+l.DY)
+			if r := fit(x, y, r); r > 1 {
+				screen.DrawCircle(x, y, r, shadow)
+			}
+		}
+	}
+
+	for i := range w.Props {
+		prop := &w.Props[i]
+		x, y := m.point(w, prop.X, prop.Y)
+		screen.DrawRect(x-2, y-2, 4, 4, p.Wood)
+	}
+	for i := range w.Trees {
+		tree := &w.Trees[i]
+		x, y := m.point(w, tree.X, tree.Y)
+		r := fit(x, y, math.Max(2, tree.Size*0.3*scale))
+		if r <= 0 {
+			continue
+		}
+		screen.DrawCircle(x, y, r+1, p.TrunkDark)
+		screen.DrawCircle(x, y, r, scaleColor(p.Foliage, srgbToLinear[uint8(tree.Shade*255)]))
+	}
+
+	// The wind blows left to right, the arrows lengthening and drifting
+	// faster as it picks up
+	length := math.Min(mapArrowSpacing*0.8, 6+w.Wind.Speed*6)
+	drift := math.Mod(w.SimTime*w.Wind.Speed*10, mapArrowSpacing)
+	for row := 0; row < mapArrowRows; row++ {
+		y := m.Y + m.Height*(float64(row)+0.5)/mapArrowRows
+		offset := drift + float64(row%2)*mapArrowSpacing/2
+		for x := m.X + math.Mod(offset, mapArrowSpacing); x+length < m.X+m.Width; x += mapArrowSpacing {
+			screen.DrawLine(x, y, x+length, y, mapArrow)
+			screen.DrawLine(x+length-3, y-3, x+length, y, mapArrow)
+			screen.DrawLine(x+length-3, y+3, x+length, y, mapArrow)
+		}
+	}
+
+	// Where the sun stands along the scene
+	sunX := m.X + math.Max(0, math.Min(m.Width, w.SunX*scale))
+	screen.DrawCircle(sunX, m.Y, 4, p.Sun)
+}
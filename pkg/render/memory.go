@@ -0,0 +1,89 @@
+package render
+
+import (
+	"slices"
+)
+
+// imageUse is one cached image's size and when it was last drawn
+type imageUse struct {
+	image    Renderer
+	bytes    int64
+	lastUsed int    // Frame it was last drawn in
+	evict    func() // Drops it from the cache holding it, nil for images kept for good
+}
+
+// imageCache tracks the offscreen images a Painter keeps, so the memory they
+// take can be reported and the least recently drawn dropped once they take
+// more than the budget
+type imageCache struct {
+	uses    map[Renderer]*imageUse
+	frame   int
+	bytes   int64
+	evicted int
+}
+
+// imageBytes is the memory an image is reckoned to take, four bytes a pixel
+func imageBytes(width, height int) int64 {
+	return int64(max(0, width)) * int64(max(0, height)) * 4
+}
+
+// newImage makes an offscreen image and starts tracking it. evict is called
+// to drop it from its cache when it is evicted, and must release it.
+func (r *Painter) newImage(screen Renderer, width, height int, evict func()) Renderer {
+	img := screen.NewImage(width, height)
+	c := &r.images
+	if c.uses == nil {
+		c.uses = map[Renderer]*imageUse{}
+	}
+	c.uses[img] = &imageUse{image: img, bytes: imageBytes(width, height), lastUsed: c.frame, evict: evict}
+	c.bytes += imageBytes(width, height)
+	return img
+}
+
+// touch marks an image as drawn this frame
+func (r *Painter) touch(img Renderer) {
+	if u, ok := r.images.uses[img]; ok {
+		u.lastUsed = r.images.frame
+	}
+}
+
+// release stops tracking an image its cache has dropped and frees it. It
+// does nothing for nil or an image already released.
+func (r *Painter) release(img Renderer) {
+	c := &r.images
+	u, ok := c.uses[img]
+	if img == nil || !ok {
+		return
+	}
+	delete(c.uses, img)
+	c.bytes -= u.bytes
+	if d, ok := img.(Disposer); ok {
+		d.Dispose()
+	}
+}
+
+// trimImages evicts the least recently drawn images until they fit the
+// budget, and reports their memory in Stats. Images drawn this frame are
+// kept even over the budget, as they would only be made again next frame.
+func (r *Painter) trimImages() {
+	c := &r.images
+	if r.ImageBudget > 0 && c.bytes > r.ImageBudget {
+		var stale []*imageUse
+		for _, u := range c.uses {
+			if u.evict != nil && u.lastUsed < c.frame {
+				stale = append(stale, u)
+			}
+		}
+		slices.SortFunc(stale, func(a, b *imageUse) int { return a.lastUsed - b.lastUsed })
+		for _, u := range stale {
+			if c.bytes <= r.ImageBudget {
+				break
+			}
+			u.evict()
+			r.release(u.image)
+			c.evicted++
+		}
+	}
+	r.Stats.Images, r.Stats.ImageBytes, r.Stats.Evicted = len(c.uses), c.bytes, c.evicted
+	c.frame++
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"slices"
+
+	"cloudapp/pkg/sim"
+)
+
+// objectShadowKey is everything a ground entity's cached shadow image
+// depends on besides its parts
+type objectShadowKey struct {
+	x, y       float64
+	sunX, sunY float64
+	scale      float64
+}
+
+// cachedObjectShadow is a ground entity's shadow image, kept until the
+// entity moves, changes shape or the sun moves
+type cachedObjectShadow struct {
+	key   objectShadowKey
+	parts []sim.ShadowPart
+	image Renderer
+}
+
+// drawObjectShadow casts the shadow of a ground entity other than a tree,
+// such as a custom scene object, the same way tree shadows are cast
+func (r *Painter) drawObjectShadow(screen Renderer, w *sim.World, c sim.ShadowCaster) {
+	parts := c.ShadowParts()
+	if len(parts) == 0 {
+		return
+	}
+	x, y := c.Base()
+
+	// How tall and wide the object is decides how long and how far around
+	// its shadow can reach
+	var height, width float64
+	for _, p := range parts {
+		height = math.Max(height, math.Max(p.H1, p.H2)+p.Radius)
+		width = math.Max(width, math.Max(math.Abs(p.X1), math.Abs(p.X2))+p.Radius)
+	}
+	if height <= 0 {
+		return
+	}
+	// Trees stand about one and a half times their size tall
+	shadowLength, shadowAngle := w.ShadowGeometryAt(x, y, height/1.5)
+	reach := shadowLength * 0.8
+	pad := reach + width
+
+	key := objectShadowKey{x, y, w.SunX, w.SunY, w.TreeShadow}
+	shadow, ok := r.objectShadows[c]
+	hit := ok && shadow.key == key && slices.Equal(shadow.parts, parts)
+	r.Stats.ObjectShadows.count(hit)
+	if !hit {
+		r.release(shadow.image)
+		shadow = cachedObjectShadow{key: key, parts: slices.Clone(parts), image: r.newImage(screen, int(pad*2), int(pad*2), func() { r.dropObjectShadow(c) })}
+		k := reach / height
+		caster := shadowCaster{
+			img:   shadow.image,
+			baseX: pad,
+			baseY: pad,
+			dirX:  math.Cos(shadowAngle) * k,
+			dirY:  math.Sin(shadowAngle) * k,
+			color: color.RGBA{w.Palette.Shadow.R, w.Palette.Shadow.G, w.Palette.Shadow.B, 255},
+		}
+		for _, p := range parts {
+			caster.sweep(p.X1, p.H1, p.X2, p.H2, func(float64) float64 { return p.Radius })
+		}
+		r.objectShadows[c] = shadow
+	}
+	r.touch(shadow.image)
+	screen.DrawImage(shadow.image, x-pad, y-pad, shadowAlpha*float64(w.Palette.Shadow.A)/255)
+}
+
+// forgetObjectShadows drops the shadows of entities no longer on the ground
+func (r *Painter) forgetObjectShadows(w *sim.World) {
+	if len(r.objectShadows) == 0 {
+		return
+	}
+	ground := w.Entities(sim.LayerGround)
+	for c := range r.objectShadows {
+		if !slices.ContainsFunc(ground, func(e sim.Entity) bool { return any(e) == any(c) }) {
+			r.dropObjectShadow(c)
+		}
+	}
+}
+
+// dropObjectShadow forgets a ground entity's cached shadow
+func (r *Painter) dropObjectShadow(c sim.ShadowCaster) {
+	r.release(r.objectShadows[c].image)
+	delete(r.objectShadows, c)
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	probeCell     = 16 // Size of each heatmap cell over the ground
+	probeAlpha    = 110
+	probeBand     = 50 // Added to alternate bands' alpha
+	gaugeWidth    = 24
+	gaugeHeight   = 4
+	gaugeGap      = 8   // Between a gauge and the top of its tree
+	minLightLevel = 0.4 // The darkest calcTreeLighting gets
+)
+
+var (
+	probeDark  = color.RGBA{40, 60, 200, 255} // Least light
+	probeMid   = color.RGBA{220, 50, 60, 255}
+	probeLight = color.RGBA{255, 220, 40, 255} // Most light
+)
+
+// LightFactor is how brightly a tree standing at x, y is lit, from 0.4 in
+// the far corners with the sun low to 1 right under a high sun. Trees are
+// drawn lit by this before the shadow scale is applied.
+func LightFactor(w *sim.World, x, y float64) float64 {
+	return calcTreeLighting(w, x, y)
+}
+
+// DrawLightProbes draws the lighting model for tuning it: the light factor a
+// tree would get as a heatmap over the ground, from blue at the darkest to
+// yellow at the brightest, and a gauge above each tree showing its own.
+// Every other lightStep band is drawn stronger, so even a shallow slope
+// shows, along with where cached tree images get redrawn.
+func DrawLightProbes(screen Renderer, w *sim.World) {
+	for y := w.GroundTop(); y < float64(w.Height); y += probeCell {
+		for x := 0.0; x < float64(w.Width); x += probeCell {
+			light := LightFactor(w, x+probeCell/2, y+probeCell/2)
+			c := probeColor(light)
+			alpha := uint8(probeAlpha)
+			if int(light/lightStep)%2 == 1 {
+				alpha += probeBand
+			}
+			screen.DrawRect(x, y, probeCell, probeCell, color.NRGBA{c.R, c.G, c.B, alpha})
+		}
+	}
+
+	for i := range w.Trees {
+		tree := &w.Trees[i]
+		light := LightFactor(w, tree.X, tree.Y)
+		x, y := tree.X-gaugeWidth/2, tree.Y-tree.Height()-gaugeGap-gaugeHeight
+		screen.DrawRect(x-1, y-1, gaugeWidth+2, gaugeHeight+2, color.RGBA{0, 0, 0, 200})
+		screen.DrawRect(x, y, gaugeWidth*probeLevel(light), gaugeHeight, probeColor(light))
+	}
+}
+
+// probeLevel is where a light factor falls between the darkest and
+// brightest the model gives, 0-1
+func probeLevel(light float64) float64 {
+	return math.Max(0, math.Min(1, (light-minLightLevel)/(1-minLightLevel)))
+}
+
+// probeColor runs from blue through red to yellow, as a straight blend of
+// blue and yellow passes through a gray that reads as neither
+func probeColor(light float64) color.RGBA {
+	level := probeLevel(light)
+	if level < 0.5 {
+		return Mix(probeDark, probeMid, level*2)
+	}
+	return Mix(probeMid, probeLight, level*2-1)
+}
@@ -0,0 +1,161 @@
+package render
+
+import (
+	"math"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+func drawProp(screen Renderer, w *sim.World, prop *sim.Prop) {
+	switch prop.Kind {
+	case scene.PropWindTurbine:
+		drawWindTurbine(screen, w, prop)
+	case scene.PropWindsock:
+		drawWindsock(screen, w, prop)
+	case scene.PropBirdhouse:
+		drawBirdhouse(screen, w, prop)
+	case scene.PropFeeder:
+		drawFeeder(screen, w, prop)
+	case scene.PropScarecrow:
+		drawScarecrow(screen, w, prop)
+	}
+}
+
+func drawWindTurbine(screen Renderer, w *sim.World, prop *sim.Prop) {
+	hubX := prop.X
+	hubY := prop.Y - prop.Size
+	lightFactor := calcTreeLighting(w, prop.X, prop.Y)
+	towerColor := blendColors(w.Palette.Turbine, lightFactor, 1.0)
+	bladeColor := blendColors(w.Palette.TurbineBlade, lightFactor, 1.0)
+
+	// Tapered tower
+	for i := 0.0; i < 3; i++ {
+		screen.DrawLine(prop.X-1.5+i, prop.Y, hubX-0.5+i*0.5, hubY, towerColor)
+	}
+
+	// Three blades spinning in the screen plane
+	bladeLength := prop.Size * 0.45
+	for i := 0; i < 3; i++ {
+		angle := prop.Angle + float64(i)*2*math.Pi/3
+		tipX := hubX + math.Cos(angle)*bladeLength
+		tipY := hubY + math.Sin(angle)*bladeLength
+		screen.DrawLine(hubX, hubY, tipX, tipY, bladeColor)
+		screen.DrawLine(hubX+1, hubY, tipX+1, tipY, bladeColor)
+	}
+
+	screen.DrawCircle(hubX, hubY, 3, towerColor)
+}
+
+func drawWindsock(screen Renderer, w *sim.World, prop *sim.Prop) {
+	topX := prop.X
+	topY := prop.Y - prop.Size
+
+	// Pole
+	screen.DrawLine(prop.X, prop.Y, topX, topY, w.Palette.Pole)
+	screen.DrawLine(prop.X+1, prop.Y, topX+1, topY, scaleColor(w.Palette.Pole, 0.5))
+
+	// The sock hangs straight down when calm and streams downwind as the wind
+	// picks up, fully horizontal at around 1.5x the base wind
+	lift := math.Min(1, w.Wind.Speed/1.5)
+	droop := (1 - lift) * math.Pi / 2
+	flutter := math.Sin(prop.Angle) * 0.08 * lift
+	angle := droop + flutter
+
+	length := prop.Size * 0.5
+	segments := 5
+	for i := 0; i < segments; i++ {
+		start := float64(i) / float64(segments) * length
+		end := float64(i+1) / float64(segments) * length
+		width := 5 * (1 - float64(i)*0.12)
+
+		stripe := w.Palette.Windsock
+		if i%2 == 1 {
+			stripe = w.Palette.WindsockStripe
+		}
+
+		// Fill across the sock, perpendicular to the direction it points
+		for w := -width / 2; w <= width/2; w++ {
+			offX := -math.Sin(angle) * w
+			offY := math.Cos(angle) * w
+			screen.DrawLine(
+				topX+math.Cos(angle)*start+offX,
+				topY+math.Sin(angle)*start+offY,
+				topX+math.Cos(angle)*end+offX,
+				topY+math.Sin(angle)*end+offY,
+				stripe,
+			)
+		}
+	}
+}
+
+func drawBirdhouse(screen Renderer, w *sim.World, prop *sim.Prop) {
+	lightFactor := calcTreeLighting(w, prop.X, prop.Y)
+	wood := blendColors(w.Palette.Wood, lightFactor, 1.0)
+	roof := blendColors(w.Palette.Roof, lightFactor, 1.0)
+
+	boxTop := prop.Y - prop.Size
+	boxHeight := prop.Size * 0.35
+	boxWidth := prop.Size * 0.4
+
+	// Post
+	screen.DrawRect(prop.X-1.5, boxTop+boxHeight, 3, prop.Size-boxHeight, wood)
+
+	// Box with entrance hole
+	screen.DrawRect(prop.X-boxWidth/2, boxTop, boxWidth, boxHeight, wood)
+	screen.DrawCircle(prop.X, boxTop+boxHeight*0.45, boxWidth*0.15, w.Palette.BirdhouseHole)
+
+	// Pitched roof
+	for i := 0.0; i < boxHeight*0.6; i++ {
+		width := (boxWidth + 6) * (1 - i/(boxHeight*0.6))
+		screen.DrawLine(prop.X-width/2, boxTop-i, prop.X+width/2, boxTop-i, roof)
+	}
+}
+
+func drawFeeder(screen Renderer, w *sim.World, prop *sim.Prop) {
+	lightFactor := calcTreeLighting(w, prop.X, prop.Y)
+	wood := blendColors(w.Palette.Wood, lightFactor, 1.0)
+	seed := blendColors(w.Palette.Seed, lightFactor, 1.0)
+
+	trayY := prop.Y - prop.Size
+	trayWidth := prop.Size * 0.7
+
+	// Post and tray
+	screen.DrawRect(prop.X-1.5, trayY, 3, prop.Size, wood)
+	screen.DrawRect(prop.X-trayWidth/2, trayY, trayWidth, 3, wood)
+
+	// Scattered seed on the tray
+	for i := 0; i < 5; i++ {
+		screen.DrawCircle(prop.X-trayWidth*0.35+float64(i)*trayWidth*0.17, trayY-1, 1.5, seed)
+	}
+}
+
+func drawScarecrow(screen Renderer, w *sim.World, prop *sim.Prop) {
+	lightFactor := calcTreeLighting(w, prop.X, prop.Y)
+	wood := blendColors(w.Palette.Wood, lightFactor*0.8, 1.0)
+	shirt := blendColors(w.Palette.Shirt, lightFactor, 1.0)
+	straw := blendColors(w.Palette.Straw, lightFactor, 1.0)
+
+	// The arms rock gently in the wind
+	sway := math.Sin(prop.Angle) * 2 * w.Wind.Speed
+	armY := prop.Y - prop.Size*0.7
+	armSpan := prop.Size * 0.4
+
+	// Post and crossbar
+	screen.DrawRect(prop.X-1.5, prop.Y-prop.Size*0.85, 3, prop.Size*0.85, wood)
+	screen.DrawLine(prop.X-armSpan, armY+sway, prop.X+armSpan, armY-sway, wood)
+
+	// Shirt hanging off the crossbar
+	screen.DrawRect(prop.X-prop.Size*0.12, armY-2, prop.Size*0.24, prop.Size*0.3, shirt)
+	screen.DrawLine(prop.X-armSpan*0.8, armY+sway*0.8+1, prop.X+armSpan*0.8, armY-sway*0.8+1, shirt)
+
+	// Straw tufts at the cuffs
+	screen.DrawLine(prop.X-armSpan, armY+sway, prop.X-armSpan-4, armY+sway+4, straw)
+	screen.DrawLine(prop.X+armSpan, armY-sway, prop.X+armSpan+4, armY-sway+4, straw)
+
+	// Sack head and wide straw hat
+	headY := prop.Y - prop.Size*0.85
+	screen.DrawCircle(prop.X, headY, prop.Size*0.1, blendColors(w.Palette.ScarecrowHead, lightFactor, 1.0))
+	screen.DrawLine(prop.X-prop.Size*0.18, headY-prop.Size*0.08, prop.X+prop.Size*0.18, headY-prop.Size*0.08, straw)
+	screen.DrawRect(prop.X-prop.Size*0.08, headY-prop.Size*0.18, prop.Size*0.16, prop.Size*0.1, straw)
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	rainDarken   = 0.45 // How dark a full cloud is, as a share of the palette's cloud color
+	rainSpacing  = 8.0  // Pixels between the columns of rain under a cloud
+	rainDrops    = 3    // Streaks falling down each column at once
+	rainLength   = 10.0 // Length of each streak
+	rainFall     = 300  // How fast the streaks fall, in pixels a second
+	rainSlant    = 0.15 // Sideways drift of the streaks for each unit of wind speed
+	rainAlpha    = 0.8  // Opacity of the streaks under a fully opaque cloud
+	rainDripping = 0.3  // Share of the cloud's height the rain starts above its base
+)
+
+// rainColor darkens the palette's cloud color towards gray as a cloud fills
+// with water, so clouds about to rain stand out before they do
+func rainColor(w *sim.World, cloud sim.Cloud) color.RGBA {
+	return Mix(w.Palette.Cloud, scaleColor(w.Palette.Cloud, rainDarken), cloud.Water)
+}
+
+// drawRain draws streaks falling from under a raining cloud to the ground,
+// slanting with the wind
+func drawRain(screen Renderer, w *sim.World, cloud sim.Cloud, bounds occluder) {
+	top := bounds.y1 - (bounds.y1-bounds.y0)*rainDripping
+	fall := w.GroundTop() - top
+	if fall <= rainLength {
+		return
+	}
+	col := faint(rainColor(w, cloud), rainAlpha*cloud.Opacity)
+	slant := w.Wind.Speed * rainSlant
+	columns := int((bounds.x1 - bounds.x0) / rainSpacing)
+	for i := range columns {
+		x := bounds.x0 + (float64(i)+0.5)*rainSpacing
+		// Each column starts at its own point in the fall so they don't
+		// move in step
+		offset := math.Abs(math.Sin(float64(i)*12.9898+cloud.ShapeSeed)) * fall
+		for d := range rainDrops {
+			y := math.Mod(w.MotionTime*rainFall+offset+float64(d)*fall/rainDrops, fall)
+			y0, y1 := top+y, top+math.Min(fall, y+rainLength)
+			screen.DrawLine(x+slant*y, y0, x+slant*(y1-top), y1, col)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Raster draws into an image.RGBA in software, so frames can be made without
+// a window or GPU, such as thumbnails on a server. Scene coordinates are
+// scaled by ScaleX and ScaleY to fit the image.
+type Raster struct {
+	Image          *image.RGBA
+	ScaleX, ScaleY float64
+}
+
+// NewRaster makes a width x height image for a scene of sceneWidth x
+// sceneHeight
+func NewRaster(width, height, sceneWidth, sceneHeight int) *Raster {
+	return &Raster{
+		Image:  image.NewRGBA(image.Rect(0, 0, width, height)),
+		ScaleX: float64(width) / float64(sceneWidth),
+		ScaleY: float64(height) / float64(sceneHeight),
+	}
+}
+
+func (r *Raster) Fill(c color.Color) {
+	draw.Draw(r.Image, r.Image.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func (r *Raster) DrawCircle(x, y, radius float64, c color.Color) {
+	cx, cy := x*r.ScaleX, y*r.ScaleY
+	rx, ry := radius*r.ScaleX, radius*r.ScaleY
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	r.fillPixels(cx-rx, cy-ry, cx+rx, cy+ry, c, func(px, py float64) bool {
+		dx, dy := (px-cx)/rx, (py-cy)/ry
+		return dx*dx+dy*dy <= 1
+	})
+}
+
+// DrawLine draws a line one scene pixel wide
+func (r *Raster) DrawLine(x1, y1, x2, y2 float64, c color.Color) {
+	x1, y1, x2, y2 = x1*r.ScaleX, y1*r.ScaleY, x2*r.ScaleX, y2*r.ScaleY
+	half := math.Max(0.5, (r.ScaleX+r.ScaleY)/4)
+	dx, dy := x2-x1, y2-y1
+	lengthSq := dx*dx + dy*dy
+	r.fillPixels(math.Min(x1, x2)-half, math.Min(y1, y2)-half, math.Max(x1, x2)+half, math.Max(y1, y2)+half, c,
+		func(px, py float64) bool {
+			// Distance from the pixel center to the nearest point on the segment
+			t := 0.0
+			if lengthSq > 0 {
+				t = math.Max(0, math.Min(1, ((px-x1)*dx+(py-y1)*dy)/lengthSq))
+			}
+			ex, ey := px-(x1+t*dx), py-(y1+t*dy)
+			return ex*ex+ey*ey <= half*half
+		})
+}
+
+func (r *Raster) DrawRect(x, y, width, height float64, c color.Color) {
+	r.fillPixels(x*r.ScaleX, y*r.ScaleY, (x+width)*r.ScaleX, (y+height)*r.ScaleY, c, nil)
+}
+
+func (r *Raster) NewImage(width, height int) Renderer {
+	w := int(math.Ceil(float64(width) * r.ScaleX))
+	h := int(math.Ceil(float64(height) * r.ScaleY))
+	return &Raster{
+		Image:  image.NewRGBA(image.Rect(0, 0, w, h)),
+		ScaleX: r.ScaleX,
+		ScaleY: r.ScaleY,
+	}
+}
+
+func (r *Raster) DrawImage(img Renderer, x, y, alpha float64) {
+	src := img.(*Raster).Image
+	at := image.Pt(int(math.Round(x*r.ScaleX)), int(math.Round(y*r.ScaleY)))
+	mask := image.NewUniform(color.Alpha{uint8(math.Round(255 * math.Max(0, math.Min(1, alpha))))})
+	draw.DrawMask(r.Image, src.Bounds().Add(at), src, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// DrawSprite samples the nearest source pixel for each pixel covered, which
+// is plenty for the soft shapes it's used for. The sprite may have been made
+// by a Raster of another scale, such as an earlier frame of another size.
+func (r *Raster) DrawSprite(img Renderer, x, y, scale float64, tint color.Color) {
+	sprite := img.(*Raster)
+	src := sprite.Image
+	scaleX, scaleY := scale*r.ScaleX/sprite.ScaleX, scale*r.ScaleY/sprite.ScaleY
+	if scaleX <= 0 || scaleY <= 0 {
+		return
+	}
+	x0, y0 := x*r.ScaleX, y*r.ScaleY
+	size := src.Bounds().Size()
+	bounds := r.Image.Bounds()
+	minX := max(bounds.Min.X, int(math.Floor(x0)))
+	minY := max(bounds.Min.Y, int(math.Floor(y0)))
+	maxX := min(bounds.Max.X, int(math.Ceil(x0+float64(size.X)*scaleX)))
+	maxY := min(bounds.Max.Y, int(math.Ceil(y0+float64(size.Y)*scaleY)))
+
+	tr, tg, tb, ta := tint.RGBA()
+	for py := minY; py < maxY; py++ {
+		sy := int((float64(py) + 0.5 - y0) / scaleY)
+		if sy < 0 || sy >= size.Y {
+			continue
+		}
+		for px := minX; px < maxX; px++ {
+			sx := int((float64(px) + 0.5 - x0) / scaleX)
+			if sx < 0 || sx >= size.X {
+				continue
+			}
+			r.stampPixel(px, py, src, sx, sy, tr, tg, tb, ta)
+		}
+	}
+}
+
+// DrawSpriteAffine maps each pixel covered back through m to the nearest
+// source pixel, like DrawSprite
+func (r *Raster) DrawSpriteAffine(img Renderer, x, y float64, m Affine, tint color.Color) {
+	sprite := img.(*Raster)
+	src := sprite.Image
+	det := m.A*m.D - m.B*m.C
+	if det == 0 {
+		return
+	}
+	size := src.Bounds().Size()
+	// Half the sprite's size, and of the box it covers once transformed,
+	// in scene units
+	hw, hh := float64(size.X)/sprite.ScaleX/2, float64(size.Y)/sprite.ScaleY/2
+	ex := math.Abs(m.A)*hw + math.Abs(m.B)*hh
+	ey := math.Abs(m.C)*hw + math.Abs(m.D)*hh
+	bounds := r.Image.Bounds()
+	minX := max(bounds.Min.X, int(math.Floor((x-ex)*r.ScaleX)))
+	minY := max(bounds.Min.Y, int(math.Floor((y-ey)*r.ScaleY)))
+	maxX := min(bounds.Max.X, int(math.Ceil((x+ex)*r.ScaleX)))
+	maxY := min(bounds.Max.Y, int(math.Ceil((y+ey)*r.ScaleY)))
+
+	tr, tg, tb, ta := tint.RGBA()
+	for py := minY; py < maxY; py++ {
+		dy := (float64(py)+0.5)/r.ScaleY - y
+		for px := minX; px < maxX; px++ {
+			dx := (float64(px)+0.5)/r.ScaleX - x
+			u := (m.D*dx - m.B*dy) / det
+			v := (m.A*dy - m.C*dx) / det
+			sx, sy := int((u+hw)*sprite.ScaleX), int((v+hh)*sprite.ScaleY)
+			if u+hw < 0 || v+hh < 0 || sx >= size.X || sy >= size.Y {
+				continue
+			}
+			r.stampPixel(px, py, src, sx, sy, tr, tg, tb, ta)
+		}
+	}
+}
+
+// stampPixel blends source pixel sx, sy, with its colors multiplied by the
+// tint, over pixel px, py
+func (r *Raster) stampPixel(px, py int, src *image.RGBA, sx, sy int, tr, tg, tb, ta uint32) {
+	j := src.PixOffset(sx, sy)
+	s := src.Pix[j : j+4 : j+4]
+	sa := uint32(s[3]) * 0x101 * ta / 0xffff
+	if sa == 0 {
+		return
+	}
+	keep := 0xffff - sa
+	i := r.Image.PixOffset(px, py)
+	p := r.Image.Pix[i : i+4 : i+4]
+	p[0] = over(uint32(s[0])*0x101*tr/0xffff, p[0], keep)
+	p[1] = over(uint32(s[1])*0x101*tg/0xffff, p[1], keep)
+	p[2] = over(uint32(s[2])*0x101*tb/0xffff, p[2], keep)
+	p[3] = over(sa, p[3], keep)
+}
+
+// fillPixels blends c over every pixel in the box whose center is inside,
+// or every pixel in the box when inside is nil
+func (r *Raster) fillPixels(x0, y0, x1, y1 float64, c color.Color, inside func(px, py float64) bool) {
+	bounds := r.Image.Bounds()
+	minX := max(bounds.Min.X, int(math.Floor(x0)))
+	minY := max(bounds.Min.Y, int(math.Floor(y0)))
+	maxX := min(bounds.Max.X, int(math.Ceil(x1)))
+	maxY := min(bounds.Max.Y, int(math.Ceil(y1)))
+
+	sr, sg, sb, sa := c.RGBA()
+	if sa == 0 {
+		return
+	}
+	keep := 0xffff - sa
+	for py := minY; py < maxY; py++ {
+		for px := minX; px < maxX; px++ {
+			if inside != nil && !inside(float64(px)+0.5, float64(py)+0.5) {
+				continue
+			}
+			// Source over destination. Colors in the scene aren't always
+			// validly premultiplied, so the sum saturates like it does on
+			// the GPU rather than wrapping around.
+			i := r.Image.PixOffset(px, py)
+			p := r.Image.Pix[i : i+4 : i+4]
+			p[0] = over(sr, p[0], keep)
+			p[1] = over(sg, p[1], keep)
+			p[2] = over(sb, p[2], keep)
+			p[3] = over(sa, p[3], keep)
+		}
+	}
+}
+
+// over adds a 16-bit source channel to an 8-bit destination channel scaled
+// by keep/0xffff, clamping at full
+func over(src uint32, dst uint8, keep uint32) uint8 {
+	return uint8(min(0xffff, src+uint32(dst)*0x101*keep/0xffff) >> 8)
+}
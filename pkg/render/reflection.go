@@ -0,0 +1,55 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+const (
+	reflectionWetness = 0.5  // Wetness at which reflections start to show
+	reflectionAlpha   = 0.25 // Opacity of reflections on soaked ground
+	reflectionSquash  = 0.35 // How much reflected heights shrink, like a view across a puddle
+)
+
+// drawReflections mirrors the sun and clouds faintly in wet ground, flipped
+// about the horizon and fading with distance from it
+func drawReflections(screen Renderer, w *sim.World) {
+	strength := (w.Wetness - reflectionWetness) / (1 - reflectionWetness)
+	if strength <= 0 {
+		return
+	}
+	horizon := float64(w.Height - scene.GroundHeight + scene.GroundOffset)
+	bottom := float64(w.Height)
+
+	// reflect maps a sky height to the ground and says how visible it is there
+	reflect := func(y float64) (float64, float64) {
+		ry := horizon + (horizon-y)*reflectionSquash
+		if ry < horizon || ry > bottom {
+			return ry, 0
+		}
+		return ry, strength * reflectionAlpha * (1 - (ry-horizon)/(bottom-horizon))
+	}
+
+	if y, alpha := reflect(w.SunY); alpha > 0 {
+		screen.DrawCircle(w.SunX, y, sim.SunRadius*0.8, faint(w.Palette.Sun, alpha))
+	}
+	for _, cloud := range w.ActiveClouds() {
+		y, alpha := reflect(cloud.Y)
+		if alpha <= 0 {
+			continue
+		}
+		c := faint(w.Palette.Cloud, alpha*cloud.Opacity)
+		for _, l := range w.CloudLobes(cloud) {
+			screen.DrawCircle(cloud.X+l.DX, y-l.DY*reflectionSquash, cloud.Size*0.3*math.Sqrt(reflectionSquash), c)
+		}
+	}
+}
+
+// faint makes a see-through version of c. It is non-premultiplied so pale
+// colors stay faint instead of adding up to full brightness.
+func faint(c color.RGBA, alpha float64) color.NRGBA {
+	return color.NRGBA{c.R, c.G, c.B, uint8(float64(c.A) * alpha)}
+}
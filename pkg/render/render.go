@@ -0,0 +1,250 @@
+package render
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// Painter draws a World onto a Renderer. The ground and sun are drawn into
+// images kept until the palette or size changes, trees and their shadows
+// into images kept until the tree, the sun or the shadow scale changes,
+// cloud shadows are kept while their cloud drifts sideways, and cloud lobes
+// are stamped from images made on the first frame, so a Painter should keep
+// drawing to the same kind of Renderer. Trees and clouds drawn small, or
+// every one of them in a crowded scene, are drawn with less detail and
+// simpler shadows. The cached images that haven't been drawn lately are
+// dropped when they take more memory than ImageBudget.
+type Painter struct {
+	// Economy leaves out the costliest effects, cloud shadows and wet
+	// ground reflections, for running in the background
+	Economy bool
+	// LowEnd is the minimal path for Pi-class GPUs: everything Economy
+	// leaves out, plus contrails thinned to a fixed number of puffs stamped
+	// from the cloud atlas
+	LowEnd bool
+	// ImageBudget is how many bytes of cached images to keep, counting four
+	// bytes a pixel, before the least recently drawn are dropped. 0 keeps
+	// them all.
+	ImageBudget int64
+	// Stats describes the last frame drawn
+	Stats Stats
+
+	shadows       map[int]cachedShadow                    // Tree ID -> shadow
+	trees         map[int]cachedTree                      // Tree ID -> trunk and crown
+	objectShadows map[sim.ShadowCaster]cachedObjectShadow // Shadows of other ground entities
+	cloudShadows  map[int]cachedCloudShadow               // Cloud index -> shadow
+	cloudAtlas    []cloudSprite                           // White cloud lobes, smallest first
+	ground        cachedGround
+	sun           cachedSun
+	images        imageCache           // Memory held by all the cached images above
+	lobes         sim.Pool[[]sim.Lobe] // Scratch lobe slices, reused every frame
+	occluders     []occluder           // What the sun's rays pass behind, reused every frame
+	crowded       bool                 // Too much in the scene this frame to draw at full detail
+	pixelScale    float64              // Output pixels per scene pixel this frame
+}
+
+func New() *Painter {
+	return &Painter{
+		shadows:       map[int]cachedShadow{},
+		trees:         map[int]cachedTree{},
+		objectShadows: map[sim.ShadowCaster]cachedObjectShadow{},
+		cloudShadows:  map[int]cachedCloudShadow{},
+		lobes:         sim.Pool[[]sim.Lobe]{Reset: func(l []sim.Lobe) []sim.Lobe { return l[:0] }},
+	}
+}
+
+// economy reports whether the costliest effects should be left out
+func (r *Painter) economy() bool {
+	return r.Economy || r.LowEnd
+}
+
+// drawGround draws the ground with its top edge at baseY
+func drawGround(screen Renderer, w *sim.World, baseY float64) {
+	palette := w.Palette
+
+	// Draw main ground with isometric grid effect
+
+	// Base ground color
+	screen.DrawRect(
+		0,
+		baseY,
+		float64(w.Width),
+		scene.GroundHeight,
+		palette.Ground,
+	)
+
+	// Draw isometric grid
+	gridSize := 40.0
+	rows := int(scene.GroundHeight/gridSize) + 1
+	cols := int(float64(w.Width)/gridSize) + 2
+
+	for row := 0; row < rows; row++ {
+		for col := -1; col < cols; col++ {
+			// Calculate isometric tile corners
+			x1 := float64(col)*gridSize - (float64(row) * gridSize * 0.5)
+			y1 := baseY + float64(row)*gridSize*0.5
+
+			// Draw diagonal lines for isometric effect
+			screen.DrawLine(
+				x1, y1,
+				x1+gridSize, y1+gridSize*0.5,
+				palette.GridDark,
+			)
+			screen.DrawLine(
+				x1+gridSize, y1+gridSize*0.5,
+				x1+gridSize*2, y1,
+				palette.GridLight,
+			)
+		}
+	}
+}
+
+// Add these helper functions before drawTree
+func calcTreeLighting(w *sim.World, treeX, treeY float64) float64 {
+	sunX, sunY := w.SunX, w.SunY
+
+	// Calculate distance to sun
+	dx := treeX - sunX
+	dy := treeY - sunY
+	distanceToSun := math.Sqrt(dx*dx + dy*dy)
+	maxDistance := math.Sqrt(float64(w.Width*w.Width + w.Height*w.Height))
+
+	// Light factor based on distance (closer = brighter)
+	distanceFactor := 1.0 - (distanceToSun / maxDistance)
+
+	// Light factor based on sun height (lower sun = darker)
+	sunHeightFactor := sunY / float64(w.Height)
+
+	// Combine factors
+	return 0.4 + (0.6 * distanceFactor * (1.0 - sunHeightFactor))
+}
+
+// blendColors lights base by lightFactor scaled by the shadow intensity,
+// clamped so colors never go black or blow out to white
+func blendColors(base color.RGBA, lightFactor, shadowIntensity float64) color.RGBA {
+	return scaleColor(base, math.Max(0.1, math.Min(2, lightFactor*shadowIntensity)))
+}
+
+// drawSun draws the sun's disc centered on x, y. Its rays depend on what
+// passes in front of them, so they are drawn each frame by drawSunRays.
+func drawSun(screen Renderer, x, y float64, c color.RGBA) {
+	screen.DrawCircle(
+		x,
+		y,
+		sim.SunRadius,
+		c,
+	)
+}
+
+// Draw paints the whole scene onto screen
+func (r *Painter) Draw(screen Renderer, w *sim.World) {
+	r.Stats = Stats{}
+	r.updateDetail(screen, w)
+	screen = r.counting(screen)
+
+	// Clear the screen with the sky color
+	screen.Fill(w.Palette.Sky)
+
+	// Draw the sun
+	r.drawSun(screen, w)
+	r.drawSunRays(screen, w)
+	drawShootingStar(screen, w)
+
+	// Draw the airplane and its contrail behind the cloud layer
+	r.drawContrail(screen, w)
+	drawAirplane(screen, w)
+	r.drawLayer(screen, w, sim.LayerSky)
+
+	// Draw the ground
+	r.drawGround(screen, w)
+	if !r.economy() {
+		drawReflections(screen, w)
+	}
+
+	// Sort trees, props and other standing entities by the Y position of
+	// their base, so objects closer to the bottom are drawn last and appear
+	// on top. Ground entities without a depth, such as the cloud shadows,
+	// lie flat under everything else.
+	type groundObject struct {
+		y    float64
+		draw func()
+	}
+	objects := make([]groundObject, 0, len(w.Trees)+len(w.Props))
+	var stand func(e sim.Entity)
+	stand = func(e sim.Entity) {
+		if g, ok := e.(sim.Group); ok {
+			for _, m := range g.Members() {
+				stand(m)
+			}
+			return
+		}
+		d, ok := e.(sim.Depther)
+		if !ok {
+			r.drawEntity(screen, w, e)
+			return
+		}
+		objects = append(objects, groundObject{d.Depth(), func() {
+			if c, ok := e.(sim.ShadowCaster); ok {
+				r.drawObjectShadow(screen, w, c)
+			}
+			r.drawEntity(screen, w, e)
+		}})
+	}
+	for _, e := range w.Entities(sim.LayerGround) {
+		stand(e)
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].y < objects[j].y
+	})
+
+	for _, obj := range objects {
+		obj.draw()
+	}
+
+	// Forget the images of trees that have been removed
+	if len(r.shadows) > len(w.Trees) {
+		for id := range r.shadows {
+			if w.FindTree(id) == nil {
+				r.dropTreeShadow(id)
+				r.dropTree(id)
+			}
+		}
+	}
+
+	r.forgetObjectShadows(w)
+
+	// Clouds are at the front of the top layer, after the trees
+	r.drawLayer(screen, w, sim.LayerAir)
+	r.drawLayer(screen, w, sim.LayerTop)
+	r.trimImages()
+}
+
+// drawLayer draws the entities in one layer in the order they were added
+func (r *Painter) drawLayer(screen Renderer, w *sim.World, l sim.Layer) {
+	for _, e := range w.Entities(l) {
+		r.drawEntity(screen, w, e)
+	}
+}
+
+// drawEntity draws the clouds, trees and props lit, shaded and cached, and
+// any other entity as it draws itself
+func (r *Painter) drawEntity(screen Renderer, w *sim.World, e sim.Entity) {
+	switch e := e.(type) {
+	case *sim.Cloudbank:
+		for _, cloud := range w.ActiveClouds() {
+			r.drawCloud(screen, w, cloud)
+		}
+	case *sim.CloudShadows:
+		r.drawCloudShadows(screen, w)
+	case *sim.TreeEntity:
+		r.drawTree(screen, w, e.Tree)
+	case *sim.PropEntity:
+		drawProp(screen, w, e.Prop)
+	default:
+		e.Draw(screen)
+	}
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const shootingStarTail = 60.0 // Tail length in pixels
+
+func drawShootingStar(screen Renderer, w *sim.World) {
+	star := w.ShootingStar
+	if !star.Active {
+		return
+	}
+
+	fade := 1 - star.Age/sim.ShootingStarLife
+	speed := math.Hypot(star.VX, star.VY)
+	dirX, dirY := star.VX/speed, star.VY/speed
+
+	// Tail fades out behind the head
+	steps := 12
+	for i := 0; i < steps; i++ {
+		t0 := float64(i) / float64(steps)
+		t1 := float64(i+1) / float64(steps)
+		alpha := uint8(255 * fade * (1 - t0))
+		screen.DrawLine(
+			star.X-dirX*shootingStarTail*t0,
+			star.Y-dirY*shootingStarTail*t0,
+			star.X-dirX*shootingStarTail*t1,
+			star.Y-dirY*shootingStarTail*t1,
+			withAlpha(w.Palette.Star, alpha),
+		)
+	}
+	screen.DrawCircle(star.X, star.Y, 2, withAlpha(w.Palette.Star, uint8(255*fade)))
+}
@@ -0,0 +1,119 @@
+package render
+
+import (
+	"image/color"
+)
+
+// CacheStats counts how often a cache's images were reused in a frame and
+// how often they had to be redrawn
+type CacheStats struct {
+	Hits, Misses int
+}
+
+// HitRate is the share of lookups that reused an image, 1 when there were
+// none
+func (c CacheStats) HitRate() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 1
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// count records one lookup
+func (c *CacheStats) count(hit bool) {
+	if hit {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+}
+
+// Stats describes the last frame a Painter drew
+type Stats struct {
+	// Calls is how many shapes and images were drawn onto the screen, an
+	// upper bound on GPU draw calls before the backend batches them
+	Calls int
+	// Simplified is how many trees and clouds were drawn at low detail
+	Simplified int
+
+	Background, TreeShadows, Trees, ObjectShadows, CloudShadows CacheStats
+
+	// Images is how many offscreen images the painter keeps and ImageBytes
+	// the memory they take, at four bytes a pixel
+	Images     int
+	ImageBytes int64
+	// Evicted is how many images have been dropped to keep within the
+	// budget since the painter was made
+	Evicted int
+}
+
+// ShadowRebuilds is how many tree, object and cloud shadow images had to be
+// drawn afresh in the frame
+func (s Stats) ShadowRebuilds() int {
+	return s.TreeShadows.Misses + s.ObjectShadows.Misses + s.CloudShadows.Misses
+}
+
+// countingRenderer passes drawing on to a Renderer, counting the calls
+type countingRenderer struct {
+	Renderer
+	calls *int
+}
+
+func (c countingRenderer) Fill(col color.Color) {
+	*c.calls++
+	c.Renderer.Fill(col)
+}
+
+func (c countingRenderer) DrawCircle(x, y, r float64, col color.Color) {
+	*c.calls++
+	c.Renderer.DrawCircle(x, y, r, col)
+}
+
+func (c countingRenderer) DrawLine(x1, y1, x2, y2 float64, col color.Color) {
+	*c.calls++
+	c.Renderer.DrawLine(x1, y1, x2, y2, col)
+}
+
+func (c countingRenderer) DrawRect(x, y, width, height float64, col color.Color) {
+	*c.calls++
+	c.Renderer.DrawRect(x, y, width, height, col)
+}
+
+func (c countingRenderer) DrawImage(img Renderer, x, y, alpha float64) {
+	*c.calls++
+	c.Renderer.DrawImage(img, x, y, alpha)
+}
+
+// countingSprites is a countingRenderer for a Renderer that can stamp
+// sprites, kept apart so wrapping doesn't change what the screen supports
+type countingSprites struct {
+	countingRenderer
+}
+
+func (c countingSprites) DrawSprite(img Renderer, x, y, scale float64, tint color.Color) {
+	*c.calls++
+	c.Renderer.(SpriteDrawer).DrawSprite(img, x, y, scale, tint)
+}
+
+// countingAffine is a countingSprites for a Renderer that can also stretch
+// and shear sprites
+type countingAffine struct {
+	countingSprites
+}
+
+func (c countingAffine) DrawSpriteAffine(img Renderer, x, y float64, m Affine, tint color.Color) {
+	*c.calls++
+	c.Renderer.(AffineDrawer).DrawSpriteAffine(img, x, y, m, tint)
+}
+
+// counting wraps screen so the calls made on it are counted in r.Stats
+func (r *Painter) counting(screen Renderer) Renderer {
+	c := countingRenderer{Renderer: screen, calls: &r.Stats.Calls}
+	if _, ok := screen.(AffineDrawer); ok {
+		return countingAffine{countingSprites{c}}
+	}
+	if _, ok := screen.(SpriteDrawer); ok {
+		return countingSprites{c}
+	}
+	return c
+}
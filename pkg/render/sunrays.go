@@ -0,0 +1,108 @@
+package render
+
+import (
+	"math"
+
+	"cloudapp/pkg/sim"
+)
+
+const (
+	sunRays  = 12
+	rayStart = 1.15 // Where rays start and end, in sun radii from its center
+	rayEnd   = 1.6
+)
+
+// occluder is the bounding box of something a sun ray can pass behind, and
+// how much of the ray it blocks, 0-1
+type occluder struct {
+	x0, y0, x1, y1 float64
+	block          float64
+}
+
+// enter returns how far along the segment from a to b, 0-1, it first
+// enters the box, testing it against each pair of sides in turn
+func (o occluder) enter(ax, ay, bx, by float64) (float64, bool) {
+	near, far := 0.0, 1.0
+	for _, axis := range [2][4]float64{{ax, bx - ax, o.x0, o.x1}, {ay, by - ay, o.y0, o.y1}} {
+		from, d, lo, hi := axis[0], axis[1], axis[2], axis[3]
+		if d == 0 {
+			if from < lo || from > hi {
+				return 0, false
+			}
+			continue
+		}
+		t0, t1 := (lo-from)/d, (hi-from)/d
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		near, far = math.Max(near, t0), math.Min(far, t1)
+		if near > far {
+			return 0, false
+		}
+	}
+	return near, true
+}
+
+// cloudBounds is the box around a cloud's lobes of the given radius
+func cloudBounds(cloud sim.Cloud, lobes []sim.Lobe, radius float64) occluder {
+	o := occluder{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1), cloud.Opacity}
+	for _, l := range lobes {
+		o.x0, o.x1 = math.Min(o.x0, cloud.X+l.DX-radius), math.Max(o.x1, cloud.X+l.DX+radius)
+		o.y0, o.y1 = math.Min(o.y0, cloud.Y+l.DY-radius), math.Max(o.y1, cloud.Y+l.DY+radius)
+	}
+	return o
+}
+
+// gatherOccluders collects the clouds, and trees tall enough to reach when
+// the sun sits low, whose bounds come within reach of the sun's rays
+func (r *Painter) gatherOccluders(w *sim.World) []occluder {
+	reach := sim.SunRadius * rayEnd
+	near := func(o occluder) bool {
+		return o.x1 >= w.SunX-reach && o.x0 <= w.SunX+reach && o.y1 >= w.SunY-reach && o.y0 <= w.SunY+reach
+	}
+
+	r.occluders = r.occluders[:0]
+	for i := 0; i < w.ActiveCloudCount() && i < len(w.Clouds); i++ {
+		cloud := w.Clouds[i]
+		lobes, m := r.cloudShape(w, cloud)
+		o := cloudBounds(cloud, lobes, deformedRadius(cloud.Size*0.3, m))
+		r.lobes.Put(lobes)
+		if near(o) {
+			r.occluders = append(r.occluders, o)
+		}
+	}
+	for _, tree := range w.Trees {
+		o := occluder{tree.X - tree.Size*0.6, tree.Y - tree.Height(), tree.X + tree.Size*0.6, tree.Y, 1}
+		if near(o) {
+			r.occluders = append(r.occluders, o)
+		}
+	}
+	return r.occluders
+}
+
+// drawSunRays draws the sun's rays, each stopping where it first passes
+// behind a cloud or tree and carrying on past a thin cloud only as faintly
+// as the cloud lets light through
+func (r *Painter) drawSunRays(screen Renderer, w *sim.World) {
+	occluders := r.gatherOccluders(w)
+	for i := range sunRays {
+		angle := float64(i) * 2 * math.Pi / sunRays
+		cos, sin := math.Cos(angle), math.Sin(angle)
+		ax, ay := w.SunX+cos*sim.SunRadius*rayStart, w.SunY+sin*sim.SunRadius*rayStart
+		bx, by := w.SunX+cos*sim.SunRadius*rayEnd, w.SunY+sin*sim.SunRadius*rayEnd
+
+		hit, block := 1.0, 0.0
+		for _, o := range occluders {
+			if t, ok := o.enter(ax, ay, bx, by); ok && t < hit {
+				hit, block = t, o.block
+			}
+		}
+		mx, my := ax+(bx-ax)*hit, ay+(by-ay)*hit
+		if hit > 0 {
+			screen.DrawLine(ax, ay, mx, my, w.Palette.Sun)
+		}
+		if hit < 1 && block < 1 {
+			screen.DrawLine(mx, my, bx, by, faint(w.Palette.Sun, 1-block))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// SVG records the scene as an SVG document, for printing or for scaling
+// without losing detail
+type SVG struct {
+	Width, Height int
+	body          bytes.Buffer
+}
+
+func NewSVG(width, height int) *SVG {
+	return &SVG{Width: width, Height: height}
+}
+
+// WriteTo writes the finished document
+func (s *SVG) WriteTo(w io.Writer) (int64, error) {
+	var doc bytes.Buffer
+	fmt.Fprintf(&doc, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		s.Width, s.Height, s.Width, s.Height)
+	doc.Write(s.body.Bytes())
+	doc.WriteString("</svg>\n")
+	return doc.WriteTo(w)
+}
+
+// Fill starts the document over, since whatever was drawn is now covered
+func (s *SVG) Fill(c color.Color) {
+	s.body.Reset()
+	s.DrawRect(0, 0, float64(s.Width), float64(s.Height), c)
+}
+
+func (s *SVG) DrawCircle(x, y, r float64, c color.Color) {
+	fmt.Fprintf(&s.body, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", svgNum(x), svgNum(y), svgNum(r), svgPaint("fill", c))
+}
+
+func (s *SVG) DrawLine(x1, y1, x2, y2 float64, c color.Color) {
+	fmt.Fprintf(&s.body, `<line x1="%s" y1="%s" x2="%s" y2="%s" %s/>`+"\n",
+		svgNum(x1), svgNum(y1), svgNum(x2), svgNum(y2), svgPaint("stroke", c))
+}
+
+func (s *SVG) DrawRect(x, y, width, height float64, c color.Color) {
+	fmt.Fprintf(&s.body, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`+"\n",
+		svgNum(x), svgNum(y), svgNum(width), svgNum(height), svgPaint("fill", c))
+}
+
+func (s *SVG) NewImage(width, height int) Renderer {
+	return NewSVG(width, height)
+}
+
+// DrawImage copies the image's elements into a group moved to x, y
+func (s *SVG) DrawImage(img Renderer, x, y, alpha float64) {
+	fmt.Fprintf(&s.body, `<g transform="translate(%s %s)"`, svgNum(x), svgNum(y))
+	if alpha < 1 {
+		fmt.Fprintf(&s.body, ` opacity="%s"`, svgNum(alpha))
+	}
+	s.body.WriteString(">\n")
+	s.body.Write(img.(*SVG).body.Bytes())
+	s.body.WriteString("</g>\n")
+}
+
+// svgNum formats a coordinate to two decimal places, dropping trailing zeros
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// svgPaint sets the fill or stroke attribute to c, with its opacity if it
+// is not solid
+func svgPaint(attr string, c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	paint := fmt.Sprintf(`%s="#%02x%02x%02x"`, attr, n.R, n.G, n.B)
+	if n.A < 255 {
+		paint += fmt.Sprintf(` %s-opacity="%s"`, attr, svgNum(float64(n.A)/255))
+	}
+	return paint
+}
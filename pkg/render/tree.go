@@ -0,0 +1,361 @@
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/scene"
+	"cloudapp/pkg/sim"
+)
+
+// shadowKey is everything a tree's cached shadow image depends on
+type shadowKey struct {
+	x, y, size float64
+	sunX, sunY float64
+	scale      float64
+	shape      int
+	seed       int64
+	simple     bool // Cast as a single ellipse
+}
+
+// cachedShadow is a tree's shadow image, kept until its key changes
+type cachedShadow struct {
+	key   shadowKey
+	image Renderer
+}
+
+// lightStep is how much a tree's lighting has to change before its cached
+// image is redrawn
+const lightStep = 0.02
+
+// treeKey is everything a tree's cached image depends on. Trees are drawn
+// the same wherever they stand, so position only counts through lighting.
+type treeKey struct {
+	size, shade, light, scale float64
+	shape                     int
+	seed                      int64
+	trunk, trunkDark, foliage color.RGBA
+}
+
+// cachedTree is a tree's trunk and crown drawn into an image, kept until its
+// key changes
+type cachedTree struct {
+	key   treeKey
+	image Renderer
+}
+
+func (r *Painter) drawTree(screen Renderer, w *sim.World, tree *sim.Tree) {
+	trunkWidth := tree.Size * 0.2
+	trunkHeight := tree.Size * 0.4
+	treeShadow := w.TreeShadow
+
+	shadowLength, shadowAngle := w.ShadowGeometry(tree)
+
+	// Check if shadow needs to be updated
+	simple := r.lowDetail(tree.Size)
+	key := shadowKey{tree.X, tree.Y, tree.Size, w.SunX, w.SunY, treeShadow, tree.Shape, tree.Seed, simple}
+	shadow, ok := r.shadows[tree.ID]
+	// The shadow reaches this far from the base, plus room for the crown
+	reach := shadowLength * 0.8
+	pad := reach + tree.Size
+	r.Stats.TreeShadows.count(ok && shadow.key == key)
+	if !ok || shadow.key != key {
+		r.release(shadow.image)
+		id := tree.ID
+		shadow = cachedShadow{key: key, image: r.newImage(screen, int(pad*2), int(pad*2), func() { r.dropTreeShadow(id) })}
+		k := reach / tree.Height() // Ground distance per unit of height
+		caster := shadowCaster{
+			img:   shadow.image,
+			baseX: pad,
+			baseY: pad,
+			dirX:  math.Cos(shadowAngle) * k,
+			dirY:  math.Sin(shadowAngle) * k,
+			color: color.RGBA{w.Palette.Shadow.R, w.Palette.Shadow.G, w.Palette.Shadow.B, 255},
+		}
+		if simple {
+			caster.outline(tree.Height(), tree.Size*0.7)
+		} else {
+			caster.tree(tree, trunkWidth, trunkHeight)
+		}
+		r.shadows[tree.ID] = shadow
+	}
+	r.touch(shadow.image)
+
+	// Draw shadow
+	screen.DrawImage(shadow.image, tree.X-pad, tree.Y-pad, shadowAlpha*float64(w.Palette.Shadow.A)/255) // Position shadow relative to tree
+
+	// Draw the tree itself from its cached image, redrawn only when it or
+	// its lighting changes noticeably. Simple trees are cheaper to draw
+	// directly than to keep an image of.
+	lightFactor := math.Round(calcTreeLighting(w, tree.X, tree.Y)/lightStep) * lightStep
+	if simple {
+		r.dropTree(tree.ID)
+		drawSimpleTree(screen, w, tree, lightFactor)
+		r.Stats.Simplified++
+		return
+	}
+	bodyKey := treeKey{
+		size: tree.Size, shade: tree.Shade, light: lightFactor, scale: treeShadow,
+		shape: tree.Shape, seed: tree.Seed,
+		trunk: w.Palette.Trunk, trunkDark: w.Palette.TrunkDark, foliage: w.Palette.Foliage,
+	}
+	padX := tree.Size * 1.2
+	padTop := tree.Height() + tree.Size*0.2
+	padBottom := tree.Size * 0.1
+	body, ok := r.trees[tree.ID]
+	r.Stats.Trees.count(ok && body.key == bodyKey)
+	if !ok || body.key != bodyKey {
+		r.release(body.image)
+		id := tree.ID
+		body = cachedTree{key: bodyKey, image: r.newImage(screen, int(math.Ceil(padX*2)), int(math.Ceil(padTop+padBottom)), func() { r.dropTree(id) })}
+		local := *tree
+		local.X, local.Y = padX, padTop
+		drawTreeBody(body.image, w, &local, lightFactor)
+		r.trees[tree.ID] = body
+	}
+	r.touch(body.image)
+	screen.DrawImage(body.image, tree.X-padX, tree.Y-padTop, 1)
+}
+
+// dropTreeShadow forgets a tree's cached shadow
+func (r *Painter) dropTreeShadow(id int) {
+	r.release(r.shadows[id].image)
+	delete(r.shadows, id)
+}
+
+// dropTree forgets a tree's cached trunk and crown
+func (r *Painter) dropTree(id int) {
+	r.release(r.trees[id].image)
+	delete(r.trees, id)
+}
+
+// drawTreeBody draws a tree's trunk and crown, lit by lightFactor
+func drawTreeBody(screen Renderer, w *sim.World, tree *sim.Tree, lightFactor float64) {
+	trunkWidth := tree.Size * 0.2
+	trunkHeight := tree.Size * 0.4
+	treeShadow := w.TreeShadow
+
+	// Base colors
+	baseTrunkColor := w.Palette.Trunk
+	darkTrunkColor := w.Palette.TrunkDark
+
+	// Apply lighting to trunk colors with shadow intensity
+	litTrunkColor := blendColors(baseTrunkColor, lightFactor, treeShadow)
+	litDarkTrunkColor := blendColors(darkTrunkColor, lightFactor, treeShadow)
+
+	// Calculate leaf colors with lighting and shadow intensity
+	baseGreen := scaleColor(w.Palette.Foliage, srgbToLinear[uint8(tree.Shade*255)])
+	darkGreen := scaleColor(baseGreen, 0.5) // About 70% as bright to the eye
+
+	litBaseGreen := blendColors(baseGreen, lightFactor, treeShadow)
+	litDarkGreen := blendColors(darkGreen, lightFactor, treeShadow)
+
+	// Branching trees grow their own trunk
+	if tree.Shape == scene.ShapeBranching && tree.Structure != nil {
+		drawBranchingTree(screen, tree, litTrunkColor, litBaseGreen, litDarkGreen)
+		return
+	}
+
+	// Draw trunk with lighting
+	screen.DrawRect(
+		tree.X-trunkWidth/2,
+		tree.Y-trunkHeight,
+		trunkWidth,
+		trunkHeight,
+		litTrunkColor,
+	)
+
+	// Trunk right shading
+	screen.DrawRect(
+		tree.X+trunkWidth/2-2,
+		tree.Y-trunkHeight,
+		4,
+		trunkHeight,
+		litDarkTrunkColor,
+	)
+
+	// Draw tree top based on shape
+	switch tree.Shape {
+	case 0: // Triangle
+		for i := 0; i < 3; i++ {
+			segment := float64(i)
+			segmentHeight := tree.Size * 0.4
+			segmentWidth := tree.Size * (1.0 - segment*0.2)
+
+			top := tree.Y - trunkHeight - segmentHeight*(segment+1)
+			bottom := tree.Y - trunkHeight - segmentHeight*segment
+
+			// Draw filled triangle
+			for y := bottom; y > top; y-- {
+				progress := (bottom - y) / (bottom - top)
+				width := segmentWidth * (1 - progress)
+
+				// Main triangle body
+				screen.DrawLine(
+					tree.X-width/2,
+					y,
+					tree.X+width/2,
+					y,
+					litBaseGreen,
+				)
+
+				// Right side shading
+				screen.DrawLine(
+					tree.X+width/2,
+					y,
+					tree.X+width/2+5,
+					y+2,
+					litDarkGreen,
+				)
+			}
+		}
+
+	case 1: // Oval
+		for i := 0; i < 3; i++ {
+			centerY := tree.Y - trunkHeight - tree.Size*0.4*float64(i)
+			width := tree.Size * 0.7 * (1.0 - float64(i)*0.2)
+			height := tree.Size * 0.4
+
+			// Draw main oval with lighting
+			screen.DrawCircle(
+				tree.X,
+				centerY,
+				width/2,
+				litBaseGreen,
+			)
+
+			// Draw highlight with lighting
+			screen.DrawCircle(
+				tree.X+width*0.2,
+				centerY-height*0.1,
+				width*0.15,
+				litDarkGreen,
+			)
+		}
+
+	case 2: // Circle
+		for i := 0; i < 3; i++ {
+			centerY := tree.Y - trunkHeight - tree.Size*0.4*float64(i)
+			radius := tree.Size * 0.35 * (1.0 - float64(i)*0.2)
+
+			// Main circle with lighting
+			screen.DrawCircle(
+				tree.X,
+				centerY,
+				radius,
+				litBaseGreen,
+			)
+
+			// Highlight with lighting
+			screen.DrawCircle(
+				tree.X+radius*0.5,
+				centerY-radius*0.3,
+				radius*0.3,
+				litDarkGreen,
+			)
+		}
+	}
+}
+
+// drawBranchingTree draws a grown tree's branches as thick strokes, then its
+// leaf clusters over them, each with a darker shaded side
+func drawBranchingTree(screen Renderer, tree *sim.Tree, wood, leaf, leafShade color.RGBA) {
+	for _, b := range tree.Structure.Branches {
+		x1, y1 := tree.X+b.X1*tree.Size, tree.Y+b.Y1*tree.Size
+		x2, y2 := tree.X+b.X2*tree.Size, tree.Y+b.Y2*tree.Size
+		radius := math.Max(0.5, b.Width*tree.Size/2)
+		steps := math.Max(1, math.Hypot(x2-x1, y2-y1))
+		for i := 0.0; i <= steps; i++ {
+			t := i / steps
+			screen.DrawCircle(x1+(x2-x1)*t, y1+(y2-y1)*t, radius, wood)
+		}
+	}
+	for _, l := range tree.Structure.Leaves {
+		x, y, r := tree.X+l.X*tree.Size, tree.Y+l.Y*tree.Size, l.R*tree.Size
+		screen.DrawCircle(x+r*0.25, y+r*0.25, r, leafShade)
+		screen.DrawCircle(x, y, r*0.85, leaf)
+	}
+}
+
+const (
+	shadowAlpha    = 0.35 // Opacity of tree shadows
+	shadowMinWidth = 0.3  // Radius below which a part casts nothing
+)
+
+// shadowCaster projects a tree onto the ground away from the sun. A point
+// at some height above the base lands that height times dir further along
+// the ground, and each horizontal slice of the tree lands as a disc, so
+// round crowns cast round blobs stretched along the light. The silhouette
+// is drawn solid and made see-through as a whole when it is drawn, so
+// overlapping parts don't darken each other.
+type shadowCaster struct {
+	img          Renderer
+	baseX, baseY float64 // Where the tree's base is in the image
+	dirX, dirY   float64 // Ground offset per unit of height
+	color        color.RGBA
+}
+
+func (s shadowCaster) tree(tree *sim.Tree, trunkWidth, trunkHeight float64) {
+	if tree.Shape == scene.ShapeBranching && tree.Structure != nil {
+		for _, b := range tree.Structure.Branches {
+			radius := b.Width * tree.Size / 2
+			s.sweep(b.X1*tree.Size, -b.Y1*tree.Size, b.X2*tree.Size, -b.Y2*tree.Size, func(float64) float64 { return radius })
+		}
+		for _, l := range tree.Structure.Leaves {
+			s.ball(l.X*tree.Size, -l.Y*tree.Size, l.R*tree.Size)
+		}
+		return
+	}
+
+	s.sweep(0, 0, 0, trunkHeight, func(float64) float64 { return trunkWidth * 0.3 })
+	for i := 0; i < 3; i++ {
+		step := float64(i)
+		switch tree.Shape {
+		case scene.ShapeTriangle:
+			// Stacked cones, each narrowing to a point
+			bottom := trunkHeight + tree.Size*0.4*step
+			width := tree.Size * (1.0 - step*0.2)
+			s.sweep(0, bottom, 0, bottom+tree.Size*0.4, func(t float64) float64 { return width / 2 * (1 - t) })
+		case scene.ShapeOval, scene.ShapeCircle:
+			s.ball(0, trunkHeight+tree.Size*0.4*step, tree.Size*0.35*(1.0-step*0.2))
+		}
+	}
+}
+
+// outline casts a whole tree as one upright ellipse height tall and width
+// across, for trees drawn at low detail
+func (s shadowCaster) outline(height, width float64) {
+	s.sweep(0, 0, 0, height, func(t float64) float64 {
+		return width / 2 * math.Sqrt(math.Max(0, 1-(2*t-1)*(2*t-1)))
+	})
+}
+
+// ball casts a round part of the crown centered h above the ground
+func (s shadowCaster) ball(x, h, radius float64) {
+	s.sweep(x, h-radius, x, h+radius, func(t float64) float64 {
+		return radius * math.Sqrt(math.Max(0, 1-(2*t-1)*(2*t-1)))
+	})
+}
+
+// sweep casts the part of the tree from (x1, h1) to (x2, h2), x across and h
+// up from the base, whose slices have the given radius t of the way along
+func (s shadowCaster) sweep(x1, h1, x2, h2 float64, radius func(t float64) float64) {
+	ax, ay := s.project(x1, h1)
+	bx, by := s.project(x2, h2)
+	length := math.Hypot(bx-ax, by-ay)
+
+	// Sample at the middle of each step so even a part seen from straight
+	// above, with no length, casts its widest slice
+	for dist := 0.0; dist < math.Max(length, 1); {
+		t := math.Min(1, (dist+0.5)/math.Max(length, 1))
+		r := radius(t)
+		if r >= shadowMinWidth {
+			s.img.DrawCircle(ax+(bx-ax)*t, ay+(by-ay)*t, r, s.color)
+		}
+		dist += math.Max(1, r/2)
+	}
+}
+
+func (s shadowCaster) project(x, h float64) (float64, float64) {
+	return s.baseX + x + s.dirX*h, s.baseY + s.dirY*h
+}
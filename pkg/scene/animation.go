@@ -0,0 +1,113 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Animation is a scripted timeline for demo loops: keyframed tracks that
+// drive the sun, cloud density, wind and time of day as it plays. Tracks
+// left empty leave that part of the scene alone.
+//
+// In a file each track is a list of [time, value] pairs, times in seconds:
+//
+//	{"loop": true, "time_of_day": [[0, 0.1], [20, 0.9]], "density": [[0, 0.2], [10, 0.8], [20, 0.2]]}
+type Animation struct {
+	Loop      bool  `json:"loop,omitempty"`        // Start again from the beginning at the end
+	SunX      Track `json:"sun_x,omitempty"`       // Sun position in pixels
+	SunY      Track `json:"sun_y,omitempty"`       //
+	TimeOfDay Track `json:"time_of_day,omitempty"` // 0 is sunrise, 0.5 noon and 1 sunset, moving the sun along its arc. Overrides SunX and SunY.
+	Density   Track `json:"density,omitempty"`     // Share of the clouds shown, 0-1
+	Wind      Track `json:"wind,omitempty"`        // Prevailing wind strength, 1 is a gentle breeze
+}
+
+// Keyframe is a track's value at a time in seconds from the start
+type Keyframe struct {
+	Time, Value float64
+}
+
+func (k Keyframe) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]float64{k.Time, k.Value})
+}
+
+func (k *Keyframe) UnmarshalJSON(data []byte) error {
+	var pair [2]float64
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return fmt.Errorf("keyframe %s is not a [time, value] pair", data)
+	}
+	k.Time, k.Value = pair[0], pair[1]
+	return nil
+}
+
+// Track is a value changing over time, eased smoothly from keyframe to
+// keyframe. Keyframes are in time order.
+type Track []Keyframe
+
+// At returns the track's value at time t, holding the first and last values
+// before and after the keyframes. ok is false for an empty track.
+func (tr Track) At(t float64) (value float64, ok bool) {
+	if len(tr) == 0 {
+		return 0, false
+	}
+	if t <= tr[0].Time {
+		return tr[0].Value, true
+	}
+	for i := 1; i < len(tr); i++ {
+		a, b := tr[i-1], tr[i]
+		if t < b.Time {
+			s := (t - a.Time) / (b.Time - a.Time)
+			s = s * s * (3 - 2*s) // Smoothstep, so motion eases through each keyframe
+			return a.Value + (b.Value-a.Value)*s, true
+		}
+	}
+	return tr[len(tr)-1].Value, true
+}
+
+// Duration is the time of the last keyframe in any track
+func (a *Animation) Duration() float64 {
+	var d float64
+	for _, tr := range a.tracks() {
+		if len(tr) > 0 {
+			d = math.Max(d, tr[len(tr)-1].Time)
+		}
+	}
+	return d
+}
+
+func (a *Animation) tracks() map[string]Track {
+	return map[string]Track{
+		"sun_x":       a.SunX,
+		"sun_y":       a.SunY,
+		"time_of_day": a.TimeOfDay,
+		"density":     a.Density,
+		"wind":        a.Wind,
+	}
+}
+
+// Validate checks that every track's keyframes are in time order
+func (a *Animation) Validate() error {
+	for name, tr := range a.tracks() {
+		for i, k := range tr {
+			if k.Time < 0 {
+				return fmt.Errorf("track %s: keyframe at negative time %g", name, k.Time)
+			}
+			if i > 0 && k.Time <= tr[i-1].Time {
+				return fmt.Errorf("track %s: keyframe at %gs is not after the one before it", name, k.Time)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseAnimation reads an animation file
+func ParseAnimation(data []byte) (*Animation, error) {
+	var a Animation
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
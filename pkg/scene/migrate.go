@@ -0,0 +1,81 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Version is the schema version written to saved scenes. Bump it when a
+// change needs old files rewritten, and add a migration from the old version.
+const Version = 3
+
+// migrations[v] upgrades a scene from version v to v+1. Scenes are migrated
+// as generic JSON so a migration can rename or reshape fields that the
+// current Scene struct no longer has.
+var migrations = map[int]func(s map[string]any) error{
+	1: migrateV1,
+	2: migrateV2,
+}
+
+// migrateV1 fills in fields that scenes saved before versioning may lack,
+// where the zero value would mean something different from the old default
+func migrateV1(s map[string]any) error {
+	menu, _ := s["menu"].(map[string]any)
+	if menu == nil {
+		menu = map[string]any{}
+		s["menu"] = menu
+	}
+	if v, _ := menu["treeShadow"].(float64); v == 0 {
+		menu["treeShadow"] = 1.0
+	}
+	if v, _ := menu["maxClouds"].(float64); v == 0 {
+		clouds, _ := s["clouds"].([]any)
+		menu["maxClouds"] = len(clouds)
+	}
+	if _, ok := s["palette"]; !ok {
+		s["palette"] = "default"
+	}
+	return nil
+}
+
+// migrateV2 has nothing to rewrite. Version 3 adds branching trees, which
+// older builds would load without drawing, so they must refuse the file.
+func migrateV2(s map[string]any) error {
+	return nil
+}
+
+// Decode parses a saved scene, migrating it from older versions.
+// Scenes without a version number predate versioning and count as version 1.
+func Decode(data []byte) (Scene, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Scene{}, err
+	}
+
+	version := 1
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > Version {
+		return Scene{}, fmt.Errorf("saved by a newer GoClouds (scene version %d, this build reads up to %d)", version, Version)
+	}
+	if version < 1 {
+		return Scene{}, fmt.Errorf("unknown scene version %d", version)
+	}
+
+	for ; version < Version; version++ {
+		if err := migrations[version](raw); err != nil {
+			return Scene{}, fmt.Errorf("migrate scene from version %d: %w", version, err)
+		}
+	}
+	raw["version"] = Version
+
+	// Round trip through JSON to fill the typed struct
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return Scene{}, err
+	}
+	var s Scene
+	err = json.Unmarshal(data, &s)
+	return s, err
+}
@@ -0,0 +1,189 @@
+package scene
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Palette is every color the scene is painted with, so a palette alone can
+// reskin it
+type Palette struct {
+	Sky       color.RGBA
+	Ground    color.RGBA
+	GridDark  color.RGBA
+	GridLight color.RGBA
+	Sun       color.RGBA
+	Cloud     color.RGBA
+	Shadow    color.RGBA // Alpha scales how dark tree and cloud shadows are
+	Trunk     color.RGBA
+	TrunkDark color.RGBA
+	Foliage   color.RGBA // Brightest leaves, each tree is a shade of this
+	Bird      color.RGBA
+	Airplane  color.RGBA
+	Contrail  color.RGBA
+	Star      color.RGBA
+
+	Turbine        color.RGBA
+	TurbineBlade   color.RGBA
+	Pole           color.RGBA
+	Windsock       color.RGBA
+	WindsockStripe color.RGBA
+	Wood           color.RGBA
+	Roof           color.RGBA
+	BirdhouseHole  color.RGBA
+	Seed           color.RGBA
+	Shirt          color.RGBA
+	Straw          color.RGBA
+	ScarecrowHead  color.RGBA
+}
+
+// PaletteField is one named color of a palette
+type PaletteField struct {
+	Name  string // Key in the palette file
+	Color *color.RGBA
+}
+
+// Fields lists the palette's colors by their names in the palette file
+func (p *Palette) Fields() []PaletteField {
+	return []PaletteField{
+		{"sky", &p.Sky},
+		{"ground", &p.Ground},
+		{"gridDark", &p.GridDark},
+		{"gridLight", &p.GridLight},
+		{"sun", &p.Sun},
+		{"cloud", &p.Cloud},
+		{"shadow", &p.Shadow},
+		{"trunk", &p.Trunk},
+		{"trunkDark", &p.TrunkDark},
+		{"foliage", &p.Foliage},
+		{"bird", &p.Bird},
+		{"airplane", &p.Airplane},
+		{"contrail", &p.Contrail},
+		{"star", &p.Star},
+		{"turbine", &p.Turbine},
+		{"turbineBlade", &p.TurbineBlade},
+		{"pole", &p.Pole},
+		{"windsock", &p.Windsock},
+		{"windsockStripe", &p.WindsockStripe},
+		{"wood", &p.Wood},
+		{"roof", &p.Roof},
+		{"birdhouseHole", &p.BirdhouseHole},
+		{"seed", &p.Seed},
+		{"shirt", &p.Shirt},
+		{"straw", &p.Straw},
+		{"scarecrowHead", &p.ScarecrowHead},
+	}
+}
+
+// DefaultPalettes is the built-in palette file, written out for users to
+// edit on first run
+//
+//go:embed palettes.json
+var DefaultPalettes []byte
+
+// builtinPalettes is DefaultPalettes parsed, the fallback for colors a
+// palette file leaves out
+var builtinPalettes = func() map[string]Palette {
+	palettes, err := parsePalettes(DefaultPalettes, Palette{})
+	if err != nil {
+		panic(err)
+	}
+	return palettes
+}()
+
+// Palettes holds the palettes scenes can use by name. It starts as the
+// built-in set and is replaced when a palette file is loaded.
+var Palettes = maps.Clone(builtinPalettes)
+
+// ParsePalettes reads a palette file: an object of palettes by name, each an
+// object of "#rrggbb" or "#rrggbbaa" colors by field name. Colors a palette
+// leaves out come from its file's "default" palette, and those it leaves out
+// from the built-in one, so a palette only needs to list what it changes.
+func ParsePalettes(data []byte) (map[string]Palette, error) {
+	return parsePalettes(data, builtinPalettes["default"])
+}
+
+func parsePalettes(data []byte, base Palette) (map[string]Palette, error) {
+	var file map[string]map[string]string
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	if colors, ok := file["default"]; ok {
+		var err error
+		if base, err = parsePalette(base, colors); err != nil {
+			return nil, fmt.Errorf("palette default: %w", err)
+		}
+	}
+
+	palettes := map[string]Palette{"default": base}
+	for name, colors := range file {
+		p, err := parsePalette(base, colors)
+		if err != nil {
+			return nil, fmt.Errorf("palette %s: %w", name, err)
+		}
+		palettes[name] = p
+	}
+	return palettes, nil
+}
+
+// WithColors returns the palette with the listed colors, "#rrggbb" or
+// "#rrggbbaa" by field name, set on it
+func (p Palette) WithColors(colors map[string]string) (Palette, error) {
+	return parsePalette(p, colors)
+}
+
+// HexColor writes a color the way palette files do, as "#rrggbb", or
+// "#rrggbbaa" when it is see-through
+func HexColor(c color.RGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// parsePalette sets the listed colors on a copy of base
+func parsePalette(base Palette, colors map[string]string) (Palette, error) {
+	p := base
+	fields := p.Fields()
+	for name, hex := range colors {
+		i := -1
+		for j, f := range fields {
+			if f.Name == name {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			return p, fmt.Errorf("unknown color %q", name)
+		}
+		c, err := ParseHexColor(hex)
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", name, err)
+		}
+		*fields[i].Color = c
+	}
+	return p, nil
+}
+
+// ParseHexColor reads "#rrggbb", or "#rrggbbaa" for a see-through color, as
+// palette files write them
+func ParseHexColor(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok || (len(hex) != 6 && len(hex) != 8) {
+		return color.RGBA{}, fmt.Errorf("color %q is not #rrggbb or #rrggbbaa", s)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q is not #rrggbb or #rrggbbaa", s)
+	}
+	return color.RGBA{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
@@ -0,0 +1,29 @@
+package scene
+
+type PropKind int
+
+const (
+	PropWindTurbine PropKind = iota
+	PropWindsock
+	PropBirdhouse
+	PropFeeder
+	PropScarecrow
+
+	numPropKinds = iota
+)
+
+func (k PropKind) String() string {
+	switch k {
+	case PropWindTurbine:
+		return "Wind Turbine"
+	case PropWindsock:
+		return "Windsock"
+	case PropBirdhouse:
+		return "Birdhouse"
+	case PropFeeder:
+		return "Bird Feeder"
+	case PropScarecrow:
+		return "Scarecrow"
+	}
+	return "Unknown"
+}
@@ -0,0 +1,124 @@
+package scene
+
+const (
+	MaxClouds    = 100 // Largest cloud pool a scene holds
+	MaxTrees     = 20  // Most trees a scene holds
+	GroundHeight = 150
+	GroundOffset = 20 // Offset for isometric perspective
+)
+
+// Layout is the size of the area a scene is laid out in, and how far from
+// its edges things are spawned
+type Layout struct {
+	Width, Height int
+	Margins       Margins
+}
+
+// Margins set where clouds and trees are spawned. The defaults suit an
+// 800x600 scene; larger or unusually shaped ones may want others.
+type Margins struct {
+	CloudWrap float64 `json:"cloudWrap"` // How far past the sides clouds drift before wrapping around
+	TreeEdge  float64 `json:"treeEdge"`  // Closest trees are planted to the sides
+	SkyBand   float64 `json:"skyBand"`   // Share of the height, from the top, clouds are spawned in
+}
+
+// DefaultMargins are the margins used when none are given
+var DefaultMargins = Margins{CloudWrap: 100, TreeEdge: 50, SkyBand: 0.6}
+
+// TreeX returns an x for a tree, where 0 is the left edge of where trees may
+// be planted and 1 the right
+func (l Layout) TreeX(t float64) float64 {
+	return l.Margins.TreeEdge + t*l.TreeSpan()
+}
+
+// TreeSpan is the width trees may be planted across
+func (l Layout) TreeSpan() float64 {
+	return float64(l.Width) - 2*l.Margins.TreeEdge
+}
+
+// CloudY returns a y for a new cloud, where 0 is the top of the sky and 1
+// the bottom of the band clouds are spawned in
+func (l Layout) CloudY(t float64) float64 {
+	return t * float64(l.Height) * l.Margins.SkyBand
+}
+
+// GroundTop is the y of the horizon, where the ground starts
+func (l Layout) GroundTop() float64 {
+	return float64(l.Height - GroundHeight + GroundOffset)
+}
+
+// GroundRow returns a y on the ground, where 0 is the back edge and 1 the
+// front
+func (l Layout) GroundRow(t float64) float64 {
+	return l.GroundTop() + t*float64(GroundHeight-GroundOffset)
+}
+
+// Scene is the on-disk form of everything the user can arrange. A running
+// world keeps its own types, so state is copied in and out of these structs.
+type Scene struct {
+	Version   int     `json:"version"`
+	SunX      float64 `json:"sunX"`
+	SunY      float64 `json:"sunY"`
+	SunLocked bool    `json:"sunLocked,omitempty"`
+	Density   float64 `json:"density"`
+	Wind      float64 `json:"wind"`
+	Palette   string  `json:"palette,omitempty"`
+	Menu      Menu    `json:"menu"`
+	Clouds    []Cloud `json:"clouds"`
+	Trees     []Tree  `json:"trees"`
+	Props     []Prop  `json:"props"`
+	Notes     []Note  `json:"notes,omitempty"`
+
+	// Colors picked in the app over the palette's own, "#rrggbb" by the
+	// field names palette files use
+	Colors map[string]string `json:"colors,omitempty"`
+}
+
+type Menu struct {
+	TreeDensity int     `json:"treeDensity"`
+	CloudCount  int     `json:"cloudCount"`
+	MaxClouds   int     `json:"maxClouds"`
+	TreeShadow  float64 `json:"treeShadow"`
+}
+
+type Cloud struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Speed     float64 `json:"speed"`
+	Size      float64 `json:"size"`
+	Opacity   float64 `json:"opacity"`
+	ShapeSeed float64 `json:"shapeSeed"`
+	Locked    bool    `json:"locked,omitempty"` // Stays put instead of drifting
+	Water     float64 `json:"water,omitempty"`  // 0-1, darkening it towards rain
+	Raining   bool    `json:"raining,omitempty"`
+}
+
+type Tree struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name,omitempty"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Size  float64 `json:"size"`
+	Shade float64 `json:"shade"`
+	Shape int     `json:"shape"`
+
+	// Branching trees keep the seed they were grown from and what grew,
+	// so they look the same even if GrowTree changes
+	Seed      int64          `json:"seed,omitempty"`
+	Structure *TreeStructure `json:"structure,omitempty"`
+	Locked    bool           `json:"locked,omitempty"`
+}
+
+type Prop struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name,omitempty"`
+	Kind PropKind `json:"kind"`
+	X    float64  `json:"x"`
+	Y    float64  `json:"y"`
+}
+
+type Note struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Text string  `json:"text"`
+}
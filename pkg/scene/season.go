@@ -0,0 +1,31 @@
+package scene
+
+import (
+	"math"
+)
+
+// Season is a time of year a generated scene can be set in. It recolors the
+// scene and sets its weather.
+type Season struct {
+	Name    string
+	Palette string  // Empty keeps the biome's own palette
+	density float64 // Scales the biome's cloud density
+	wind    float64
+}
+
+var Seasons = []Season{
+	{Name: "Spring", Palette: "sakura", density: 1.2, wind: 1.2},
+	{Name: "Summer", density: 0.6, wind: 0.8},
+	{Name: "Autumn", Palette: "autumn", density: 1.4, wind: 1.8},
+	{Name: "Winter", Palette: "winter", density: 1.8, wind: 2.2},
+}
+
+// Apply sets a generated scene in the season
+func (s Season) Apply(sc Scene) Scene {
+	if s.Palette != "" {
+		sc.Palette = s.Palette
+	}
+	sc.Density = math.Min(1, sc.Density*s.density)
+	sc.Wind = s.wind
+	return sc
+}
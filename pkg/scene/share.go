@@ -0,0 +1,230 @@
+package scene
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Share strings pack a scene into a short piece of text that can be pasted
+// into a chat. The payload is a version byte followed by varint-encoded
+// fields, base64 encoded behind a prefix. Clouds are not stored one by one,
+// only a seed they are regenerated from, so they come back in a similar mix
+// rather than in the same places.
+const (
+	sharePrefix  = "goclouds:"
+	shareVersion = 2 // Version 2 adds the seeds of branching trees
+)
+
+var errShareTruncated = errors.New("share string is cut short")
+
+type shareWriter struct {
+	buf []byte
+}
+
+func (w *shareWriter) int(v int64)   { w.buf = binary.AppendVarint(w.buf, v) }
+func (w *shareWriter) uint(v uint64) { w.buf = binary.AppendUvarint(w.buf, v) }
+
+// fixed stores a float rounded to the given number of decimal places
+func (w *shareWriter) fixed(v float64, places int) {
+	w.int(int64(math.Round(v * math.Pow10(places))))
+}
+
+func (w *shareWriter) string(s string) {
+	w.uint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// shareReader reads what shareWriter wrote. After the first error every read
+// returns zero, so callers only check err once at the end.
+type shareReader struct {
+	buf []byte
+	err error
+}
+
+func (r *shareReader) int() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = errShareTruncated
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *shareReader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = errShareTruncated
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *shareReader) fixed(places int) float64 {
+	return float64(r.int()) / math.Pow10(places)
+}
+
+// count reads a list length, rejecting lengths longer than the data left
+func (r *shareReader) count() int {
+	n := r.uint()
+	if n > uint64(len(r.buf)) {
+		r.err = errShareTruncated
+		return 0
+	}
+	return int(n)
+}
+
+// upTo reads a number of things that can be at most limit, rejecting larger
+// ones before they can size anything
+func (r *shareReader) upTo(limit int, what string) int {
+	n := r.uint()
+	if r.err == nil && n > uint64(limit) {
+		r.err = fmt.Errorf("share string has %d %s, more than %d", n, what, limit)
+		return 0
+	}
+	return int(n)
+}
+
+func (r *shareReader) string() string {
+	n := r.count()
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+// EncodeShare packs a scene into a share string
+func EncodeShare(s Scene, cloudSeed int64) string {
+	w := &shareWriter{buf: []byte{shareVersion}}
+	w.fixed(s.SunX, 0)
+	w.fixed(s.SunY, 0)
+	w.fixed(s.Density, 2)
+	w.fixed(s.Wind, 2)
+	w.string(s.Palette)
+	w.uint(uint64(s.Menu.TreeDensity))
+	w.uint(uint64(s.Menu.CloudCount))
+	w.uint(uint64(s.Menu.MaxClouds))
+	w.fixed(s.Menu.TreeShadow, 2)
+	w.int(cloudSeed)
+
+	w.uint(uint64(len(s.Trees)))
+	for _, t := range s.Trees {
+		w.uint(uint64(t.ID))
+		w.string(t.Name)
+		w.fixed(t.X, 0)
+		w.fixed(t.Y, 0)
+		w.fixed(t.Size, 1)
+		w.fixed(t.Shade, 2)
+		w.uint(uint64(t.Shape))
+		if t.Shape == ShapeBranching {
+			w.int(t.Seed)
+		}
+	}
+	w.uint(uint64(len(s.Props)))
+	for _, p := range s.Props {
+		w.uint(uint64(p.ID))
+		w.string(p.Name)
+		w.uint(uint64(p.Kind))
+		w.fixed(p.X, 0)
+		w.fixed(p.Y, 0)
+	}
+	w.uint(uint64(len(s.Notes)))
+	for _, n := range s.Notes {
+		w.fixed(n.X, 0)
+		w.fixed(n.Y, 0)
+		w.string(n.Text)
+	}
+	return sharePrefix + base64.RawURLEncoding.EncodeToString(w.buf)
+}
+
+// DecodeShare unpacks a share string, regenerating the clouds from their seed
+func DecodeShare(text string, l Layout) (Scene, error) {
+	var s Scene
+	text = strings.TrimPrefix(strings.TrimSpace(text), sharePrefix)
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return s, fmt.Errorf("not a scene share string: %w", err)
+	}
+	if len(data) == 0 {
+		return s, errShareTruncated
+	}
+	version := data[0]
+	if version < 1 || version > shareVersion {
+		return s, fmt.Errorf("share string version %d is not supported", data[0])
+	}
+
+	r := &shareReader{buf: data[1:]}
+	s.SunX = r.fixed(0)
+	s.SunY = r.fixed(0)
+	s.Density = r.fixed(2)
+	s.Wind = r.fixed(2)
+	s.Palette = r.string()
+	s.Menu.TreeDensity = r.upTo(MaxTrees, "trees")
+	s.Menu.CloudCount = r.upTo(MaxClouds, "clouds")
+	s.Menu.MaxClouds = r.upTo(MaxClouds, "clouds")
+	if r.err == nil && s.Menu.CloudCount > s.Menu.MaxClouds {
+		r.err = fmt.Errorf("share string shows %d clouds of %d", s.Menu.CloudCount, s.Menu.MaxClouds)
+	}
+	s.Menu.TreeShadow = r.fixed(2)
+	cloudSeed := r.int()
+
+	trees := r.upTo(MaxTrees, "trees")
+	if r.err == nil && trees != s.Menu.TreeDensity {
+		r.err = fmt.Errorf("share string has %d trees but a tree count of %d", trees, s.Menu.TreeDensity)
+	}
+	s.Trees = make([]Tree, trees)
+	for i := range s.Trees {
+		s.Trees[i] = Tree{
+			ID:    int(r.uint()),
+			Name:  r.string(),
+			X:     r.fixed(0),
+			Y:     r.fixed(0),
+			Size:  r.fixed(1),
+			Shade: r.fixed(2),
+		}
+		if version >= 2 {
+			s.Trees[i].Shape = int(r.uint()) % NumShapes
+		} else {
+			s.Trees[i].Shape = int(r.uint()) % ShapeBranching
+		}
+		if s.Trees[i].Shape == ShapeBranching {
+			s.Trees[i].Seed = r.int()
+			s.Trees[i].Structure = GrowTree(s.Trees[i].Seed)
+		}
+	}
+	s.Props = make([]Prop, r.count())
+	for i := range s.Props {
+		id, name, kind := int(r.uint()), r.string(), r.uint()
+		if r.err == nil && kind >= uint64(numPropKinds) {
+			r.err = fmt.Errorf("share string has a prop of unknown kind %d", kind)
+		}
+		s.Props[i] = Prop{
+			ID:   id,
+			Name: name,
+			Kind: PropKind(kind),
+			X:    r.fixed(0),
+			Y:    r.fixed(0),
+		}
+	}
+	s.Notes = make([]Note, r.count())
+	for i := range s.Notes {
+		s.Notes[i] = Note{X: r.fixed(0), Y: r.fixed(0), Text: r.string()}
+	}
+	if r.err != nil {
+		return Scene{}, r.err
+	}
+
+	s.Clouds = GenerateClouds(rand.New(rand.NewSource(cloudSeed)), l, s.Menu.MaxClouds, 30, 80)
+	return s, nil
+}
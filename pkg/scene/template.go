@@ -0,0 +1,121 @@
+package scene
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Template is a built-in scene theme. Generating one replaces the trees,
+// clouds and ground colors but keeps the sun, props and notes.
+type Template struct {
+	Name     string
+	Palette  string
+	trees    int
+	shapes   []int      // Tree shapes to pick from
+	treeSize [2]float64 // Min and max tree size
+	clouds   int        // Clouds active after generating
+	cloudMix [2]float64 // Min and max cloud size
+	density  float64
+
+	// place positions tree i of n on the ground
+	place func(r *rand.Rand, l Layout, i, n int) (x, y float64)
+}
+
+var Templates = []Template{
+	{
+		Name:     "Forest",
+		Palette:  "forest",
+		trees:    20,
+		shapes:   []int{ShapeTriangle, ShapeTriangle, ShapeTriangle, ShapeBranching},
+		treeSize: [2]float64{60, 90},
+		clouds:   60,
+		cloudMix: [2]float64{50, 90},
+		density:  0.5,
+		place: func(r *rand.Rand, l Layout, i, n int) (float64, float64) {
+			return l.TreeX(r.Float64()), l.GroundRow(r.Float64())
+		},
+	},
+	{
+		Name:     "Prairie",
+		Palette:  "prairie",
+		trees:    3,
+		shapes:   []int{ShapeBranching},
+		treeSize: [2]float64{45, 65},
+		clouds:   30,
+		cloudMix: [2]float64{20, 45},
+		density:  0.1,
+		place: func(r *rand.Rand, l Layout, i, n int) (float64, float64) {
+			// Spread out, one tree per stretch of open grass
+			stretch := 1 / float64(n)
+			return l.TreeX(stretch * (float64(i) + 0.2 + r.Float64()*0.6)), l.GroundRow(r.Float64())
+		},
+	},
+	{
+		Name:     "Orchard",
+		Palette:  "default",
+		trees:    12,
+		shapes:   []int{ShapeCircle},
+		treeSize: [2]float64{50, 56},
+		clouds:   40,
+		cloudMix: [2]float64{30, 60},
+		density:  0.2,
+		place: func(r *rand.Rand, l Layout, i, n int) (float64, float64) {
+			// Two rows of evenly spaced trees with a little jitter
+			cols := (n + 1) / 2
+			spacing := l.TreeSpan() / float64(cols)
+			x := l.Margins.TreeEdge + spacing*(float64(i%cols)+0.5) + (r.Float64()-0.5)*6
+			return x, l.GroundRow(0.25 + 0.5*float64(i/cols))
+		},
+	},
+}
+
+// Generate builds the template's scene on top of an existing one. It uses its
+// own generator so the same template and seed always give the same scene,
+// whatever else has drawn from rng.
+func (t Template) Generate(base Scene, seed int64, l Layout) Scene {
+	r := rand.New(rand.NewSource(seed))
+	s := base
+	s.Palette = t.Palette
+	s.Density = t.density
+
+	s.Trees = make([]Tree, t.trees)
+	for i := range s.Trees {
+		x, y := t.place(r, l, i, t.trees)
+		s.Trees[i] = Tree{
+			X:     x,
+			Y:     y,
+			Size:  t.treeSize[0] + r.Float64()*(t.treeSize[1]-t.treeSize[0]),
+			Shade: 0.7 + r.Float64()*0.3,
+			Shape: t.shapes[r.Intn(len(t.shapes))],
+		}
+		if s.Trees[i].Shape == ShapeBranching {
+			s.Trees[i].Seed = int64(r.Int31())
+			s.Trees[i].Structure = GrowTree(s.Trees[i].Seed)
+		}
+	}
+	s.Menu.TreeDensity = t.trees
+
+	// Fill the whole cloud pool so raising the cloud count later adds clouds
+	// of the same mix
+	s.Clouds = GenerateClouds(r, l, max(s.Menu.MaxClouds, t.clouds), t.cloudMix[0], t.cloudMix[1])
+	s.Menu.MaxClouds = len(s.Clouds)
+	s.Menu.CloudCount = t.clouds
+	return s
+}
+
+// GenerateClouds scatters n clouds with sizes between minSize and maxSize
+// across the upper part of the sky
+func GenerateClouds(r *rand.Rand, l Layout, n int, minSize, maxSize float64) []Cloud {
+	clouds := make([]Cloud, n)
+	for i := range clouds {
+		clouds[i] = Cloud{
+			X:         r.Float64() * float64(l.Width),
+			Y:         l.CloudY(r.Float64()),
+			Speed:     1 + r.Float64()*2,
+			Size:      minSize + r.Float64()*(maxSize-minSize),
+			Opacity:   0.3 + r.Float64()*0.5,
+			ShapeSeed: r.Float64() * 2 * math.Pi,
+		}
+	}
+	return clouds
+}
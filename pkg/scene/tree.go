@@ -0,0 +1,221 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Tree shapes
+const (
+	ShapeTriangle = iota
+	ShapeOval
+	ShapeCircle
+	ShapeBranching // Branches and leaf clusters grown by GrowTree
+	NumShapes
+)
+
+// BranchingHeight is how tall GrowTree makes a tree, in multiples of its size
+const BranchingHeight = 1.45
+
+// TreeStructure is the shape of a branching tree, measured from the base of
+// its trunk in multiples of the tree's size with y pointing down
+type TreeStructure struct {
+	Branches []Branch
+	Leaves   []Leaf
+}
+
+type Branch struct {
+	X1, Y1, X2, Y2 float64
+	Width          float64
+}
+
+// Leaf is a round cluster of leaves at the tip of a twig
+type Leaf struct {
+	X, Y, R float64
+}
+
+// lsystemRules are the ways a bud (X) can grow each generation. A bud grows
+// a stem (F) and splits into new buds turned left (+) or right (-), with
+// brackets saving and restoring where the turtle was.
+var lsystemRules = []string{
+	"F[+X][-X]FX",
+	"F[-X]F[+X]X",
+	"F[+X]F[-X]+X",
+	"FF[-X][+X]",
+	"F[+X][-X][X]",
+}
+
+// GrowTree grows a tree from seed with a stochastic L-system, so every seed
+// gives a differently branched tree. The result is scaled to BranchingHeight.
+func GrowTree(seed int64) *TreeStructure {
+	r := rand.New(rand.NewSource(seed))
+
+	// Rewrite the bud a few generations, picking a rule at random each time
+	// and letting stems lengthen as the tree ages
+	s := "X"
+	for gen := 0; gen < 4; gen++ {
+		var next strings.Builder
+		for _, c := range s {
+			switch c {
+			case 'X':
+				next.WriteString(lsystemRules[r.Intn(len(lsystemRules))])
+			case 'F':
+				if r.Float64() < 0.7 {
+					next.WriteString("FF")
+				} else {
+					next.WriteString("F")
+				}
+			default:
+				next.WriteRune(c)
+			}
+		}
+		s = next.String()
+	}
+
+	// Walk a turtle over the string. Runs of F in one direction become a
+	// single branch, and every bud left at a twig tip becomes a leaf cluster.
+	type turtle struct {
+		x, y, heading float64
+		depth         int
+	}
+	spread := (20 + r.Float64()*15) * math.Pi / 180 // Narrow trees to wide ones
+	t := turtle{heading: -math.Pi / 2}
+	var stack []turtle
+	var st TreeStructure
+	startX, startY := t.x, t.y
+	flush := func() {
+		if t.x != startX || t.y != startY {
+			st.Branches = append(st.Branches, Branch{startX, startY, t.x, t.y, 0.16 * math.Pow(0.65, float64(t.depth))})
+		}
+		startX, startY = t.x, t.y
+	}
+	for _, c := range s {
+		switch c {
+		case 'F':
+			step := math.Pow(0.8, float64(t.depth))
+			t.x += math.Cos(t.heading) * step
+			t.y += math.Sin(t.heading) * step
+		case '+', '-':
+			flush()
+			turn := spread * (0.7 + r.Float64()*0.6)
+			if c == '-' {
+				turn = -turn
+			}
+			t.heading += turn
+		case '[':
+			flush()
+			stack = append(stack, t)
+			t.depth++
+		case ']':
+			flush()
+			t = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			startX, startY = t.x, t.y
+		case 'X':
+			flush()
+			if t.depth >= 2 {
+				st.Leaves = append(st.Leaves, Leaf{t.x, t.y, 0})
+			}
+		}
+	}
+	flush()
+
+	// Scale so the top of the crown is at BranchingHeight, leaving room
+	// for the leaf clusters above the highest twig
+	top := 0.0
+	for _, b := range st.Branches {
+		top = math.Min(top, math.Min(b.Y1, b.Y2))
+	}
+	for _, l := range st.Leaves {
+		top = math.Min(top, l.Y)
+	}
+	scale := (BranchingHeight - 0.15) / math.Max(-top, 1)
+	for i := range st.Branches {
+		b := &st.Branches[i]
+		b.X1, b.Y1, b.X2, b.Y2 = round2(b.X1*scale), round2(b.Y1*scale), round2(b.X2*scale), round2(b.Y2*scale)
+		b.Width = round2(math.Max(0.02, b.Width))
+	}
+	for i := range st.Leaves {
+		l := &st.Leaves[i]
+		l.X, l.Y = round2(l.X*scale), round2(l.Y*scale)
+		l.R = round2(0.1 + r.Float64()*0.08)
+	}
+	return &st
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// treeStructureJSON stores the numbers as space-separated lists so a saved
+// scene stays readable instead of spending a line on every coordinate
+type treeStructureJSON struct {
+	Branches string `json:"branches"` // x1,y1,x2,y2,width per branch
+	Leaves   string `json:"leaves"`   // x,y,radius per leaf cluster
+}
+
+func (s TreeStructure) MarshalJSON() ([]byte, error) {
+	var out treeStructureJSON
+	branches := make([]string, len(s.Branches))
+	for i, b := range s.Branches {
+		branches[i] = joinFloats(b.X1, b.Y1, b.X2, b.Y2, b.Width)
+	}
+	leaves := make([]string, len(s.Leaves))
+	for i, l := range s.Leaves {
+		leaves[i] = joinFloats(l.X, l.Y, l.R)
+	}
+	out.Branches = strings.Join(branches, " ")
+	out.Leaves = strings.Join(leaves, " ")
+	return json.Marshal(out)
+}
+
+func (s *TreeStructure) UnmarshalJSON(data []byte) error {
+	var in treeStructureJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = TreeStructure{}
+	for _, field := range strings.Fields(in.Branches) {
+		v, err := splitFloats(field, 5)
+		if err != nil {
+			return fmt.Errorf("branch %q: %w", field, err)
+		}
+		s.Branches = append(s.Branches, Branch{v[0], v[1], v[2], v[3], v[4]})
+	}
+	for _, field := range strings.Fields(in.Leaves) {
+		v, err := splitFloats(field, 3)
+		if err != nil {
+			return fmt.Errorf("leaf %q: %w", field, err)
+		}
+		s.Leaves = append(s.Leaves, Leaf{v[0], v[1], v[2]})
+	}
+	return nil
+}
+
+func joinFloats(vs ...float64) string {
+	parts := make([]string, len(vs))
+	for i, v := range vs {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+func splitFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("want %d numbers, got %d", n, len(parts))
+	}
+	vs := make([]float64, n)
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
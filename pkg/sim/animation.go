@@ -0,0 +1,95 @@
+package sim
+
+import (
+	"math"
+
+	"cloudapp/pkg/scene"
+)
+
+// Play starts an animation from the beginning, replacing any that is playing
+func (w *World) Play(a *scene.Animation) {
+	w.Animation = a
+	w.AnimationTime = 0
+	w.stepAnimation(0)
+}
+
+// Stop ends the animation, leaving the scene as it last set it
+func (w *World) Stop() {
+	w.Animation = nil
+}
+
+// stepAnimation advances the animation and sets the scene from its tracks
+func (w *World) stepAnimation(dt float64) {
+	a := w.Animation
+	if a == nil {
+		return
+	}
+	w.AnimationTime += dt
+	if d := a.Duration(); w.AnimationTime > d {
+		if a.Loop && d > 0 {
+			w.AnimationTime = math.Mod(w.AnimationTime, d)
+		} else {
+			w.AnimationTime = d
+			defer w.Stop()
+			w.event("animation finished")
+		}
+	}
+
+	t := w.AnimationTime
+	if v, ok := a.SunX.At(t); ok {
+		w.SunX = v
+	}
+	if v, ok := a.SunY.At(t); ok {
+		w.SunY = v
+	}
+	if v, ok := a.TimeOfDay.At(t); ok {
+		w.SunX, w.SunY = w.SunAt(v)
+	}
+	if v, ok := a.Density.At(t); ok {
+		w.Density = math.Max(0, math.Min(1, v))
+	}
+	if v, ok := a.Wind.At(t); ok {
+		w.Wind.Base = math.Max(0, v)
+	}
+}
+
+// SunAt is where the sun stands at a time of day: rising on the left at 0,
+// highest at 0.5 and setting on the right at 1
+func (w *World) SunAt(timeOfDay float64) (x, y float64) {
+	t := math.Max(0, math.Min(1, timeOfDay))
+	horizon := w.GroundTop() - 10 // As low as the sun can be dragged
+	x = SunRadius + t*(float64(w.Width)-2*SunRadius)
+	y = horizon - math.Sin(t*math.Pi)*(horizon-SunRadius)
+	return x, y
+}
+
+// SetTimeOfDay puts the sun on its arc at a time of day. A mood directing
+// the scene eases on from there rather than pulling the sun back to where
+// it had it.
+func (w *World) SetTimeOfDay(timeOfDay float64) {
+	t := math.Max(0, math.Min(1, timeOfDay))
+	w.SunX, w.SunY = w.SunAt(t)
+	w.Director.sunTime = t
+}
+
+// sunsetBand is the share of the sun's arc above the horizon that it counts
+// as down in, about the first and last 6% of the day
+const sunsetBand = 0.2
+
+// SunDown reports whether the sun is low enough to have set
+func (w *World) SunDown() bool {
+	horizon := w.GroundTop() - 10
+	return w.SunY > horizon-(horizon-SunRadius)*sunsetBand
+}
+
+// stepSunset tells OnEvent when the sun sets or rises again
+func (w *World) stepSunset() {
+	down := w.SunDown()
+	switch {
+	case down && !w.sunDown:
+		w.event("sunset")
+	case !down && w.sunDown:
+		w.event("sunrise")
+	}
+	w.sunDown = down
+}
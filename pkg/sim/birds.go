@@ -0,0 +1,302 @@
+package sim
+
+import (
+	"math"
+
+	"cloudapp/pkg/gfx"
+	"cloudapp/pkg/scene"
+)
+
+const (
+	numBirds         = 6
+	birdSpeed        = 2.0
+	birdSteer        = 0.06  // How quickly birds turn toward their target
+	birdArriveDist   = 6.0   // Distance at which a bird counts as having landed
+	birdAttractRange = 250.0 // Props only sway birds within this distance
+	birdHungerRate   = 0.02  // Hunger gained per second, feeders satisfy it
+	birdHabituate    = 0.01  // Habituation gained per second spent near a scarecrow
+	birdForget       = 0.002 // Habituation lost per second away from scarecrows
+)
+
+type BirdState int
+
+const (
+	BirdFlying BirdState = iota
+	BirdPerched
+)
+
+// Bird is a simple agent that wanders the sky and perches on trees and props
+type Bird struct {
+	X, Y             float64
+	VX, VY           float64
+	State            BirdState
+	targetX, targetY float64
+	perching         bool    // Whether the current target is a perch
+	perchProp        int     // Prop index of the perch, -1 for trees and open sky
+	restTime         float64 // Seconds left before a perched bird takes off
+	hunger           float64 // 0-1, grows over time and pulls birds toward feeders
+	habituation      float64 // 0-1, how used the bird is to scarecrows
+	Flap             float64 // Wing animation phase
+}
+
+// Flock is the birds wandering the scene. It is an Entity in LayerAir.
+type Flock struct {
+	w     *World
+	Birds []Bird
+}
+
+// NewFlock makes n birds scattered about the sky
+func (w *World) NewFlock(n int) *Flock {
+	f := &Flock{w: w}
+	for i := 0; i < n; i++ {
+		f.Birds = append(f.Birds, w.newBird())
+	}
+	return f
+}
+
+// Rearranged sends perched birds and those heading for a perch in the old
+// arrangement off to pick a new target
+func (f *Flock) Rearranged() {
+	for i := range f.Birds {
+		f.Birds[i].State = BirdFlying
+		f.w.chooseTarget(&f.Birds[i])
+	}
+}
+
+func (f *Flock) Draw(r gfx.Renderer) {
+	birdColor := f.w.Palette.Bird
+	for _, b := range f.Birds {
+		if b.State == BirdPerched {
+			// Small body with a tail flick
+			r.DrawCircle(b.X, b.Y-2, 2.5, birdColor)
+			r.DrawLine(b.X-2, b.Y-2, b.X-5, b.Y, birdColor)
+			continue
+		}
+
+		// Flying birds are drawn as a flapping "v"
+		wing := 3 * math.Sin(b.Flap)
+		r.DrawLine(b.X-5, b.Y-wing, b.X, b.Y, birdColor)
+		r.DrawLine(b.X, b.Y, b.X+5, b.Y-wing, birdColor)
+	}
+}
+
+// perchSpot is somewhere a bird could land, weighted by how attractive it is
+type perchSpot struct {
+	x, y   float64
+	weight float64
+	linger float64 // Seconds a bird stays once landed
+	prop   int     // Prop index, -1 for trees
+}
+
+func (w *World) newBird() Bird {
+	b := Bird{
+		X:         w.Rand.Float64() * float64(w.Width),
+		Y:         60 + w.Rand.Float64()*float64(w.Height)*0.3,
+		perchProp: -1,
+		hunger:    w.Rand.Float64() * 0.5,
+		Flap:      w.Rand.Float64() * 2 * math.Pi,
+	}
+	b.targetX, b.targetY = w.randomSkyPoint()
+	return b
+}
+
+func (w *World) randomSkyPoint() (float64, float64) {
+	return 40 + w.Rand.Float64()*float64(w.Width-80),
+		60 + w.Rand.Float64()*float64(w.Height-scene.GroundHeight-120)
+}
+
+// birdPerch is the hook props use to influence birds: where a bird lands on
+// the prop, how strongly the prop draws birds in and how long they stay.
+// Props birds ignore report ok=false.
+func (w *World) birdPerch(p *Prop, b *Bird) (spot perchSpot, ok bool) {
+	switch p.Kind {
+	case scene.PropBirdhouse:
+		return perchSpot{x: p.X, y: p.Y - p.Size - 4, weight: 3, linger: 6 + w.Rand.Float64()*6}, true
+	case scene.PropFeeder:
+		// Feeders get more attractive as birds get hungry, giving periodic visits
+		return perchSpot{x: p.X, y: p.Y - p.Size - 2, weight: 1 + b.hunger*12, linger: 3 + w.Rand.Float64()*3}, true
+	case scene.PropScarecrow:
+		// Birds that have stopped fearing the scarecrow happily sit on it
+		if b.habituation < 0.9 {
+			return perchSpot{}, false
+		}
+		return perchSpot{x: p.X - p.Size*0.35, y: p.Y - p.Size*0.7, weight: 2, linger: 4 + w.Rand.Float64()*4}, true
+	}
+	return perchSpot{}, false
+}
+
+// birdRepel is the hook for props that scare birds away, reporting the
+// radius birds try to keep clear of
+func (w *World) birdRepel(p *Prop) (radius float64, ok bool) {
+	if p.Kind == scene.PropScarecrow {
+		return w.ScarecrowRadius, true
+	}
+	return 0, false
+}
+
+// scared reports how strongly scarecrows push the bird away from a point,
+// from 0 when clear of them all to 1 right next to one
+func (w *World) scared(b *Bird, x, y float64) float64 {
+	fear := 0.0
+	for i := range w.Props {
+		radius, ok := w.birdRepel(&w.Props[i])
+		if !ok {
+			continue
+		}
+		dist := math.Hypot(x-w.Props[i].X, y-(w.Props[i].Y-w.Props[i].Size*0.5))
+		if dist < radius {
+			fear = math.Max(fear, 1-dist/radius)
+		}
+	}
+	return fear * (1 - b.habituation)
+}
+
+// perchSpots gathers every place the bird could land, with props only
+// counting as attractive if they are near the bird
+func (w *World) perchSpots(b *Bird) []perchSpot {
+	spots := make([]perchSpot, 0, len(w.Trees)+len(w.Props))
+	for _, tree := range w.Trees {
+		spots = append(spots, perchSpot{
+			x:      tree.X,
+			y:      tree.Y - tree.Size*1.45, // Top of the crown
+			weight: 1,
+			linger: 2 + w.Rand.Float64()*4,
+			prop:   -1,
+		})
+	}
+	for i := range w.Props {
+		spot, ok := w.birdPerch(&w.Props[i], b)
+		if !ok {
+			continue
+		}
+		if math.Hypot(spot.x-b.X, spot.y-b.Y) > birdAttractRange {
+			spot.weight = 1
+		}
+		spot.prop = i
+		spots = append(spots, spot)
+	}
+
+	// Avoid landing anywhere a scarecrow still frightens the bird
+	for i := range spots {
+		spots[i].weight *= 1 - w.scared(b, spots[i].x, spots[i].y)
+	}
+	return spots
+}
+
+// chooseTarget picks the bird's next destination, either another point in
+// the sky or a weighted random perch
+func (w *World) chooseTarget(b *Bird) {
+	b.perching = false
+	b.perchProp = -1
+	b.targetX, b.targetY = w.randomSkyPoint()
+
+	if w.Rand.Float64() < 0.4 {
+		return // Keep flying for a while
+	}
+
+	spots := w.perchSpots(b)
+	total := 0.0
+	for _, s := range spots {
+		total += s.weight
+	}
+	if total == 0 {
+		return
+	}
+
+	pick := w.Rand.Float64() * total
+	for _, s := range spots {
+		pick -= s.weight
+		if pick <= 0 {
+			b.perching = true
+			b.perchProp = s.prop
+			b.targetX, b.targetY = s.x, s.y
+			b.restTime = s.linger
+			return
+		}
+	}
+}
+
+func (f *Flock) Update(dt float64) {
+	w := f.w
+	for i := range f.Birds {
+		b := &f.Birds[i]
+		b.hunger = math.Min(1, b.hunger+birdHungerRate*dt)
+
+		// Time spent near a scarecrow slowly teaches birds to ignore it
+		fear := w.scared(b, b.X, b.Y)
+		if fear > 0 {
+			b.habituation = math.Min(1, b.habituation+birdHabituate*dt)
+		} else {
+			b.habituation = math.Max(0, b.habituation-birdForget*dt)
+		}
+
+		if b.State == BirdPerched {
+			// A scarecrow dragged close flushes perched birds
+			if fear > 0.2 {
+				b.State = BirdFlying
+				w.chooseTarget(b)
+				continue
+			}
+			b.restTime -= dt
+			if b.perchProp >= 0 && b.perchProp < len(w.Props) && w.Props[b.perchProp].Kind == scene.PropFeeder {
+				b.hunger = math.Max(0, b.hunger-0.2*dt) // Eating
+			}
+			if b.restTime <= 0 {
+				b.State = BirdFlying
+				w.chooseTarget(b)
+			}
+			continue
+		}
+
+		// Follow props that are dragged while a bird is heading for them
+		if b.perching && b.perchProp >= 0 && b.perchProp < len(w.Props) {
+			if spot, ok := w.birdPerch(&w.Props[b.perchProp], b); ok {
+				b.targetX, b.targetY = spot.x, spot.y
+			}
+		}
+
+		dx := b.targetX - b.X
+		dy := b.targetY - b.Y
+		dist := math.Hypot(dx, dy)
+		if dist < birdArriveDist {
+			if b.perching {
+				b.State = BirdPerched
+				b.X, b.Y = b.targetX, b.targetY
+				b.VX, b.VY = 0, 0
+			} else {
+				w.chooseTarget(b)
+			}
+			continue
+		}
+
+		// Steer toward the target, slowing down when coming in to land
+		speed := birdSpeed
+		if b.perching && dist < 40 {
+			speed = math.Max(0.6, birdSpeed*dist/40)
+		}
+		steer := 1 - math.Pow(1-birdSteer, dt*tuneRate)
+		b.VX += (dx/dist*speed - b.VX) * steer
+		b.VY += (dy/dist*speed - b.VY) * steer
+
+		// Veer away from scarecrows the bird still fears
+		for j := range w.Props {
+			radius, ok := w.birdRepel(&w.Props[j])
+			if !ok {
+				continue
+			}
+			awayX := b.X - w.Props[j].X
+			awayY := b.Y - (w.Props[j].Y - w.Props[j].Size*0.5)
+			awayDist := math.Max(1, math.Hypot(awayX, awayY))
+			if awayDist >= radius {
+				continue
+			}
+			push := (1 - awayDist/radius) * (1 - b.habituation) * 0.3 * dt * tuneRate
+			b.VX += awayX / awayDist * push
+			b.VY += awayY / awayDist * push
+		}
+		move := w.motion(dt)
+		b.X += b.VX * move
+		b.Y += b.VY * move
+		b.Flap = math.Mod(b.Flap+0.35*move, 2*math.Pi)
+	}
+}
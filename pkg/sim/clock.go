@@ -0,0 +1,116 @@
+package sim
+
+import (
+	"math"
+)
+
+const (
+	// FixedStep is the length of every simulation step a Clock runs, so the
+	// scene plays out the same whether it is updated at 30, 60 or 144 Hz
+	FixedStep = 1.0 / 60
+
+	maxCatchUp = 1.0  // Most seconds of steps one Advance runs after a stall
+	maxBlend   = 50.0 // Moves further than this in an Advance, such as wrapping around, aren't blended
+)
+
+// Clock drives a World in fixed steps from however much time has passed,
+// carrying any remainder over to the next call. Drawing through Blend puts
+// the moving parts between where they were before and after the last
+// Advance, so motion stays smooth when frames don't line up with steps.
+type Clock struct {
+	pending float64 // Seconds passed but not yet stepped
+	span    float64 // Seconds the last Advance that stepped ran for
+	since   float64 // Seconds passed since the last Advance, as told by Since
+	prev    motionState
+	stepped []float64 // Where Blend puts the clouds back to, reused each frame
+}
+
+// motionState is where the fast-moving parts of the world were before the
+// last step
+type motionState struct {
+	clouds       []float64
+	planeX       float64
+	starX, starY float64
+}
+
+// Advance steps w by as many whole FixedSteps as elapsed seconds allow,
+// keeping the rest for next time
+func (c *Clock) Advance(w *World, elapsed float64) {
+	c.pending = math.Min(c.pending+elapsed, maxCatchUp)
+	c.since = 0
+	// A little slack so rates that divide evenly into steps don't drift a
+	// step behind through rounding
+	if c.pending < FixedStep-1e-9 {
+		return
+	}
+	c.record(w)
+	c.span = 0
+	for c.pending >= FixedStep-1e-9 {
+		w.Step(FixedStep)
+		c.span += FixedStep
+		c.pending = math.Max(0, c.pending-FixedStep)
+	}
+}
+
+// Since tells the clock how many seconds of real time have passed since
+// the last Advance, so frames drawn between updates move on by it. A game
+// updating at a fixed rate calls it from its draw with the time since its
+// last update.
+func (c *Clock) Since(seconds float64) {
+	c.since = math.Max(0, seconds)
+}
+
+// record remembers where the moving parts are before the steps of an Advance
+func (c *Clock) record(w *World) {
+	c.prev.clouds = c.prev.clouds[:0]
+	for _, cloud := range w.Clouds {
+		c.prev.clouds = append(c.prev.clouds, cloud.X)
+	}
+	c.prev.planeX = w.Airplane.X
+	c.prev.starX, c.prev.starY = w.ShootingStar.X, w.ShootingStar.Y
+}
+
+// Blend moves the clouds, airplane and shooting star of w back between
+// where they were before and after the last Advance, by how far time has
+// run on since, calls draw and puts them back. What is drawn lags by an
+// update at most, but moves on evenly between updates.
+func (c *Clock) Blend(w *World, draw func()) {
+	t := 1.0
+	if c.span > 0 {
+		t = math.Min(1, (c.pending+c.since)/c.span)
+	}
+	if len(c.prev.clouds) != len(w.Clouds) {
+		draw()
+		return
+	}
+	c.stepped = c.stepped[:0]
+	for _, cloud := range w.Clouds {
+		c.stepped = append(c.stepped, cloud.X)
+	}
+	clouds := c.stepped
+	plane := w.Airplane.X
+	starX, starY := w.ShootingStar.X, w.ShootingStar.Y
+	for i := range w.Clouds {
+		w.Clouds[i].X = blend(c.prev.clouds[i], clouds[i], t)
+	}
+	w.Airplane.X = blend(c.prev.planeX, plane, t)
+	w.ShootingStar.X = blend(c.prev.starX, starX, t)
+	w.ShootingStar.Y = blend(c.prev.starY, starY, t)
+	defer func() {
+		for i := range clouds {
+			w.Clouds[i].X = clouds[i]
+		}
+		w.Airplane.X = plane
+		w.ShootingStar.X, w.ShootingStar.Y = starX, starY
+	}()
+	draw()
+}
+
+// blend is the point t of the way from a value before the last step to the
+// value after it
+func blend(prev, cur, t float64) float64 {
+	if math.Abs(cur-prev) > maxBlend {
+		return cur
+	}
+	return prev + (cur-prev)*t
+}
@@ -0,0 +1,170 @@
+package sim
+
+import (
+	"image/color"
+	"math"
+
+	"cloudapp/pkg/gfx"
+	"cloudapp/pkg/scene"
+)
+
+type Cloud struct {
+	X, Y      float64
+	Speed     float64
+	Size      float64
+	Opacity   float64
+	ShapeSeed float64 // Varies lobe layout and billowing phase per cloud
+	Locked    bool    // Held in place instead of drifting with the wind
+	Water     float64 // How much water it holds, 0-1. Full clouds rain.
+	Raining   bool
+}
+
+// Cloudbank is the clouds drifting with the wind and raining, kept in
+// World.Clouds. It is an Entity at the front of LayerTop.
+type Cloudbank struct {
+	w *World
+}
+
+// Update drifts the clouds that aren't locked, wrapping them around once
+// they are past the right edge, and lets them gather water and rain
+func (b *Cloudbank) Update(dt float64) {
+	w := b.w
+	move := w.motion(dt)
+	for i := range w.Clouds {
+		if w.Clouds[i].Locked {
+			continue
+		}
+		w.Clouds[i].X += w.Clouds[i].Speed * w.Wind.Speed * move
+		if w.Clouds[i].X > float64(w.Width)+w.Margins.CloudWrap {
+			w.Clouds[i].X = -w.Margins.CloudWrap
+		}
+	}
+	w.updateRain(dt)
+}
+
+// Draw draws the shown clouds plainly as their lobes. The render package
+// draws them lit by the sun instead.
+func (b *Cloudbank) Draw(r gfx.Renderer) {
+	w := b.w
+	lobes := make([]Lobe, 0, len(baseCloudLobes))
+	for _, c := range w.ActiveClouds() {
+		col := w.Palette.Cloud
+		col.A = uint8(c.Opacity * 255)
+		lobes = w.AppendCloudLobes(lobes[:0], c)
+		for _, l := range lobes {
+			r.DrawCircle(c.X+l.DX, c.Y+l.DY, c.Size*0.3, color.NRGBA(col))
+		}
+	}
+}
+
+// CloudShadows is the shadows the shown clouds cast. It is an Entity in
+// LayerGround with no depth, so it lies flat under the trees and props.
+type CloudShadows struct {
+	w *World
+}
+
+func (s *CloudShadows) Update(dt float64) {}
+
+// Draw darkens a patch of ground under each shown cloud. The render
+// package casts them away from the sun instead.
+func (s *CloudShadows) Draw(r gfx.Renderer) {
+	w := s.w
+	y := w.GroundTop() + (scene.GroundHeight-scene.GroundOffset)/2
+	for _, c := range w.ActiveClouds() {
+		col := w.Palette.Shadow
+		col.A = uint8(float64(col.A) * c.Opacity * 0.3)
+		r.DrawCircle(c.X+c.Size*0.35, y, c.Size*0.5, color.NRGBA(col))
+	}
+}
+
+// Lobe is the offset of one of the circles making up a cloud
+type Lobe struct{ DX, DY float64 }
+
+// Lobe layout shared by all clouds, in units of cloud size
+var baseCloudLobes = []Lobe{
+	{0, 0},
+	{0.5, 0.1},
+	{0.3, -0.1},
+	{0.7, 0.05},
+}
+
+// CloudLobes returns the current lobe offsets for a cloud. The seed skews the
+// base layout so no two clouds match, slow sine drift makes them billow, and
+// the cloud type stretches or heaps the layout.
+func (w *World) CloudLobes(cloud Cloud) []Lobe {
+	return w.AppendCloudLobes(make([]Lobe, 0, len(baseCloudLobes)), cloud)
+}
+
+// AppendCloudLobes is CloudLobes appending to dst, so a caller drawing every
+// cloud every frame can reuse one slice, such as from a Pool
+func (w *World) AppendCloudLobes(dst []Lobe, cloud Cloud) []Lobe {
+	info := CloudTypes[w.CloudKind(cloud)]
+	for i, base := range baseCloudLobes {
+		phase := cloud.ShapeSeed + float64(i)*1.7
+		dst = append(dst, Lobe{
+			DX: cloud.Size * info.Stretch * (base.DX + 0.1*math.Sin(cloud.ShapeSeed*float64(i+1)) + 0.08*math.Sin(w.MotionTime*0.6+phase)),
+			DY: cloud.Size * info.Flatten * (base.DY + 0.05*math.Cos(cloud.ShapeSeed*float64(i+2)) + 0.06*math.Cos(w.MotionTime*0.45+phase*1.3)),
+		})
+	}
+	return dst
+}
+
+// CloudType is the kind of cloud, following the height band it sits in
+type CloudType int
+
+const (
+	Cirrus CloudType = iota
+	Altocumulus
+	Cumulus
+	Cumulonimbus
+)
+
+// CloudTypes describes each type for the cloud lesson. Stretch and Flatten
+// scale the lobe layout so the types look different: cirrus are thin wisps,
+// cumulonimbus tall and heaped. Moisture scales how fast the type gathers
+// water; ice-crystal cirrus never rain.
+var CloudTypes = [...]struct {
+	Name     string
+	Altitude string
+	Stretch  float64
+	Flatten  float64
+	Moisture float64
+}{
+	Cirrus:       {"Cirrus", "6-12 km", 1.6, 0.4, 0},
+	Altocumulus:  {"Altocumulus", "2-6 km", 1.0, 0.7, 0.5},
+	Cumulus:      {"Cumulus", "0.5-2 km", 1.0, 1.0, 1},
+	Cumulonimbus: {"Cumulonimbus", "0.5-12 km", 0.9, 1.6, 2},
+}
+
+func (t CloudType) String() string {
+	return CloudTypes[t].Name
+}
+
+// CloudKind classifies a cloud by where it sits in the sky. Clouds only drift
+// sideways, so a cloud keeps its type.
+func (w *World) CloudKind(c Cloud) CloudType {
+	height := c.Y / float64(w.Height) // 0 at the top of the screen
+	switch {
+	case height < 0.2:
+		return Cirrus
+	case height < 0.4:
+		return Altocumulus
+	case c.Size > 70:
+		return Cumulonimbus
+	default:
+		return Cumulus
+	}
+}
+
+// NewCloud makes a cloud of the given size at x, y with a random speed,
+// opacity and shape. Like PlantTree it is left to the caller to add.
+func (w *World) NewCloud(x, y, size float64) Cloud {
+	return Cloud{
+		X:         x,
+		Y:         y,
+		Speed:     1 + w.Rand.Float64()*2,
+		Size:      size,
+		Opacity:   0.3 + w.Rand.Float64()*0.5,
+		ShapeSeed: w.Rand.Float64() * 2 * math.Pi,
+	}
+}
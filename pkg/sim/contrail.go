@@ -0,0 +1,77 @@
+package sim
+
+import (
+	"math"
+)
+
+const (
+	ContrailLifetime    = 30.0 // Seconds before a contrail puff is fully absorbed
+	contrailSpacing     = 6.0  // Distance the plane travels between puffs
+	airplaneMinInterval = 40.0 // Seconds between planes (minimum)
+	airplaneMaxInterval = 90.0 // Seconds between planes (maximum)
+)
+
+type Airplane struct {
+	X, Y       float64
+	Speed      float64 // Negative when flying right to left
+	Active     bool
+	sinceTrail float64 // Distance travelled since the last contrail puff
+}
+
+type ContrailPuff struct {
+	X, Y  float64
+	Age   float64 // Seconds since the puff was emitted
+	drift float64 // Random vertical drift so the trail diffuses unevenly
+}
+
+// updateAirplane spawns the occasional airplane, moves it across the sky and
+// ages its contrail until each puff diffuses into the cloud layer
+func (w *World) updateAirplane(dt float64) {
+	plane := &w.Airplane
+
+	if !plane.Active && w.SimTime >= w.nextAirplane {
+		speed := 2 + w.Rand.Float64()*1.5
+		plane.X = -40
+		if w.Rand.Intn(2) == 0 {
+			speed = -speed
+			plane.X = float64(w.Width + 40)
+		}
+		plane.Y = 30 + w.Rand.Float64()*float64(w.Height)*0.25 // Cruise above most clouds
+		plane.Speed = speed
+		plane.Active = true
+		plane.sinceTrail = 0
+		w.event("airplane crossing the sky")
+	}
+
+	if plane.Active {
+		step := plane.Speed * w.motion(dt)
+		plane.X += step
+		plane.sinceTrail += math.Abs(step)
+		if plane.sinceTrail >= contrailSpacing {
+			plane.sinceTrail = 0
+			w.Contrail = append(w.Contrail, ContrailPuff{
+				X:     plane.X - math.Copysign(12, plane.Speed), // Emit from the tail
+				Y:     plane.Y,
+				drift: (w.Rand.Float64() - 0.5) * 0.15,
+			})
+		}
+
+		if plane.X < -60 || plane.X > float64(w.Width+60) {
+			plane.Active = false
+			w.nextAirplane = w.SimTime + (airplaneMinInterval+w.Rand.Float64()*(airplaneMaxInterval-airplaneMinInterval))/w.airplaneRate()
+		}
+	}
+
+	// Age the contrail, dropping puffs that have been absorbed
+	kept := w.Contrail[:0]
+	for _, puff := range w.Contrail {
+		puff.Age += dt
+		if puff.Age >= ContrailLifetime {
+			continue
+		}
+		puff.X += 0.2 * w.Wind.Speed * dt * tuneRate // Carried slowly by high-altitude wind
+		puff.Y += puff.drift * dt * tuneRate
+		kept = append(kept, puff)
+	}
+	w.Contrail = kept
+}
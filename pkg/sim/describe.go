@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"fmt"
+)
+
+// Sky is how much of the sky the clouds cover, in coarse bands
+type Sky int
+
+const (
+	SkyClear Sky = iota
+	SkyFewClouds
+	SkyPartlyCloudy
+	SkyOvercast
+)
+
+// wetAfterRain is how wet the ground has to be to count as just rained on
+const wetAfterRain = 0.2
+
+// Sky puts the share of the sky covered by clouds into a band
+func (w *World) Sky() Sky {
+	clouds := w.ActiveCloudCount()
+	switch share := float64(clouds) / float64(max(1, w.MaxClouds)); {
+	case clouds == 0:
+		return SkyClear
+	case share < 0.25:
+		return SkyFewClouds
+	case share < 0.6:
+		return SkyPartlyCloudy
+	}
+	return SkyOvercast
+}
+
+// Wet reports whether the ground is still wet enough to show it rained
+func (w *World) Wet() bool {
+	return w.Wetness > wetAfterRain
+}
+
+// Describe sums up the scene in a sentence for people who can't see it,
+// such as "Partly cloudy, a gentle breeze, the sun high. 20 clouds, 5 trees
+// and 1 prop." Values are put in coarse bands so the text only changes when
+// something noticeable happens, not with every gust.
+func (w *World) Describe() string {
+	clouds := w.ActiveCloudCount()
+	sky := [...]string{
+		SkyClear:        "Clear sky",
+		SkyFewClouds:    "A few clouds",
+		SkyPartlyCloudy: "Partly cloudy",
+		SkyOvercast:     "Overcast",
+	}[w.Sky()]
+	if w.Raining() {
+		sky += " with rain"
+	} else if w.Wet() {
+		sky += " after rain"
+	}
+
+	var wind string
+	switch {
+	case w.Wind.Base < 0.2:
+		wind = "calm"
+	case w.Wind.Base < 1.5:
+		wind = "a gentle breeze"
+	case w.Wind.Base < 3:
+		wind = "breezy"
+	default:
+		wind = "windy"
+	}
+
+	// The sun's height above the ground stands in for the time of day
+	var sun string
+	switch height := (w.GroundTop() - w.SunY) / w.GroundTop(); {
+	case height > 0.66:
+		sun = "the sun high"
+	case height > 0.33:
+		sun = "the sun midway"
+	default:
+		sun = "the sun low"
+	}
+
+	return fmt.Sprintf("%s, %s, %s. %s, %s and %s.", sky, wind, sun,
+		plural(clouds, "cloud"), plural(len(w.Trees), "tree"), plural(len(w.Props), "prop"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
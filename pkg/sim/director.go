@@ -0,0 +1,128 @@
+package sim
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	directorSettle = 20.0 // Seconds for density and wind to get most of the way to their targets
+	sunSettle      = 30.0 // Seconds for the sun to get most of the way along its arc to its target
+	sunReturn      = 5.0  // Seconds for a sun moved off its arc to glide back onto it
+)
+
+// Mood is a feel the director keeps the scene to: ranges it picks density,
+// wind and the time of day from, and how often rain and airplanes come
+type Mood struct {
+	Name      string
+	Density   [2]float64 // Share of the clouds shown, 0-1
+	Wind      [2]float64 // Prevailing wind strength, 1 is a gentle breeze
+	TimeOfDay [2]float64 // Where the sun stands, 0 sunrise, 0.5 noon and 1 sunset
+	Rain      float64    // How much faster than usual clouds fill with rain
+	Airplanes float64    // How much more often than usual airplanes cross
+	Change    float64    // Average seconds between new targets
+}
+
+// Moods are the feels the director can keep to
+var Moods = []Mood{
+	{Name: "Serene", Density: [2]float64{0.1, 0.3}, Wind: [2]float64{0.3, 0.8}, TimeOfDay: [2]float64{0.3, 0.7}, Rain: 0.2, Airplanes: 0.5, Change: 90},
+	{Name: "Dramatic", Density: [2]float64{0.6, 0.9}, Wind: [2]float64{2, 3.5}, TimeOfDay: [2]float64{0.8, 0.95}, Rain: 2, Airplanes: 1, Change: 30},
+	{Name: "Melancholy", Density: [2]float64{0.7, 1}, Wind: [2]float64{0.4, 1}, TimeOfDay: [2]float64{0.88, 1}, Rain: 3, Airplanes: 0.3, Change: 120},
+	{Name: "Chaotic", Density: [2]float64{0, 1}, Wind: [2]float64{1, 5}, TimeOfDay: [2]float64{0, 1}, Rain: 1.5, Airplanes: 3, Change: 10},
+}
+
+// FindMood looks a mood up by name, ignoring case
+func FindMood(name string) (*Mood, bool) {
+	for i := range Moods {
+		if strings.EqualFold(Moods[i].Name, name) {
+			return &Moods[i], true
+		}
+	}
+	return nil, false
+}
+
+// Director keeps the weather, light and wind to a mood. Now and then it
+// picks new targets within the mood's ranges and eases the scene towards
+// them, so changes made meanwhile, such as dragging the sun, drift back
+// rather than jump. It stands aside while an animation plays.
+type Director struct {
+	Mood *Mood // Nil while nothing is directed
+
+	density, wind, timeOfDay float64 // Targets
+	sunTime                  float64 // Time of day the sun is being eased along its arc at
+	next                     float64 // SimTime new targets are picked at
+}
+
+// Direct keeps the scene to a mood from now on, or stops with nil, leaving
+// the scene as it is
+func (w *World) Direct(m *Mood) {
+	d := &w.Director
+	d.Mood = m
+	if m == nil {
+		return
+	}
+	d.sunTime = w.TimeOfDay()
+	w.pickTargets()
+}
+
+// TimeOfDay is the time of day the sun's arc passes closest to where the
+// sun is
+func (w *World) TimeOfDay() float64 {
+	best, bestDist := 0.0, math.Inf(1)
+	for i := 0; i <= 100; i++ {
+		t := float64(i) / 100
+		x, y := w.SunAt(t)
+		if d := math.Hypot(x-w.SunX, y-w.SunY); d < bestDist {
+			best, bestDist = t, d
+		}
+	}
+	return best
+}
+
+// pickTargets chooses where the scene drifts next and when it next changes
+// its mind, anywhere from half to one and a half times the mood's pace
+func (w *World) pickTargets() {
+	d := &w.Director
+	m := d.Mood
+	within := func(r [2]float64) float64 { return r[0] + w.Rand.Float64()*(r[1]-r[0]) }
+	d.density, d.wind, d.timeOfDay = within(m.Density), within(m.Wind), within(m.TimeOfDay)
+	d.next = w.SimTime + m.Change*(0.5+w.Rand.Float64())
+}
+
+// stepDirector eases the scene towards the mood's current targets
+func (w *World) stepDirector(dt float64) {
+	d := &w.Director
+	if d.Mood == nil || w.Animation != nil {
+		return
+	}
+	if w.SimTime >= d.next {
+		w.pickTargets()
+	}
+	approach := func(v *float64, target, settle float64) {
+		*v += (target - *v) * (1 - math.Exp(-dt/settle))
+	}
+	approach(&w.Density, d.density, directorSettle)
+	approach(&w.Wind.Base, d.wind, directorSettle)
+	if !w.SunLocked && !w.Transition.Active {
+		approach(&d.sunTime, d.timeOfDay, sunSettle)
+		x, y := w.SunAt(d.sunTime)
+		approach(&w.SunX, x, sunReturn)
+		approach(&w.SunY, y, sunReturn)
+	}
+}
+
+// rainRate is how much faster than usual clouds fill with rain
+func (w *World) rainRate() float64 {
+	if m := w.Director.Mood; m != nil {
+		return m.Rain
+	}
+	return 1
+}
+
+// airplaneRate is how much more often than usual airplanes cross
+func (w *World) airplaneRate() float64 {
+	if m := w.Director.Mood; m != nil && m.Airplanes > 0 {
+		return m.Airplanes
+	}
+	return 1
+}
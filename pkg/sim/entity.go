@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"slices"
+
+	"cloudapp/pkg/gfx"
+)
+
+// Entity is an object in the scene that moves and draws itself. New kinds
+// of object implement it and are added to a layer with World.Add, so neither
+// the World nor the app needs to know about them. The clouds, trees and
+// props are entities too, the Cloudbank, Grove and PropSet, updating and
+// drawing what is kept in World.Clouds, Trees and Props, the typed slices
+// that saving, sharing and editing work on.
+type Entity interface {
+	Update(dt float64)
+	Draw(r gfx.Renderer)
+}
+
+// Layer is where in the drawing order an entity appears
+type Layer int
+
+const (
+	LayerSky    Layer = iota // Behind the ground and clouds, with the sun and airplanes
+	LayerGround              // On the ground, sorted with trees and props when it has a Depth
+	LayerAir                 // Above the trees and props, below the clouds
+	LayerTop                 // The clouds, then in front of everything
+	numLayers
+)
+
+// Depther is implemented by ground entities that should be drawn in front of
+// trees and props nearer the horizon and behind those nearer the viewer.
+// Depth is the Y position of the entity's base.
+type Depther interface {
+	Depth() float64
+}
+
+// Group is implemented by entities that stand for several things, such as
+// the trees. Each member is drawn on its own, sorted by depth with the rest
+// of the ground layer, but only the group is updated.
+type Group interface {
+	Members() []Entity
+}
+
+// Rearranger is implemented by entities that track the trees or props, to
+// be told when Apply replaces them
+type Rearranger interface {
+	Rearranged()
+}
+
+// Add puts e into layer l, drawn after the entities already there
+func (w *World) Add(l Layer, e Entity) {
+	w.layers[l] = append(w.layers[l], e)
+}
+
+// Remove takes e out of whichever layer it is in
+func (w *World) Remove(e Entity) {
+	for l := range w.layers {
+		w.layers[l] = slices.DeleteFunc(w.layers[l], func(x Entity) bool { return x == e })
+	}
+}
+
+// Entities returns the entities in layer l in drawing order
+func (w *World) Entities(l Layer) []Entity {
+	return w.layers[l]
+}
+
+// updateEntities steps every entity. Each layer is copied first, into a
+// slice kept from step to step, so entities can add or remove others as
+// they update.
+func (w *World) updateEntities(dt float64) {
+	for l := range w.layers {
+		w.updating = append(w.updating[:0], w.layers[l]...)
+		for _, e := range w.updating {
+			e.Update(dt)
+		}
+	}
+}
+
+// rearranged tells the entities that track trees or props that they changed
+func (w *World) rearranged() {
+	for l := range w.layers {
+		for _, e := range w.layers[l] {
+			if r, ok := e.(Rearranger); ok {
+				r.Rearranged()
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+
+	"cloudapp/pkg/gfx"
+)
+
+// SceneObject is a custom object standing on the ground, such as a windmill
+// or a house, compiled into a program that embeds the scene. Register a kind
+// with RegisterObject and place one with World.AddObject. It is told where
+// the sun is and how the wind blows every frame, drawn in depth order with
+// the trees and props, and casts a shadow from its ShadowParts like a tree.
+// Objects aren't saved with the scene.
+type SceneObject interface {
+	Update(env Environment, dt float64)
+	Draw(r gfx.Renderer)
+
+	// Base is where the object stands on the ground
+	Base() (x, y float64)
+
+	// ShadowParts describes the object's shape for casting its shadow, with
+	// positions relative to its base. None casts no shadow.
+	ShadowParts() []ShadowPart
+}
+
+// Environment is what a SceneObject is told about the scene each frame
+type Environment struct {
+	SunX, SunY float64
+	Wind       float64 // Wind speed, 1 is a gentle breeze
+	Time       float64 // Seconds of simulated time
+}
+
+// ShadowPart is a solid piece of an object that blocks the sun: a rod of the
+// given radius from (X1, H1) to (X2, H2), with X across from the base and H
+// up from the ground. A rod with both ends the same is a ball.
+type ShadowPart struct {
+	X1, H1, X2, H2 float64
+	Radius         float64
+}
+
+// ShadowCaster is implemented by ground entities that cast a shadow away
+// from the sun
+type ShadowCaster interface {
+	Base() (x, y float64)
+	ShadowParts() []ShadowPart
+}
+
+// objectKinds maps registered kinds to the functions that make them
+var objectKinds = map[string]func(x, y float64) SceneObject{}
+
+// RegisterObject makes a kind of SceneObject available to AddObject, made
+// standing at x, y by newObject. It is meant to be called from an init
+// function and panics if the kind is already registered.
+func RegisterObject(kind string, newObject func(x, y float64) SceneObject) {
+	if _, ok := objectKinds[kind]; ok {
+		panic(fmt.Sprintf("sim: object kind %q registered twice", kind))
+	}
+	objectKinds[kind] = newObject
+}
+
+// ObjectKinds lists the registered kinds of SceneObject in name order
+func ObjectKinds() []string {
+	kinds := make([]string, 0, len(objectKinds))
+	for kind := range objectKinds {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// AddObject places a new object of a registered kind standing at x, y
+func (w *World) AddObject(kind string, x, y float64) (SceneObject, error) {
+	newObject, ok := objectKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown object kind %q", kind)
+	}
+	obj := newObject(x, y)
+	w.Add(LayerGround, &objectEntity{w, obj})
+	return obj, nil
+}
+
+// RemoveObject takes an object added with AddObject out of the scene
+func (w *World) RemoveObject(obj SceneObject) {
+	for _, e := range w.Entities(LayerGround) {
+		if o, ok := e.(*objectEntity); ok && o.obj == obj {
+			w.Remove(e)
+			return
+		}
+	}
+}
+
+// Objects returns the objects added with AddObject
+func (w *World) Objects() []SceneObject {
+	var objs []SceneObject
+	for _, e := range w.Entities(LayerGround) {
+		if o, ok := e.(*objectEntity); ok {
+			objs = append(objs, o.obj)
+		}
+	}
+	return objs
+}
+
+// objectEntity puts a SceneObject in the ground layer, handing it the
+// environment as it updates
+type objectEntity struct {
+	w   *World
+	obj SceneObject
+}
+
+func (e *objectEntity) Update(dt float64) {
+	e.obj.Update(Environment{
+		SunX: e.w.SunX,
+		SunY: e.w.SunY,
+		Wind: e.w.Wind.Speed,
+		Time: e.w.SimTime,
+	}, dt)
+}
+
+func (e *objectEntity) Draw(r gfx.Renderer) {
+	e.obj.Draw(r)
+}
+
+func (e *objectEntity) Depth() float64 {
+	_, y := e.obj.Base()
+	return y
+}
+
+func (e *objectEntity) Base() (x, y float64) {
+	return e.obj.Base()
+}
+
+func (e *objectEntity) ShadowParts() []ShadowPart {
+	return e.obj.ShadowParts()
+}
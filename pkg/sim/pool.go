@@ -0,0 +1,55 @@
+package sim
+
+// Pool keeps short-lived objects, such as particles or the scratch slices
+// drawing a frame needs, for reuse, so effects that spawn and drop many a
+// second don't leave garbage behind for the collector to pause on. Unlike
+// sync.Pool it is never emptied behind the caller's back, and it is not safe
+// for concurrent use, as the simulation and drawing run on one goroutine.
+// The zero Pool hands out zero values.
+type Pool[T any] struct {
+	// New makes an object when there are none spare, nil for the zero value
+	New func() T
+	// Reset readies an object put back for reuse, such as truncating a
+	// slice, nil to keep it as it is
+	Reset func(T) T
+	// Max is how many spare objects are kept, 0 for no limit. Objects put
+	// back beyond it are left to the garbage collector.
+	Max int
+
+	free           []T
+	allocs, reuses int
+}
+
+// Get returns a spare object, or a new one when there are none
+func (p *Pool[T]) Get() T {
+	if n := len(p.free); n > 0 {
+		x := p.free[n-1]
+		var zero T
+		p.free[n-1] = zero // Don't keep it reachable from the free list
+		p.free = p.free[:n-1]
+		p.reuses++
+		return x
+	}
+	p.allocs++
+	if p.New != nil {
+		return p.New()
+	}
+	var zero T
+	return zero
+}
+
+// Put hands an object back for reuse. It must not be used after.
+func (p *Pool[T]) Put(x T) {
+	if p.Max > 0 && len(p.free) >= p.Max {
+		return
+	}
+	if p.Reset != nil {
+		x = p.Reset(x)
+	}
+	p.free = append(p.free, x)
+}
+
+// Counts returns how many objects Get has made and how many it reused
+func (p *Pool[T]) Counts() (allocs, reuses int) {
+	return p.allocs, p.reuses
+}
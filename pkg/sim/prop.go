@@ -0,0 +1,119 @@
+package sim
+
+import (
+	"math"
+
+	"cloudapp/pkg/gfx"
+	"cloudapp/pkg/scene"
+)
+
+// Prop is a decorative object standing on the ground
+type Prop struct {
+	ID    int
+	Name  string // Optional user-assigned name
+	Kind  scene.PropKind
+	X, Y  float64 // Base position on the ground
+	Size  float64 // Overall height
+	Angle float64 // Animation state, e.g. rotor angle
+}
+
+// PropSet is the props standing on the ground, kept in World.Props. It is
+// an Entity in LayerGround whose members are the props one by one, so each
+// is drawn in depth order among the trees and objects.
+type PropSet struct {
+	w       *World
+	props   []PropEntity // Reused by Members every frame
+	members []Entity
+}
+
+// Update turns the rotors and flutters the windsocks with the wind
+func (s *PropSet) Update(dt float64) {
+	s.w.updateProps(dt)
+}
+
+// Members is a PropEntity for each prop, in the order of World.Props. The
+// slice is reused, so it is only good until the next call.
+func (s *PropSet) Members() []Entity {
+	props := s.w.Props
+	if cap(s.props) < len(props) {
+		s.props = make([]PropEntity, len(props))
+	}
+	s.props, s.members = s.props[:len(props)], s.members[:0]
+	for i := range props {
+		s.props[i] = PropEntity{s.w, &props[i]}
+		s.members = append(s.members, &s.props[i])
+	}
+	return s.members
+}
+
+func (s *PropSet) Draw(r gfx.Renderer) {
+	for _, p := range s.Members() {
+		p.Draw(r)
+	}
+}
+
+// PropEntity is one prop of the PropSet. It points into World.Props, so it
+// is only good until the props next change.
+type PropEntity struct {
+	w    *World
+	Prop *Prop
+}
+
+func (p *PropEntity) Update(dt float64) {}
+
+func (p *PropEntity) Depth() float64 {
+	return p.Prop.Y
+}
+
+// Draw draws the prop plainly as a post. The render package draws each
+// kind as it looks instead.
+func (p *PropEntity) Draw(r gfx.Renderer) {
+	prop := p.Prop
+	r.DrawRect(prop.X-2, prop.Y-prop.Size, 4, prop.Size, p.w.Palette.Trunk)
+}
+
+func (w *World) NewProp(kind scene.PropKind, x, y float64) Prop {
+	p := Prop{ID: w.NewID(), Kind: kind, X: x, Y: y}
+	switch kind {
+	case scene.PropWindTurbine:
+		p.Size = 110
+	case scene.PropWindsock:
+		p.Size = 45
+	case scene.PropBirdhouse:
+		p.Size = 50
+	case scene.PropFeeder:
+		p.Size = 35
+	case scene.PropScarecrow:
+		p.Size = 60
+	}
+	return p
+}
+
+// Hit reports whether a point lies on the prop's pole or body
+func (p *Prop) Hit(x, y float64) bool {
+	halfWidth := 8.0
+	if p.Kind == scene.PropWindTurbine && y < p.Y-p.Size*0.8 {
+		halfWidth = p.Size * 0.45 // Include the rotor
+	}
+	if p.Kind == scene.PropScarecrow && y < p.Y-p.Size*0.5 {
+		halfWidth = p.Size * 0.4 // Include the arms
+	}
+	return math.Abs(x-p.X) < halfWidth && y >= p.Y-p.Size*1.2 && y <= p.Y
+}
+
+func (w *World) updateProps(dt float64) {
+	scale := w.motion(dt)
+	for i := range w.Props {
+		prop := &w.Props[i]
+		switch prop.Kind {
+		case scene.PropWindTurbine:
+			prop.Angle += w.Wind.Speed * 0.06 * scale
+		case scene.PropWindsock:
+			// Flutter faster in stronger wind
+			prop.Angle += (0.1 + w.Wind.Speed*0.25) * scale
+		case scene.PropScarecrow:
+			prop.Angle += (0.02 + w.Wind.Speed*0.04) * scale
+		}
+		prop.Angle = math.Mod(prop.Angle, 2*math.Pi) // Stays precise however long the scene runs
+	}
+}
@@ -0,0 +1,47 @@
+package sim
+
+// Clouds gather water from the air and darken as they fill. One that fills
+// right up rains, wetting the ground, until it has let most of it fall.
+const (
+	waterGain     = 1.0 / 240 // Share of a full load a middling cumulus gathers a second in average air
+	rainDrain     = 1.0 / 30  // Share of a full load a raining cloud lets fall a second
+	rainStopWater = 0.3       // Water left when a cloud stops raining
+	rainWetting   = 0.02      // Ground wetness each raining cloud adds a second
+)
+
+// updateRain fills the shown clouds with water, big ones faster and all of
+// them faster the more of the sky is cloud, and lets the full ones rain
+func (w *World) updateRain(dt float64) {
+	wasRaining := w.Raining()
+	for i := range w.ActiveClouds() {
+		c := &w.Clouds[i]
+		if c.Raining {
+			c.Water -= rainDrain * dt
+			w.Wetness = min(1, w.Wetness+rainWetting*dt)
+			if c.Water <= rainStopWater {
+				c.Raining = false
+			}
+			continue
+		}
+		c.Water += waterGain * CloudTypes[w.CloudKind(*c)].Moisture * c.Size / 55 * (0.5 + w.Density) * w.rainRate() * dt
+		if c.Water >= 1 {
+			c.Water, c.Raining = 1, true
+		}
+	}
+	switch raining := w.Raining(); {
+	case raining && !wasRaining:
+		w.event("rain began")
+	case !raining && wasRaining:
+		w.event("rain stopped")
+	}
+}
+
+// Raining reports whether any shown cloud is raining
+func (w *World) Raining() bool {
+	for _, c := range w.ActiveClouds() {
+		if c.Raining {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+
+	"cloudapp/pkg/scene"
+)
+
+// Sanitize puts right any state that has gone out of range, such as a
+// position that became NaN or a cloud that drifted off and never wrapped,
+// and describes each fix. A healthy world is left as it is. Long-running
+// programs call it now and then as a safeguard.
+func (w *World) Sanitize() []string {
+	var fixes []string
+	fix := func(format string, args ...any) {
+		fixes = append(fixes, fmt.Sprintf(format, args...))
+	}
+
+	if !finite(w.SunX, w.SunY) {
+		w.SunX, w.SunY = w.SunAt(0.5)
+		fix("sun moved back into the sky")
+	}
+	if !finite(w.Density) || w.Density < 0 || w.Density > 1 {
+		w.Density = 0.2
+		fix("cloud density reset")
+	}
+	if !finite(w.Wind.Base, w.Wind.Speed) || w.Wind.Base < 0 {
+		w.Wind = NewWind(1)
+		fix("wind reset")
+	}
+	if !finite(w.Wetness) || w.Wetness < 0 || w.Wetness > 1 {
+		w.Wetness = 0
+		fix("ground dried")
+	}
+	if !finite(w.SimTime, w.MotionTime) {
+		w.SimTime, w.MotionTime = 0, 0
+		fix("clock reset")
+	}
+
+	lost := w.Margins.CloudWrap + 100 // Further out than wrapping clouds ever go
+	for i := range w.Clouds {
+		c := &w.Clouds[i]
+		if !finite(c.X, c.Y, c.Size, c.Speed, c.Opacity, c.ShapeSeed) || c.X < -lost || c.X > float64(w.Width)+lost {
+			fresh := scene.GenerateClouds(w.Rand, w.Layout, 1, 30, 80)[0]
+			*c = Cloud{X: -w.Margins.CloudWrap, Y: fresh.Y, Speed: fresh.Speed, Size: fresh.Size, Opacity: fresh.Opacity, ShapeSeed: fresh.ShapeSeed}
+			fix("cloud %d replaced", i)
+		}
+		if !finite(c.Water) || c.Water < 0 || c.Water > 1 {
+			c.Water, c.Raining = 0, false
+			fix("cloud %d dried out", i)
+		}
+	}
+	for i := range w.Trees {
+		t := &w.Trees[i]
+		if !finite(t.X, t.Y, t.Size) {
+			t.X, t.Y = float64(w.Width)/2, w.GroundRow(0.5)
+			fix("tree #%d moved back onto the ground", t.ID)
+		}
+	}
+	for i := range w.Props {
+		p := &w.Props[i]
+		if !finite(p.X, p.Y, p.Size, p.Angle) {
+			p.X, p.Y, p.Angle = float64(w.Width)/2, w.GroundRow(0.5), 0
+			fix("prop #%d moved back onto the ground", p.ID)
+		}
+	}
+	if w.Airplane.Active && !finite(w.Airplane.X, w.Airplane.Y, w.Airplane.Speed) {
+		w.Airplane.Active = false
+		fix("airplane removed")
+	}
+
+	for l := range w.layers {
+		for _, e := range w.layers[l] {
+			if s, ok := e.(sanitizer); ok {
+				fixes = append(fixes, s.sanitize()...)
+			}
+		}
+	}
+	return fixes
+}
+
+// sanitizer is implemented by entities that can put their own state right
+type sanitizer interface {
+	sanitize() []string
+}
+
+func (f *Flock) sanitize() []string {
+	var fixes []string
+	for i := range f.Birds {
+		b := &f.Birds[i]
+		lost := b.X < -500 || b.X > float64(f.w.Width+500) || b.Y < -500 || b.Y > float64(f.w.Height+500)
+		if !finite(b.X, b.Y, b.VX, b.VY, b.Flap) || lost {
+			*b = f.w.newBird()
+			fixes = append(fixes, fmt.Sprintf("bird %d brought back", i))
+		}
+	}
+	return fixes
+}
+
+// finite reports whether none of the values are NaN or infinite
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package sim
+
+import (
+	"maps"
+	"slices"
+)
+
+const (
+	snapshotInterval = 5  // Seconds of simulation between snapshots
+	snapshotCount    = 60 // Snapshots kept, five minutes' worth
+)
+
+// Snapshot is a copy of the simulation at one moment, which Restore can
+// return the world to
+type Snapshot struct {
+	Time float64 // When it was taken, on the Rewind's timeline
+
+	world World
+}
+
+// Cloner is implemented by entities whose state changes as they update, so
+// snapshots can keep a copy. Entities that don't implement it are shared
+// between the world and its snapshots.
+type Cloner interface {
+	Clone(w *World) Entity
+}
+
+// Clone copies the flock for w
+func (f *Flock) Clone(w *World) Entity {
+	return &Flock{w: w, Birds: slices.Clone(f.Birds)}
+}
+
+// Clone makes the clouds entity for w
+func (b *Cloudbank) Clone(w *World) Entity { return &Cloudbank{w} }
+
+// Clone makes the cloud shadows entity for w
+func (s *CloudShadows) Clone(w *World) Entity { return &CloudShadows{w} }
+
+// Clone makes the trees entity for w
+func (g *Grove) Clone(w *World) Entity { return &Grove{w: w} }
+
+// Clone makes the props entity for w
+func (s *PropSet) Clone(w *World) Entity { return &PropSet{w: w} }
+
+// Snapshot copies the world as it is now
+func (w *World) Snapshot() *Snapshot {
+	s := &Snapshot{world: *w}
+	c := &s.world
+	c.Clouds = slices.Clone(w.Clouds)
+	c.Trees = slices.Clone(w.Trees)
+	c.Props = slices.Clone(w.Props)
+	c.Contrail = slices.Clone(w.Contrail)
+	c.Transition.From = maps.Clone(w.Transition.From)
+	c.Transition.To = maps.Clone(w.Transition.To)
+	c.updating = nil // Scratch the world keeps using
+	for l := range w.layers {
+		c.layers[l] = cloneEntities(w.layers[l], w)
+	}
+	return s
+}
+
+// Restore returns the world to a snapshot. Settings that aren't part of the
+// simulation, such as the palette, reduced motion and the director, are left
+// alone, and SimTime keeps counting so timers outside the simulation carry
+// on.
+func (w *World) Restore(s *Snapshot) {
+	keep := *w
+	*w = s.world
+	w.Clouds = slices.Clone(w.Clouds)
+	w.Trees = slices.Clone(w.Trees)
+	w.Props = slices.Clone(w.Props)
+	w.Contrail = slices.Clone(w.Contrail)
+	w.Transition.From = maps.Clone(w.Transition.From)
+	w.Transition.To = maps.Clone(w.Transition.To)
+	for l := range w.layers {
+		w.layers[l] = cloneEntities(s.world.layers[l], w)
+	}
+
+	w.Layout = keep.Layout
+	w.Palette, w.PaletteName, w.PaletteColors = keep.Palette, keep.PaletteName, keep.PaletteColors
+	w.ReducedMotion = keep.ReducedMotion
+	w.Director = keep.Director
+	w.Rand, w.OnEvent = keep.Rand, keep.OnEvent
+	w.nextID = keep.nextID // IDs are never reused
+	w.nextAirplane += keep.SimTime - w.SimTime
+	w.SimTime = keep.SimTime
+}
+
+func cloneEntities(es []Entity, w *World) []Entity {
+	out := make([]Entity, len(es))
+	for i, e := range es {
+		if c, ok := e.(Cloner); ok {
+			out[i] = c.Clone(w)
+		} else {
+			out[i] = e
+		}
+	}
+	return out
+}
+
+// Rewind keeps a snapshot of the world every few seconds for the last few
+// minutes, so the simulation itself can be taken back, not just edits
+type Rewind struct {
+	snaps []*Snapshot // Oldest first
+	shift float64     // Simulated time skipped over by restores
+}
+
+// Now is the current time on the rewind timeline: SimTime less the time
+// taken back by Back
+func (r *Rewind) Now(w *World) float64 {
+	return w.SimTime - r.shift
+}
+
+// Record takes a snapshot if enough time has passed since the last one,
+// dropping the oldest when the buffer is full. Call it after each Step.
+func (r *Rewind) Record(w *World) {
+	now := r.Now(w)
+	if n := len(r.snaps); n > 0 && now-r.snaps[n-1].Time < snapshotInterval {
+		return
+	}
+	s := w.Snapshot()
+	s.Time = now
+	if len(r.snaps) == snapshotCount {
+		r.snaps = slices.Delete(r.snaps, 0, 1)
+	}
+	r.snaps = append(r.snaps, s)
+}
+
+// Snapshots lists the kept snapshots, oldest first
+func (r *Rewind) Snapshots() []*Snapshot {
+	return r.snaps
+}
+
+// Back restores the newest snapshot at least minAge seconds old and forgets
+// it and any newer ones, so going back again goes further. It returns how
+// many seconds were taken back, or false with no snapshot that old.
+func (r *Rewind) Back(w *World, minAge float64) (float64, bool) {
+	now := r.Now(w)
+	i := len(r.snaps) - 1
+	for i >= 0 && now-r.snaps[i].Time < minAge {
+		i--
+	}
+	if i < 0 {
+		return 0, false
+	}
+	s := r.snaps[i]
+	w.Restore(s)
+	r.snaps = r.snaps[:i]
+	r.shift += now - s.Time
+	return now - s.Time, true
+}
+
+// Clear forgets every snapshot, such as when a different scene is loaded
+func (r *Rewind) Clear() {
+	r.snaps = nil
+}
@@ -0,0 +1,53 @@
+package sim
+
+import (
+	"math"
+
+	"cloudapp/pkg/scene"
+)
+
+const (
+	shootingStarChance = 1.0 / 600 // Chance per tuned step while the sun is low
+	ShootingStarLife   = 1.2       // Seconds a shooting star stays visible
+)
+
+type ShootingStar struct {
+	X, Y   float64
+	VX, VY float64
+	Age    float64
+	Active bool
+}
+
+// sunIsLow reports whether the sun sits close enough to the horizon for the
+// sky to be dim enough to see shooting stars
+func (w *World) sunIsLow() bool {
+	return w.SunY > float64(w.Height-scene.GroundHeight-60)
+}
+
+func (w *World) updateShootingStar(dt float64) {
+	star := &w.ShootingStar
+
+	if !star.Active {
+		// Streaking stars are left out in reduced motion mode
+		if w.sunIsLow() && !w.ReducedMotion && w.Rand.Float64() < shootingStarChance*dt*tuneRate {
+			angle := math.Pi/6 + w.Rand.Float64()*math.Pi/6 // Falling steeply to the right
+			speed := 8 + w.Rand.Float64()*4
+			*star = ShootingStar{
+				X:      w.Rand.Float64() * float64(w.Width) * 0.7,
+				Y:      20 + w.Rand.Float64()*float64(w.Height)*0.2,
+				VX:     math.Cos(angle) * speed,
+				VY:     math.Sin(angle) * speed,
+				Active: true,
+			}
+			w.event("shooting star spotted")
+		}
+		return
+	}
+
+	star.X += star.VX * dt * tuneRate
+	star.Y += star.VY * dt * tuneRate
+	star.Age += dt
+	if star.Age >= ShootingStarLife {
+		star.Active = false
+	}
+}
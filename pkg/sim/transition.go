@@ -0,0 +1,90 @@
+package sim
+
+import (
+	"math"
+)
+
+const slotTransitionTime = 1.2 // Seconds to glide between arrangements
+
+// Point is a position on screen
+type Point struct{ X, Y float64 }
+
+// Transition glides the sun and trees from where they were to where a
+// loaded slot puts them, instead of jumping
+type Transition struct {
+	Active   bool
+	Progress float64 // 0-1
+	FromSun  Point
+	ToSun    Point
+	From     map[int]Point // Tree ID -> starting position
+	To       map[int]Point // Tree ID -> final position
+}
+
+// StepTransition advances the slot transition, easing in and out
+func (w *World) StepTransition(dt float64) {
+	tr := &w.Transition
+	if !tr.Active {
+		return
+	}
+	tr.Progress = math.Min(1, tr.Progress+dt/slotTransitionTime)
+	if w.ReducedMotion {
+		tr.Progress = 1 // Jump straight to the end
+	}
+	ease := tr.Progress * tr.Progress * (3 - 2*tr.Progress) // Smoothstep
+
+	w.SunX = tr.FromSun.X + (tr.ToSun.X-tr.FromSun.X)*ease
+	w.SunY = tr.FromSun.Y + (tr.ToSun.Y-tr.FromSun.Y)*ease
+
+	for i := range w.Trees {
+		t := &w.Trees[i]
+		start, ok := tr.From[t.ID]
+		if !ok {
+			continue
+		}
+		end := tr.To[t.ID]
+		t.X = start.X + (end.X-start.X)*ease
+		t.Y = start.Y + (end.Y-start.Y)*ease
+	}
+
+	if tr.Progress >= 1 {
+		tr.Active = false
+	}
+}
+
+// FinishTransition snaps everything to its final position, used when the
+// user grabs something mid-transition
+func (w *World) FinishTransition() {
+	if w.Transition.Active {
+		w.StepTransition(slotTransitionTime)
+	}
+}
+
+// GlideSun moves the sun smoothly using the slot transition
+func (w *World) GlideSun(x, y float64) {
+	w.Transition = Transition{Active: true, FromSun: Point{w.SunX, w.SunY}, ToSun: Point{x, y}}
+}
+
+// GlideFrom starts a transition from an earlier arrangement to the current
+// one, so the sun and any trees present in both glide into place
+func (w *World) GlideFrom(sunX, sunY float64, trees []Tree) {
+	from := make(map[int]Point, len(trees))
+	for _, t := range trees {
+		from[t.ID] = Point{t.X, t.Y}
+	}
+
+	tr := Transition{
+		Active:  true,
+		FromSun: Point{sunX, sunY},
+		ToSun:   Point{w.SunX, w.SunY},
+		From:    map[int]Point{},
+		To:      map[int]Point{},
+	}
+	for _, t := range w.Trees {
+		if start, ok := from[t.ID]; ok {
+			tr.From[t.ID] = start
+			tr.To[t.ID] = Point{t.X, t.Y}
+		}
+	}
+	w.Transition = tr
+	w.StepTransition(0)
+}
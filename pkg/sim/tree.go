@@ -0,0 +1,161 @@
+package sim
+
+import (
+	"math"
+
+	"cloudapp/pkg/gfx"
+	"cloudapp/pkg/scene"
+)
+
+type Tree struct {
+	ID    int
+	Name  string // Optional user-assigned name
+	X, Y  float64
+	Size  float64
+	Shade float64
+	Shape int // One of the scene.Shape constants
+
+	Seed      int64                // Branching trees are grown from this
+	Structure *scene.TreeStructure // Branches and leaves of a branching tree
+	Locked    bool                 // Kept where it is, however it's dragged
+}
+
+// Grove is the trees standing on the ground, kept in World.Trees. It is an
+// Entity in LayerGround whose members are the trees one by one, so each is
+// drawn in depth order among the props and objects.
+type Grove struct {
+	w       *World
+	trees   []TreeEntity // Reused by Members every frame
+	members []Entity
+}
+
+// Update does nothing, as trees sway by SimTime as they are drawn
+func (g *Grove) Update(dt float64) {}
+
+// Members is a TreeEntity for each tree, in the order of World.Trees. The
+// slice is reused, so it is only good until the next call.
+func (g *Grove) Members() []Entity {
+	trees := g.w.Trees
+	if cap(g.trees) < len(trees) {
+		g.trees = make([]TreeEntity, len(trees))
+	}
+	g.trees, g.members = g.trees[:len(trees)], g.members[:0]
+	for i := range trees {
+		g.trees[i] = TreeEntity{g.w, &trees[i]}
+		g.members = append(g.members, &g.trees[i])
+	}
+	return g.members
+}
+
+func (g *Grove) Draw(r gfx.Renderer) {
+	for _, t := range g.Members() {
+		t.Draw(r)
+	}
+}
+
+// TreeEntity is one tree of the Grove. It points into World.Trees, so it is
+// only good until the trees next change.
+type TreeEntity struct {
+	w    *World
+	Tree *Tree
+}
+
+func (t *TreeEntity) Update(dt float64) {}
+
+func (t *TreeEntity) Depth() float64 {
+	return t.Tree.Y
+}
+
+// Draw draws the tree plainly as its trunk under a round crown. The render
+// package draws its shape, light and shadow instead.
+func (t *TreeEntity) Draw(r gfx.Renderer) {
+	tree, p := t.Tree, t.w.Palette
+	trunkWidth, trunkHeight := tree.Size*0.2, tree.Size*0.4
+	r.DrawRect(tree.X-trunkWidth/2, tree.Y-trunkHeight, trunkWidth, trunkHeight, p.Trunk)
+	radius := math.Min(tree.Size*0.5, (tree.Height()-trunkHeight)/2)
+	r.DrawCircle(tree.X, tree.Y-trunkHeight-radius, radius, p.Foliage)
+}
+
+// newTree plants a tree of random size and shape somewhere on the ground
+func (w *World) newTree() Tree {
+	// Calculate random position within the ground area
+	baseY := w.GroundTop() + w.Rand.Float64()*float64(scene.GroundHeight-scene.GroundOffset)
+	seed := int64(w.Rand.Int31()) // Fits a float64 exactly, as Decode reads through one
+	return Tree{
+		ID:        w.NewID(),
+		X:         w.TreeX(w.Rand.Float64()),
+		Y:         baseY,
+		Size:      50 + w.Rand.Float64()*30,   // Random size between 50-80
+		Shade:     0.7 + w.Rand.Float64()*0.3, // Random shade variation
+		Shape:     scene.ShapeBranching,       // Every new tree grows differently
+		Seed:      seed,
+		Structure: scene.GrowTree(seed),
+	}
+}
+
+// PlantTree makes a tree of random size and shade standing at x, y, grown
+// afresh when it branches. It isn't added to Trees, so the caller can add
+// it as it sees fit, such as through an undoable edit.
+func (w *World) PlantTree(x, y float64, shape int) Tree {
+	t := Tree{
+		ID:    w.NewID(),
+		X:     x,
+		Y:     y,
+		Size:  50 + w.Rand.Float64()*30,
+		Shade: 0.7 + w.Rand.Float64()*0.3,
+		Shape: shape,
+	}
+	if shape == scene.ShapeBranching {
+		t.Seed = int64(w.Rand.Int31())
+		t.Structure = scene.GrowTree(t.Seed)
+	}
+	return t
+}
+
+// ShadowGeometry returns the length of the shadow image a tree casts and the
+// direction it points in for the current sun position and shadow scale
+func (w *World) ShadowGeometry(tree *Tree) (shadowLength, shadowAngle float64) {
+	return w.ShadowGeometryAt(tree.X, tree.Y, tree.Size)
+}
+
+// ShadowGeometryAt is ShadowGeometry for anything standing at x, y that is
+// as big as a tree of the given size
+func (w *World) ShadowGeometryAt(x, y, size float64) (shadowLength, shadowAngle float64) {
+	sunX, sunY := w.SunX, w.SunY
+	// Calculate distance and angle to sun
+	dx := x - sunX
+	dy := y - sunY
+	distanceToSun := math.Sqrt(dx*dx + dy*dy)
+	shadowAngle = math.Atan2(y-sunY, x-sunX)
+
+	// Calculate distance factor (shadows get longer when sun is closer)
+	maxDistance := math.Sqrt(float64(w.Width*w.Width + w.Height*w.Height))
+	distanceFactor := math.Max(0.5, 1.0-distanceToSun/maxDistance) * 2.0
+
+	// Calculate shadow length based on sun height and distance
+	sunHeight := float64(w.Height) - sunY
+	heightFactor := math.Max(0.2, sunHeight/float64(w.Height)) // Prevents extremely short shadows when sun is at bottom
+	baseShadowLength := size * 2.0                             // Base shadow length
+
+	// Shadow gets longer as sun gets lower and closer to horizon
+	shadowLength = baseShadowLength * (1 / heightFactor) * distanceFactor
+
+	// Shadow gets shorter when sun is directly overhead
+	verticalAngleFactor := math.Abs(math.Sin(shadowAngle))
+	shadowLength *= (0.3 + 0.7*verticalAngleFactor) // Maintains minimum shadow length
+
+	// Calculate shadow length and apply treeShadow factor
+	shadowLength *= w.TreeShadow // new scaling for tree shadows
+	return shadowLength, shadowAngle
+}
+
+// Height is how far the top of the crown sits above the base of the trunk
+func (t *Tree) Height() float64 {
+	switch t.Shape {
+	case scene.ShapeTriangle:
+		return t.Size * 1.6 // Triangle tip
+	case scene.ShapeBranching:
+		return t.Size * scene.BranchingHeight
+	}
+	return t.Size * 1.41 // Top of the upper oval or circle
+}
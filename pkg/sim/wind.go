@@ -0,0 +1,25 @@
+package sim
+
+import (
+	"math"
+)
+
+// Wind is a slowly gusting breeze that carries the clouds and drives the
+// wind turbines and windsock
+type Wind struct {
+	Base  float64 // Prevailing strength the gusts vary around
+	Speed float64 // Current strength as a multiple of each cloud's base speed
+}
+
+func NewWind(base float64) Wind {
+	return Wind{Base: base, Speed: base}
+}
+
+// update recomputes the wind strength from a few layered sine waves, giving
+// gusts that rise and fall smoothly without any random jumps
+func (w *Wind) update(simTime float64) {
+	gust := 0.35*math.Sin(simTime*0.21) +
+		0.2*math.Sin(simTime*0.53+1.3) +
+		0.1*math.Sin(simTime*1.7+0.4)
+	w.Speed = math.Max(0, w.Base*(1+gust))
+}
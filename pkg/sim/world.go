@@ -0,0 +1,355 @@
+package sim
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"time"
+
+	"cloudapp/pkg/scene"
+)
+
+// SunRadius is the size of the sun, which is also how close to the edges of
+// the sky it can be dragged
+const SunRadius = 40
+
+// dryingTime is how many seconds soaked ground takes to dry out
+const dryingTime = 180
+
+// tuneRate is the number of steps a second that per-step speeds in the
+// simulation were tuned at. Step scales them by how many such steps dt
+// covers, so the result doesn't depend on how often it is called.
+const tuneRate = 60
+
+// World is the simulated scene: the sky, sun, clouds, trees, props and the
+// birds and planes moving among them. It has no notion of input or drawing,
+// so it can be stepped from any program and drawn with the render package.
+type World struct {
+	scene.Layout // Size of the area the scene fills, in pixels
+
+	Clouds        []Cloud
+	Trees         []Tree
+	Props         []Prop
+	Density       float64 // Share of the clouds shown, 0-1
+	Wetness       float64 // How wet the ground is, 0-1. It dries out over time.
+	SunX, SunY    float64
+	SunLocked     bool // The sun stays put when dragged
+	Wind          Wind
+	Airplane      Airplane
+	Contrail      []ContrailPuff
+	ShootingStar  ShootingStar
+	Transition    Transition
+	Animation     *scene.Animation // Playing animation, or nil
+	AnimationTime float64          // Seconds into the animation
+	Director      Director         // Keeps the weather to a mood, when one is set
+	Palette       scene.Palette
+	PaletteName   string
+	PaletteColors map[string]string // Colors picked over the named palette's, by field name. Replaced, never changed in place.
+
+	TreeCount  int
+	CloudCount int     // Clouds shown while ExactClouds is set
+	MaxClouds  int     // Clouds in the pool the counts draw from
+	TreeShadow float64 // Shadow scale factor, 1 is the default length
+
+	// ExactClouds shows CloudCount clouds instead of a share set by Density
+	ExactClouds bool
+
+	ReducedMotion   bool
+	ScarecrowRadius float64 // Distance birds keep from a scarecrow until they get used to it
+
+	SimTime    float64 // Seconds of simulated time
+	MotionTime float64 // Like SimTime but slowed in reduced motion mode, drives cloud billowing
+
+	// Rand drives all scene randomness so a fixed seed reproduces a scene
+	Rand *rand.Rand
+
+	// OnEvent, when set, is told about notable happenings such as an
+	// airplane crossing the sky
+	OnEvent func(text string)
+
+	nextAirplane float64 // SimTime at which the next airplane appears
+	nextID       int     // Last entity ID handed out
+	sunDown      bool    // The sun was down at the last step, for telling when it sets or rises
+
+	layers   [numLayers][]Entity // Added with Add, drawn layer by layer
+	updating []Entity            // The layer being updated, reused every step
+}
+
+// Options sets up a new World
+type Options struct {
+	Width, Height   int
+	CloudCount      int
+	TreeCount       int
+	Density         float64
+	Palette         string
+	Wind            float64
+	ReducedMotion   bool
+	ScarecrowRadius float64
+	Margins         scene.Margins // Zero picks scene.DefaultMargins
+	Rand            *rand.Rand    // Nil picks a time-seeded generator
+}
+
+// New creates a world with randomly placed clouds and trees, a flock of
+// birds and a windsock
+func New(opts Options) *World {
+	r := opts.Rand
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if opts.Margins == (scene.Margins{}) {
+		opts.Margins = scene.DefaultMargins
+	}
+	w := &World{
+		Layout:          scene.Layout{Width: opts.Width, Height: opts.Height, Margins: opts.Margins},
+		Clouds:          make([]Cloud, opts.CloudCount),
+		Trees:           make([]Tree, opts.TreeCount),
+		Density:         opts.Density,
+		Palette:         scene.Palettes[opts.Palette],
+		PaletteName:     opts.Palette,
+		SunX:            float64(opts.Width / 2),
+		SunY:            float64(opts.Height - scene.GroundHeight - 10),
+		Wind:            NewWind(opts.Wind),
+		TreeCount:       opts.TreeCount,
+		CloudCount:      opts.CloudCount,
+		MaxClouds:       opts.CloudCount,
+		TreeShadow:      1.0, // new default shadow value
+		ReducedMotion:   opts.ReducedMotion,
+		ScarecrowRadius: opts.ScarecrowRadius,
+		Rand:            r,
+		nextAirplane:    10 + r.Float64()*20,
+	}
+	w.sunDown = w.SunDown()
+
+	// Initialize clouds with random properties
+	for i := range w.Clouds {
+		w.Clouds[i] = Cloud{
+			X:         r.Float64() * float64(w.Width),
+			Y:         w.CloudY(r.Float64()), // Keep clouds in the sky band
+			Speed:     1 + r.Float64()*2,     // Random speed between 1-3
+			Size:      30 + r.Float64()*50,   // Random size between 30-80
+			Opacity:   0.3 + r.Float64()*0.5, // Random opacity between 0.3-0.8
+			ShapeSeed: r.Float64() * 2 * math.Pi,
+		}
+	}
+
+	// A single windsock shows which way the wind is blowing
+	w.Props = append(w.Props, w.NewProp(scene.PropWindsock, float64(w.Width-80), w.GroundTop()+30))
+
+	w.Add(LayerGround, &CloudShadows{w})
+	w.Add(LayerGround, &Grove{w: w})
+	w.Add(LayerGround, &PropSet{w: w})
+	w.Add(LayerAir, w.NewFlock(numBirds))
+	w.Add(LayerTop, &Cloudbank{w})
+
+	// Initialize trees with random properties
+	for i := range w.Trees {
+		w.Trees[i] = w.newTree()
+	}
+	return w
+}
+
+// Step advances the world by dt seconds. It is the only place the world
+// changes on its own, and it needs no window or clock: two worlds with the
+// same contents and Rand seed stepped by the same dts stay identical, so the
+// simulation can be checked without a display.
+func (w *World) Step(dt float64) {
+	w.SimTime += dt
+	w.MotionTime += dt * w.MotionScale()
+
+	w.Wind.update(w.SimTime)
+	w.updateAirplane(dt)
+	w.updateEntities(dt)
+	w.updateShootingStar(dt)
+	w.StepTransition(dt)
+	w.stepAnimation(dt)
+	w.stepDirector(dt)
+	w.stepSunset()
+	w.Wetness = math.Max(0, w.Wetness-dt/dryingTime)
+}
+
+// event passes a notable happening on to OnEvent
+func (w *World) event(format string, args ...any) {
+	if w.OnEvent != nil {
+		w.OnEvent(fmt.Sprintf(format, args...))
+	}
+}
+
+// ActiveCloudCount is how many clouds are shown: CloudCount while ExactClouds
+// is set, otherwise a share of them set by the density
+func (w *World) ActiveCloudCount() int {
+	if w.ExactClouds {
+		return w.CloudCount
+	}
+	return int(math.Floor(w.Density * float64(len(w.Clouds))))
+}
+
+// UpdateTreeCount grows or shrinks the trees to TreeCount, keeping the ones
+// that are already planted
+func (w *World) UpdateTreeCount() {
+	// Update tree count based on density setting
+	oldTrees := w.Trees
+	w.Trees = make([]Tree, w.TreeCount)
+
+	// Keep existing trees if possible
+	for i := range w.Trees {
+		if i < len(oldTrees) {
+			w.Trees[i] = oldTrees[i]
+		} else {
+			w.Trees[i] = w.newTree()
+		}
+	}
+}
+
+// ActiveClouds returns the clouds currently shown
+func (w *World) ActiveClouds() []Cloud {
+	return w.Clouds[:min(w.ActiveCloudCount(), len(w.Clouds))]
+}
+
+// NewID hands out the next stable entity ID. IDs are never reused within a
+// scene, so they keep referring to the same tree or prop across edits.
+func (w *World) NewID() int {
+	w.nextID++
+	return w.nextID
+}
+
+// FindTree returns the tree with the given ID, or nil
+func (w *World) FindTree(id int) *Tree {
+	for i := range w.Trees {
+		if w.Trees[i].ID == id {
+			return &w.Trees[i]
+		}
+	}
+	return nil
+}
+
+// FindProp returns the prop with the given ID, or nil
+func (w *World) FindProp(id int) *Prop {
+	for i := range w.Props {
+		if w.Props[i].ID == id {
+			return &w.Props[i]
+		}
+	}
+	return nil
+}
+
+// Reduced motion mode is for users with vestibular or photosensitivity
+// issues. Ambient movement (drifting and billowing clouds, airplanes, birds,
+// spinning props) slows right down, shooting stars stop appearing and the sun
+// and trees jump to new positions instead of gliding. Anything added later
+// that flashes should check ReducedMotion too.
+const reducedMotionScale = 0.3
+
+// MotionScale is the speed ambient motion runs at
+func (w *World) MotionScale() float64 {
+	if w.ReducedMotion {
+		return reducedMotionScale
+	}
+	return 1
+}
+
+// motion is how many tuned steps of ambient motion dt covers
+func (w *World) motion(dt float64) float64 {
+	return dt * tuneRate * w.MotionScale()
+}
+
+// Scene captures the world's arrangement for saving
+func (w *World) Scene() scene.Scene {
+	s := scene.Scene{
+		Version:   scene.Version,
+		SunX:      w.SunX,
+		SunY:      w.SunY,
+		SunLocked: w.SunLocked,
+		Density:   w.Density,
+		Wind:      w.Wind.Base,
+		Palette:   w.PaletteName,
+		Colors:    maps.Clone(w.PaletteColors),
+		Menu: scene.Menu{
+			TreeDensity: w.TreeCount,
+			CloudCount:  w.CloudCount,
+			MaxClouds:   w.MaxClouds,
+			TreeShadow:  w.TreeShadow,
+		},
+	}
+	for _, c := range w.Clouds {
+		s.Clouds = append(s.Clouds, scene.Cloud{X: c.X, Y: c.Y, Speed: c.Speed, Size: c.Size, Opacity: c.Opacity, ShapeSeed: c.ShapeSeed, Locked: c.Locked, Water: c.Water, Raining: c.Raining})
+	}
+	for _, t := range w.Trees {
+		s.Trees = append(s.Trees, scene.Tree{ID: t.ID, Name: t.Name, X: t.X, Y: t.Y, Size: t.Size, Shade: t.Shade, Shape: t.Shape, Seed: t.Seed, Structure: t.Structure, Locked: t.Locked})
+	}
+	for _, p := range w.Props {
+		s.Props = append(s.Props, scene.Prop{ID: p.ID, Name: p.Name, Kind: p.Kind, X: p.X, Y: p.Y})
+	}
+	return s
+}
+
+// PaletteFor returns a named palette with the picked colors set on it. A
+// picked color that doesn't parse is left out.
+func (w *World) PaletteFor(name string) (scene.Palette, bool) {
+	p, ok := scene.Palettes[name]
+	if !ok {
+		return p, false
+	}
+	for field, hex := range w.PaletteColors {
+		if picked, err := p.WithColors(map[string]string{field: hex}); err == nil {
+			p = picked
+		}
+	}
+	return p, true
+}
+
+// Apply replaces the world's arrangement with a saved one
+func (w *World) Apply(s scene.Scene) {
+	w.SunX, w.SunY = s.SunX, s.SunY
+	w.SunLocked = s.SunLocked
+	w.Density = s.Density
+	w.Wind.Base = s.Wind
+	if _, ok := scene.Palettes[s.Palette]; ok {
+		w.PaletteName = s.Palette
+	}
+	w.PaletteColors = maps.Clone(s.Colors)
+	if p, ok := w.PaletteFor(w.PaletteName); ok {
+		w.Palette = p
+	}
+
+	w.TreeCount = s.Menu.TreeDensity
+	w.CloudCount = s.Menu.CloudCount
+	w.MaxClouds = s.Menu.MaxClouds
+	w.TreeShadow = s.Menu.TreeShadow
+
+	w.Clouds = make([]Cloud, len(s.Clouds))
+	for i, c := range s.Clouds {
+		w.Clouds[i] = Cloud{X: c.X, Y: c.Y, Speed: c.Speed, Size: c.Size, Opacity: c.Opacity, ShapeSeed: c.ShapeSeed, Locked: c.Locked, Water: c.Water, Raining: c.Raining}
+	}
+	// Keep saved IDs so names and references survive the round trip, only
+	// handing out new ones to entities saved without an ID
+	w.nextID = 0
+	for _, t := range s.Trees {
+		w.nextID = max(w.nextID, t.ID)
+	}
+	for _, p := range s.Props {
+		w.nextID = max(w.nextID, p.ID)
+	}
+
+	w.Trees = make([]Tree, len(s.Trees))
+	for i, t := range s.Trees {
+		w.Trees[i] = Tree{ID: t.ID, Name: t.Name, X: t.X, Y: t.Y, Size: t.Size, Shade: t.Shade, Shape: t.Shape, Seed: t.Seed, Structure: t.Structure, Locked: t.Locked}
+		if t.Shape == scene.ShapeBranching && t.Structure == nil {
+			w.Trees[i].Structure = scene.GrowTree(t.Seed) // Hand-edited files may only give a seed
+		}
+		if t.ID == 0 {
+			w.Trees[i].ID = w.NewID()
+		}
+	}
+	w.Props = make([]Prop, 0, len(s.Props))
+	for _, p := range s.Props {
+		prop := w.NewProp(p.Kind, p.X, p.Y)
+		prop.Name = p.Name
+		if p.ID != 0 {
+			prop.ID = p.ID
+		}
+		w.Props = append(w.Props, prop)
+	}
+
+	w.rearranged()
+}
@@ -0,0 +1,118 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"cloudapp/pkg/scene"
+)
+
+// newTestWorld makes a small scene with every kind of thing in it, the same
+// for the same seed
+func newTestWorld(seed int64) *World {
+	return New(Options{
+		Width:      640,
+		Height:     360,
+		CloudCount: 12,
+		TreeCount:  5,
+		Density:    0.7,
+		Palette:    "default",
+		Wind:       1.5,
+		Rand:       rand.New(rand.NewSource(seed)),
+	})
+}
+
+// sameWorld fails the test if a and b differ in anything the simulation
+// moves
+func sameWorld(t *testing.T, a, b *World) {
+	t.Helper()
+	if a.SimTime != b.SimTime {
+		t.Errorf("SimTime %v and %v differ", a.SimTime, b.SimTime)
+	}
+	if !reflect.DeepEqual(a.Scene(), b.Scene()) {
+		t.Error("scenes differ")
+	}
+	if !reflect.DeepEqual(a.Clouds, b.Clouds) {
+		t.Error("clouds differ")
+	}
+	if a.Wind != b.Wind || a.Wetness != b.Wetness || a.Airplane != b.Airplane {
+		t.Error("wind, wetness or airplane differ")
+	}
+	birds := func(w *World) []Bird { return w.Entities(LayerAir)[0].(*Flock).Birds }
+	if !reflect.DeepEqual(birds(a), birds(b)) {
+		t.Error("birds differ")
+	}
+}
+
+func TestStepIsDeterministic(t *testing.T) {
+	a, b := newTestWorld(7), newTestWorld(7)
+	for range 600 {
+		a.Step(FixedStep)
+		b.Step(FixedStep)
+	}
+	sameWorld(t, a, b)
+}
+
+func TestClockIgnoresFrameRate(t *testing.T) {
+	a, b := newTestWorld(7), newTestWorld(7)
+	var slow, fast Clock
+	for range 200 {
+		slow.Advance(a, 1.0/20)
+	}
+	for range 600 {
+		fast.Advance(b, 1.0/60)
+	}
+	sameWorld(t, a, b)
+}
+
+func TestCloudsDriftWithTheWind(t *testing.T) {
+	w := newTestWorld(3)
+	before := make([]float64, len(w.Clouds))
+	for i, c := range w.Clouds {
+		before[i] = c.X
+	}
+	w.Step(FixedStep)
+	for i, c := range w.Clouds {
+		want := before[i] + c.Speed*w.Wind.Speed
+		if want > float64(w.Width)+w.Margins.CloudWrap {
+			want = -w.Margins.CloudWrap
+		}
+		if math.Abs(c.X-want) > 1e-9 {
+			t.Errorf("cloud %d at %v after a step from %v, want %v", i, c.X, before[i], want)
+		}
+	}
+}
+
+func TestCloudsWrapAround(t *testing.T) {
+	w := newTestWorld(3)
+	if w.Margins != scene.DefaultMargins {
+		t.Fatalf("margins %+v, want the defaults", w.Margins)
+	}
+	edge := float64(w.Width) + w.Margins.CloudWrap
+	w.Clouds[0].X = edge - 0.01
+	w.Clouds[1].X, w.Clouds[1].Locked = edge-0.01, true
+	w.Step(FixedStep)
+	if got := w.Clouds[0].X; got != -w.Margins.CloudWrap {
+		t.Errorf("cloud past the edge at %v, want it wrapped to %v", got, -w.Margins.CloudWrap)
+	}
+	if got := w.Clouds[1].X; got != edge-0.01 {
+		t.Errorf("locked cloud moved to %v", got)
+	}
+}
+
+func TestTimeOfDayRoundTrip(t *testing.T) {
+	w := newTestWorld(1)
+	for _, timeOfDay := range []float64{0, 0.1, 0.25, 0.5, 0.71, 0.9, 1} {
+		w.SetTimeOfDay(timeOfDay)
+		// TimeOfDay finds the sun to the nearest hundredth of the day
+		if got := w.TimeOfDay(); math.Abs(got-timeOfDay) > 0.005+1e-9 {
+			t.Errorf("SetTimeOfDay(%v) then TimeOfDay() = %v", timeOfDay, got)
+		}
+	}
+	w.SetTimeOfDay(1.5)
+	if got := w.TimeOfDay(); got != 1 {
+		t.Errorf("SetTimeOfDay(1.5) then TimeOfDay() = %v, want it held at sunset", got)
+	}
+}